@@ -0,0 +1,122 @@
+// Command replay plays recorded board sessions (see TCP_CAPTURE_DIR) back
+// into a running server, to reproduce incidents seen in the field.
+//
+//	go run ./cmd/replay -addr localhost:50066 -speed 10 captures/20260212-101504-0001.jsonl
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fall-detection/internal/capture"
+	"fall-detection/internal/telemetry"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:50066", "server address")
+	speed := flag.Float64("speed", 1, "playback speed multiplier, 0 replays as fast as possible")
+	secret := flag.String("secret", "", "hex board secret, answers the auth handshake when set")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] capture.jsonl...\n", "replay")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		return
+	}
+	if *speed < 0 {
+		log.Fatal("Speed must not be negative")
+	}
+
+	var key []byte
+	if *secret != "" {
+		var err error
+		key, err = hex.DecodeString(*secret)
+		if err != nil {
+			log.Fatal("Invalid secret: ", err)
+		}
+	}
+
+	// Sessions are replayed concurrently, as they originally happened
+	var wg sync.WaitGroup
+	for _, path := range flag.Args() {
+		records, err := capture.ReadFile(path)
+		if err != nil {
+			log.Fatal("Error reading capture: ", err)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := replay(*addr, records, *speed, key); err != nil {
+				log.Printf("[REPLAY] %s: %v", path, err)
+				return
+			}
+			log.Printf("[REPLAY] %s: done", path)
+		}()
+	}
+	wg.Wait()
+}
+
+func replay(addr string, records []capture.Record, speed float64, secret []byte) error {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	if secret != nil {
+		boardID := capture.BoardID(records)
+		if boardID == "" {
+			return errors.New("capture has no board ID to authenticate as")
+		}
+		if err := handshake(conn, reader, boardID, secret); err != nil {
+			return fmt.Errorf("handshake: %w", err)
+		}
+	}
+
+	// Commands sent by the server are not answered, just drained
+	go io.Copy(io.Discard, reader)
+
+	return capture.Replay(conn, records, speed)
+}
+
+// handshake answers the server's challenge, see telemetry/handshake.go.
+func handshake(conn net.Conn, reader *bufio.Reader, boardID string, secret []byte) error {
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	defer conn.SetDeadline(time.Time{})
+
+	if _, err := io.WriteString(conn, telemetry.HelloLine(boardID)+"\n"); err != nil {
+		return err
+	}
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	nonce, err := telemetry.ParseChallenge(line)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(conn, telemetry.AuthLine(telemetry.SignChallenge(secret, nonce, boardID))+"\n"); err != nil {
+		return err
+	}
+	line, err = reader.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(line) != telemetry.AuthOK {
+		return errors.New("authentication denied")
+	}
+	return nil
+}
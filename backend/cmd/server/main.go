@@ -1,14 +1,18 @@
 package main
 
 import (
+	"encoding/json"
 	"fall-detection/internal/alert"
+	"fall-detection/internal/capture"
 	"fall-detection/internal/config"
 	"fall-detection/internal/database"
+	"fall-detection/internal/detection"
 	"fall-detection/internal/http"
 	"fall-detection/internal/http/handlers"
 	"fall-detection/internal/mqtt"
 	"fall-detection/internal/repository"
 	"fall-detection/internal/tcp"
+	"fall-detection/internal/timeseries"
 	"log"
 
 	pahomqtt "github.com/eclipse/paho.mqtt.golang"
@@ -25,16 +29,102 @@ func main() {
 	defer db.Close()
 
 	publishClient := mqtt.CreateClient("publisher")
-	tcpServer := tcp.NewTCPServer(":"+config.TCPPort, publishClient)
+	boardCredentialRepo := repository.NewBoardCredentialRepo(db)
+	boardRepo := repository.NewBoardRepo(db)
+	residentRepo := repository.NewResidentRepo(db)
+	var tcpAddr string
+	if config.TCPPort != "" {
+		tcpAddr = ":" + config.TCPPort
+	}
+	tcpServer := tcp.NewTCPServer(tcpAddr, publishClient, boardCredentialRepo, config.BoardAuthRequired)
+	tcpServer.Registry = boardRepo
+
+	detectionProfileRepo := repository.NewDetectionProfileRepo(db)
+	tcpServer.Profiles = detectionProfileRepo
+
+	// Replay the firmware state machine server-side and flag disagreements
+	verifier := detection.NewVerifier()
+	verifier.OnDisagreement = func(d detection.Disagreement) {
+		payload, _ := json.Marshal(d)
+		mqtt.Publish(publishClient, "fall-detection/board"+d.BoardID+"/verification", string(payload))
+	}
+	tcpServer.Verifier = verifier
+
+	sensorReadingRepo := repository.NewSensorReadingRepo(db)
+	telemetryWriter := timeseries.NewWriter(sensorReadingRepo)
+	tcpServer.Storage = telemetryWriter
 
-	subscriptionRepo := repository.NewSubscriptionRepo(db)
 	fallEventRepo := repository.NewFallEventRepo(db)
+	fallEventTraceRepo := repository.NewFallEventTraceRepo(db)
+	tcpServer.FallEvents = fallEventRepo
+	tcpServer.Traces = fallEventTraceRepo
+
+	inactivitySettingsRepo := repository.NewInactivitySettingsRepo(db)
+	inactivityMonitor := detection.NewInactivityMonitor(detection.InactivitySettings{
+		Enabled: config.InactivityPeriod > 0,
+		Period:  config.InactivityPeriod,
+	}, config.FacilityLocation)
+	tcpServer.Inactivity = inactivityMonitor
+	tcpServer.InactivitySettings = inactivitySettingsRepo
+
+	if config.TCPTLSPort != "" && config.TCPTLSCert != "" && config.TCPTLSKey != "" {
+		tlsConfig, err := tcp.LoadTLSConfig(config.TCPTLSCert, config.TCPTLSKey, config.TCPTLSClientCA)
+		if err != nil {
+			log.Fatal("Error loading TLS config: ", err)
+		}
+		tcpServer.TLSAddr = ":" + config.TCPTLSPort
+		tcpServer.TLSConfig = tlsConfig
+	}
+
+	if config.CaptureDir != "" {
+		recorder, err := capture.NewRecorder(config.CaptureDir)
+		if err != nil {
+			log.Fatal("Error creating capture recorder: ", err)
+		}
+		recorder.MaxBytes = config.CaptureMaxBytes
+		recorder.MaxAge = config.CaptureMaxAge
+		tcpServer.Recorder = recorder
+	}
+
+	subscriptionRepo := repository.NewSubscriptionRepo(db)
+	outboxRepo := repository.NewOutboxRepo(db)
 	alertClient := mqtt.CreateClient("alert-subscriber")
 
-	alertService, err := alert.NewAlert(alertClient, subscriptionRepo, fallEventRepo, config.BotToken, tcpServer)
+	alertService, err := alert.NewAlert(alertClient, subscriptionRepo, fallEventRepo, outboxRepo, config.BotToken, tcpServer)
 	if err != nil {
 		log.Fatal("Error creating alert service: ", err)
 	}
+	alertService.OfflineGracePeriod = config.BoardOfflineGrace
+	alertService.Bot.Boards = boardRepo
+	alertService.Bot.Residents = residentRepo
+
+	acknowledgementRepo := repository.NewAcknowledgementRepo(db)
+	alertService.Bot.Acknowledgements = acknowledgementRepo
+
+	escalationRepo := repository.NewEscalationRepo(db)
+	alertService.Telegram.Escalation.Repo = escalationRepo
+
+	// Further alert channels alongside Telegram
+	webhookRepo := repository.NewWebhookRepo(db)
+	alertService.Notifiers.Add(alert.NewWebhooks(alertService.Bot, webhookRepo), alert.WebhookTimeout)
+
+	emailSubscriptionRepo := repository.NewEmailSubscriptionRepo(db)
+	var mailer *alert.Mailer
+	if config.SMTPHost != "" {
+		mailer, err = alert.NewMailer(alert.SMTPConfig{
+			Host:        config.SMTPHost,
+			Port:        config.SMTPPort,
+			Username:    config.SMTPUsername,
+			Password:    config.SMTPPassword,
+			From:        config.SMTPFrom,
+			StartTLS:    config.SMTPStartTLS,
+			ImplicitTLS: config.SMTPImplicitTLS,
+		}, emailSubscriptionRepo, alertService.Bot)
+		if err != nil {
+			log.Fatal("Error creating mailer: ", err)
+		}
+		alertService.Notifiers.Add(mailer, alert.EmailTimeout)
+	}
 	log.Println("[Main] About to call alertService.Start()")
 	alertService.Start()
 	log.Println("[Main] alertService.Start() completed")
@@ -46,13 +136,29 @@ func main() {
 	clients := map[string]pahomqtt.Client{
 		"publisher": publishClient,
 	}
-	healthHandler := handlers.NewHealthHandler(clients)
-	boardHandler := handlers.NewBoardHandler(tcpServer)
+	healthHandler := handlers.NewHealthHandler(clients, tcpServer)
+	boardHandler := handlers.NewBoardHandler(tcpServer, boardRepo, residentRepo, boardCredentialRepo)
 	subscribersHandler := handlers.NewSubscribersHandler(subscriptionRepo)
-	fallEventsHandler := handlers.NewFallEventsHandler(fallEventRepo)
+	fallEventsHandler := handlers.NewFallEventsHandler(fallEventRepo, fallEventTraceRepo, acknowledgementRepo)
+	profilesHandler := handlers.NewProfilesHandler(detectionProfileRepo, tcpServer)
+	verificationHandler := handlers.NewVerificationHandler(verifier)
+	readingsHandler := handlers.NewReadingsHandler(sensorReadingRepo)
+	inactivityHandler := handlers.NewInactivityHandler(inactivitySettingsRepo, inactivityMonitor)
+	residentsHandler := handlers.NewResidentsHandler(residentRepo, fallEventRepo, acknowledgementRepo)
+	escalationHandler := handlers.NewEscalationHandler(escalationRepo, fallEventRepo, subscriptionRepo)
+	acknowledgementsHandler := handlers.NewAcknowledgementsHandler(acknowledgementRepo)
+	analyticsHandler := handlers.NewAnalyticsHandler(fallEventRepo, boardRepo, config.FacilityLocation)
+	webhooksHandler := handlers.NewWebhooksHandler(webhookRepo)
+	emailSubscriptionsHandler := handlers.NewEmailSubscriptionsHandler(emailSubscriptionRepo, mailer)
+	outboxHandler := handlers.NewOutboxHandler(outboxRepo, alertService.Notifiers)
+	subscriptionAccessHandler := handlers.NewSubscriptionAccessHandler(subscriptionRepo, alertService.Bot)
 
-	httpServer := http.New(config.HTTPPort, healthHandler, boardHandler, subscribersHandler, fallEventsHandler)
+	if len(config.AdminTokens) == 0 {
+		log.Println("[Main] ADMIN_TOKENS is not set, admin routes will refuse every request")
+	}
+	httpServer := http.New(config.HTTPPort, config.CORSOrigins, config.AdminTokens, healthHandler, boardHandler, subscribersHandler, fallEventsHandler, profilesHandler, verificationHandler, readingsHandler, inactivityHandler, residentsHandler, escalationHandler, acknowledgementsHandler, analyticsHandler, webhooksHandler, emailSubscriptionsHandler, outboxHandler, subscriptionAccessHandler)
 
+	go telemetryWriter.Run()
 	go tcpServer.Start()
 	go httpServer.Run()
 
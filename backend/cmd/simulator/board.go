@@ -0,0 +1,342 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fall-detection/internal/telemetry"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	basePressure   = 1013.25 // hPa at standing height
+	fallPressure   = 0.12    // hPa rise for a ~1m drop
+	firmware       = "sim-1.0"
+	confirmedAfter = 2200 * time.Millisecond // LYING_DETECTION_TIME plus a sample
+)
+
+// virtualBoard plays a scenario over one TCP connection at a time, behaving
+// like the firmware in main.c.
+type virtualBoard struct {
+	id       int
+	scenario *Scenario
+	secret   []byte
+
+	conn    net.Conn
+	writeMu sync.Mutex
+
+	// Guarded by mu since RESET_FALL commands arrive on the reader goroutine
+	mu        sync.Mutex
+	state     telemetry.FallState
+	fallSince time.Time
+	pressure  float64
+	seq       uint32
+	battery   float64
+	started   time.Time
+}
+
+func newVirtualBoard(id int, sc *Scenario, secret []byte) *virtualBoard {
+	return &virtualBoard{
+		id:       id,
+		scenario: sc,
+		secret:   secret,
+		pressure: basePressure,
+		battery:  100,
+		started:  time.Now(),
+	}
+}
+
+func (b *virtualBoard) logf(format string, args ...any) {
+	log.Printf("[SIM board%d] "+format, append([]any{b.id}, args...)...)
+}
+
+// Run plays the scenario, reconnecting whenever the connection drops.
+func (b *virtualBoard) Run() {
+	for {
+		for _, step := range b.scenario.Steps {
+			if err := b.play(step); err != nil {
+				b.logf("%s failed: %v, reconnecting in 2s", step.Action, err)
+				b.disconnect()
+				time.Sleep(2 * time.Second)
+			}
+		}
+		if !b.scenario.Loop {
+			b.disconnect()
+			b.logf("scenario finished")
+			return
+		}
+	}
+}
+
+func (b *virtualBoard) play(step Step) error {
+	b.logf("%s %s", step.Action, step.duration)
+
+	switch step.Action {
+	case ActionDisconnect:
+		b.disconnect()
+		time.Sleep(step.duration)
+		return nil
+
+	case ActionStale:
+		if err := b.ensureConnected(); err != nil {
+			return err
+		}
+		time.Sleep(step.duration)
+		return nil
+
+	case ActionNFCReset:
+		b.resetFall()
+		return b.emit(step.duration, b.idleSample)
+
+	case ActionFall:
+		return b.fall(step.duration)
+
+	case ActionWalk:
+		return b.emit(step.duration, b.walkSample)
+
+	case ActionLie:
+		return b.emit(step.duration, b.lyingSample)
+
+	default:
+		return b.emit(step.duration, b.idleSample)
+	}
+}
+
+// fall reproduces the firmware's freefall → impact → lying sequence. The
+// board reports StateFallConfirmed once it has been still for
+// LYING_DETECTION_TIME, and stays there until reset.
+func (b *virtualBoard) fall(d time.Duration) error {
+	b.setState(telemetry.StateFreefallDetected)
+	if err := b.send(b.freefallSample); err != nil {
+		return err
+	}
+
+	b.setState(telemetry.StateImpactDetected)
+	b.mu.Lock()
+	b.pressure = basePressure + fallPressure
+	b.fallSince = time.Now()
+	b.mu.Unlock()
+	if err := b.send(b.impactSample); err != nil {
+		return err
+	}
+
+	return b.emit(d, func(t float64) [6]float64 {
+		b.mu.Lock()
+		if b.state == telemetry.StateImpactDetected && time.Since(b.fallSince) >= confirmedAfter {
+			b.state = telemetry.StateFallConfirmed
+			b.logf("fall confirmed")
+		}
+		b.mu.Unlock()
+		return b.lyingSample(t)
+	})
+}
+
+// emit sends a sample every interval for d.
+func (b *virtualBoard) emit(d time.Duration, sample func(t float64) [6]float64) error {
+	deadline := time.Now().Add(d)
+	for {
+		if err := b.send(sample); err != nil {
+			return err
+		}
+		if !time.Now().Add(b.scenario.interval).Before(deadline) {
+			return nil
+		}
+		time.Sleep(b.scenario.interval)
+	}
+}
+
+func (b *virtualBoard) send(sample func(t float64) [6]float64) error {
+	if err := b.ensureConnected(); err != nil {
+		return err
+	}
+
+	v := sample(time.Since(b.started).Seconds())
+
+	b.mu.Lock()
+	b.seq++
+	b.battery = math.Max(0, b.battery-0.0005)
+	r := telemetry.Reading{
+		AccelX: v[0], AccelY: v[1], AccelZ: v[2],
+		GyroX: v[3], GyroY: v[4], GyroZ: v[5],
+		FallStatus: b.state == telemetry.StateFallConfirmed,
+		BoardID:    strconv.Itoa(b.id),
+		FallState:  b.state,
+		Pressure:   b.pressure + noise(0.02),
+		Seq:        b.seq,
+		Battery:    b.battery,
+		Firmware:   firmware,
+		RSSI:       -55 + rand.Intn(11) - 5,
+	}
+	b.mu.Unlock()
+
+	line, err := r.EncodeVersion(b.scenario.Protocol)
+	if err != nil {
+		return err
+	}
+	return b.writeLine(line)
+}
+
+// Sample generators return accel xyz (m/s²) and gyro xyz in firmware units.
+
+func (b *virtualBoard) idleSample(t float64) [6]float64 {
+	return [6]float64{noise(0.05), noise(0.05), 9.8 + noise(0.05), noise(3), noise(3), noise(3)}
+}
+
+func (b *virtualBoard) walkSample(t float64) [6]float64 {
+	phase := 2 * math.Pi * 1.8 * t // ~1.8 steps per second
+	return [6]float64{
+		1.2*math.Sin(phase) + noise(0.2),
+		0.6*math.Cos(phase) + noise(0.2),
+		9.8 + 2.5*math.Sin(2*phase) + noise(0.3),
+		150*math.Sin(phase) + noise(20),
+		80*math.Cos(phase) + noise(20),
+		40*math.Sin(phase/2) + noise(10),
+	}
+}
+
+func (b *virtualBoard) freefallSample(t float64) [6]float64 {
+	return [6]float64{noise(0.3), noise(0.3), 2.0 + noise(0.3), noise(40), noise(40), noise(40)}
+}
+
+// impactSample spikes acceleration while gyro stays under IMPACT_GYRO_MAX.
+func (b *virtualBoard) impactSample(t float64) [6]float64 {
+	return [6]float64{4 + noise(1), 3 + noise(1), 22 + noise(2), noise(50), noise(50), noise(50)}
+}
+
+// lyingSample has gravity on the X axis, as if the board ended up on its side.
+func (b *virtualBoard) lyingSample(t float64) [6]float64 {
+	return [6]float64{9.8 + noise(0.05), noise(0.05), 0.3 + noise(0.05), noise(2), noise(2), noise(2)}
+}
+
+func noise(scale float64) float64 {
+	return (rand.Float64()*2 - 1) * scale
+}
+
+func (b *virtualBoard) setState(s telemetry.FallState) {
+	b.mu.Lock()
+	b.state = s
+	b.mu.Unlock()
+}
+
+func (b *virtualBoard) resetFall() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state != telemetry.StateNormal {
+		b.logf("fall cleared")
+	}
+	b.state = telemetry.StateNormal
+	b.pressure = basePressure
+}
+
+func (b *virtualBoard) ensureConnected() error {
+	if b.conn != nil {
+		return nil
+	}
+
+	conn, err := net.DialTimeout("tcp", b.scenario.Addr, 5*time.Second)
+	if err != nil {
+		return err
+	}
+	reader := bufio.NewReader(conn)
+	b.conn = conn
+
+	if b.secret != nil {
+		if err := b.handshake(reader); err != nil {
+			b.disconnect()
+			return err
+		}
+	}
+
+	b.logf("connected to %s", b.scenario.Addr)
+	go b.readCommands(conn, reader)
+	return nil
+}
+
+// handshake answers the server's challenge, see telemetry/handshake.go.
+func (b *virtualBoard) handshake(reader *bufio.Reader) error {
+	boardID := strconv.Itoa(b.id)
+	if err := b.writeLine(telemetry.HelloLine(boardID)); err != nil {
+		return err
+	}
+
+	b.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	defer b.conn.SetReadDeadline(time.Time{})
+
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	nonce, err := telemetry.ParseChallenge(line)
+	if err != nil {
+		return err
+	}
+	if err := b.writeLine(telemetry.AuthLine(telemetry.SignChallenge(b.secret, nonce, boardID))); err != nil {
+		return err
+	}
+
+	line, err = reader.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(line) != telemetry.AuthOK {
+		return errors.New("authentication denied")
+	}
+	return nil
+}
+
+// readCommands acks downlink commands until the connection closes.
+func (b *virtualBoard) readCommands(conn net.Conn, reader *bufio.Reader) {
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd, err := telemetry.ParseCommand(line)
+		if err != nil {
+			b.logf("ignoring %q: %v", strings.TrimSpace(line), err)
+			continue
+		}
+		b.logf("received command #%d %s %v", cmd.ID, cmd.Type, cmd.Args)
+
+		ack := telemetry.Ack{ID: cmd.ID, OK: true}
+		switch cmd.Type {
+		case telemetry.CmdResetFall:
+			b.resetFall()
+		case telemetry.CmdStatus:
+			b.mu.Lock()
+			ack.Detail = fmt.Sprintf("state=%s battery=%.1f fw=%s", b.state, b.battery, firmware)
+			b.mu.Unlock()
+		}
+
+		b.writeMu.Lock()
+		conn.Write([]byte(ack.Encode() + "\n"))
+		b.writeMu.Unlock()
+	}
+}
+
+func (b *virtualBoard) writeLine(line string) error {
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+	if b.conn == nil {
+		return net.ErrClosed
+	}
+	_, err := b.conn.Write([]byte(line + "\n"))
+	return err
+}
+
+func (b *virtualBoard) disconnect() {
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+	if b.conn != nil {
+		b.conn.Close()
+		b.conn = nil
+		b.logf("disconnected")
+	}
+}
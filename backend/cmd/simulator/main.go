@@ -0,0 +1,80 @@
+// Command simulator impersonates one or more fall-detection boards so the
+// backend can be developed and demoed without hardware.
+//
+//	go run ./cmd/simulator -addr localhost:50066 -boards 1,2 -scenario cmd/simulator/scenarios/fall.yaml
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+func main() {
+	addr := flag.String("addr", "", "server address, overrides the scenario (default localhost:50066)")
+	boards := flag.String("boards", "", "comma-separated board numbers, overrides the scenario (default 1)")
+	scenarioPath := flag.String("scenario", "", "YAML scenario file (default: walk forever)")
+	protocol := flag.Int("protocol", 0, "wire format version 1 or 2, overrides the scenario")
+	secret := flag.String("secret", "", "hex board secret for the auth handshake, overrides the scenario")
+	flag.Parse()
+
+	sc := defaultScenario()
+	if *scenarioPath != "" {
+		var err error
+		sc, err = loadScenario(*scenarioPath)
+		if err != nil {
+			log.Fatal("Error loading scenario: ", err)
+		}
+	}
+
+	if *addr != "" {
+		sc.Addr = *addr
+	}
+	if sc.Addr == "" {
+		sc.Addr = "localhost:50066"
+	}
+	if *boards != "" {
+		sc.Boards = nil
+		for _, s := range strings.Split(*boards, ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(s))
+			if err != nil || n <= 0 {
+				log.Fatalf("Invalid board number %q", s)
+			}
+			sc.Boards = append(sc.Boards, n)
+		}
+	}
+	if len(sc.Boards) == 0 {
+		sc.Boards = []int{1}
+	}
+	if *protocol != 0 {
+		sc.Protocol = *protocol
+	}
+	if *secret != "" {
+		sc.Secret = *secret
+	}
+	if err := sc.validate(); err != nil {
+		log.Fatal("Invalid scenario: ", err)
+	}
+
+	var key []byte
+	if sc.Secret != "" {
+		var err error
+		key, err = hex.DecodeString(sc.Secret)
+		if err != nil {
+			log.Fatal("Secret must be hex: ", err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, id := range sc.Boards {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			newVirtualBoard(id, sc, key).Run()
+		}(id)
+	}
+	wg.Wait()
+}
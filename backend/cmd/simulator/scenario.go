@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/goccy/go-yaml"
+)
+
+// Actions a scenario step can perform.
+const (
+	ActionIdle       = "idle"       // Board at rest on a table
+	ActionWalk       = "walk"       // Worn by a walking resident
+	ActionFall       = "fall"       // Freefall, impact, then lying until the firmware confirms
+	ActionLie        = "lie"        // Lying still on the floor
+	ActionNFCReset   = "nfc_reset"  // Caregiver taps NFC to clear a confirmed fall
+	ActionStale      = "stale"      // Socket stays open but nothing is sent
+	ActionDisconnect = "disconnect" // Close the socket, reconnect after the duration
+)
+
+var defaultDurations = map[string]time.Duration{
+	ActionIdle:       5 * time.Second,
+	ActionWalk:       5 * time.Second,
+	ActionFall:       3 * time.Second,
+	ActionLie:        5 * time.Second,
+	ActionNFCReset:   0,
+	ActionStale:      10 * time.Second,
+	ActionDisconnect: 3 * time.Second,
+}
+
+// Scenario is the YAML script a simulator run follows. Every board runs the
+// same steps concurrently.
+//
+//	addr: localhost:50066
+//	boards: [1, 2]
+//	protocol: 2
+//	secret: 6b6579          # hex, enables the HMAC handshake
+//	interval: 200ms
+//	loop: true
+//	steps:
+//	  - action: walk
+//	    duration: 10s
+//	  - action: fall
+//	  - action: lie
+//	    duration: 20s
+//	  - action: nfc_reset
+type Scenario struct {
+	Addr     string `yaml:"addr"`
+	Boards   []int  `yaml:"boards"`
+	Protocol int    `yaml:"protocol"`
+	Secret   string `yaml:"secret"`
+	Interval string `yaml:"interval"`
+	Loop     bool   `yaml:"loop"`
+	Steps    []Step `yaml:"steps"`
+
+	interval time.Duration
+}
+
+type Step struct {
+	Action   string `yaml:"action"`
+	Duration string `yaml:"duration"`
+
+	duration time.Duration
+}
+
+// defaultScenario keeps a board walking around until interrupted.
+func defaultScenario() *Scenario {
+	return &Scenario{
+		Loop:  true,
+		Steps: []Step{{Action: ActionWalk}},
+	}
+}
+
+func loadScenario(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var sc Scenario
+	if err := yaml.Unmarshal(data, &sc); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &sc, nil
+}
+
+// validate fills in defaults and parses durations.
+func (sc *Scenario) validate() error {
+	if sc.Protocol == 0 {
+		sc.Protocol = 1
+	}
+	if sc.Protocol != 1 && sc.Protocol != 2 {
+		return fmt.Errorf("unsupported protocol %d", sc.Protocol)
+	}
+
+	sc.interval = 200 * time.Millisecond // WIFI_INTERVAL_MS in firmware
+	if sc.Interval != "" {
+		d, err := time.ParseDuration(sc.Interval)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid interval %q", sc.Interval)
+		}
+		sc.interval = d
+	}
+
+	if len(sc.Steps) == 0 {
+		return fmt.Errorf("scenario has no steps")
+	}
+	for i := range sc.Steps {
+		step := &sc.Steps[i]
+		def, ok := defaultDurations[step.Action]
+		if !ok {
+			return fmt.Errorf("step %d: unknown action %q", i+1, step.Action)
+		}
+		step.duration = def
+		if step.Duration != "" {
+			d, err := time.ParseDuration(step.Duration)
+			if err != nil || d < 0 {
+				return fmt.Errorf("step %d: invalid duration %q", i+1, step.Duration)
+			}
+			step.duration = d
+		}
+	}
+	return nil
+}
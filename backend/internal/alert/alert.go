@@ -5,7 +5,7 @@ import (
 	"fall-detection/internal/mqtt"
 	"fall-detection/internal/repository"
 	"fall-detection/internal/tcp"
-	"fmt"
+	"fall-detection/internal/telemetry"
 	"log"
 	"strings"
 	"time"
@@ -22,6 +22,16 @@ type Alert struct {
 	Bot              *Bot
 	SubscriptionRepo *repository.SubscriptionRepo
 	FallEventRepo    *repository.FallEventRepo
+	TCPServer        *tcp.TCPServer
+	Telegram         *Telegram
+
+	// Notifiers delivers alerts to Telegram and any other channels added
+	// to it, through the notification outbox.
+	Notifiers *Dispatcher
+
+	// OfflineGracePeriod is how long a board may be silent before
+	// subscribers are warned. Zero disables offline alerts.
+	OfflineGracePeriod time.Duration
 }
 
 func (a *Alert) Start() {
@@ -31,38 +41,36 @@ func (a *Alert) Start() {
 		return
 	}
 
+	a.Notifiers.Start()
+
 	// Background safety-net: expire events that have been active for more than
 	// fallEventTTL (board lost power / NFC tap never happened). Runs every 30s.
 	go func() {
 		ticker := time.NewTicker(30 * time.Second)
 		defer ticker.Stop()
 		for range ticker.C {
-			expiredBoards, err := a.FallEventRepo.AutoExpireStale(context.Background(), fallEventTTL)
+			// Subscribers are warned that the board needs checking
+			expired, err := a.FallEventRepo.AutoExpireStale(context.Background(), fallEventTTL, a.Notifiers.Expired())
 			if err != nil {
 				log.Printf("[Alert] Failed to auto-expire stale events: %v", err)
 				continue
 			}
-			for _, boardID := range expiredBoards {
-				log.Printf("[Alert] Safety-net expired event for %s", boardID)
-
-				// Warn all Telegram subscribers
-				chatIDs, _, _, err := a.SubscriptionRepo.GetSubscribers(context.Background(), boardID)
-				if err == nil {
-					warnMsg := fmt.Sprintf(
-						"⚠️ Fall alert on %s has not been cleared after 5 minutes.\n\nThe board may have lost power or be malfunctioning. Please check the board physically.",
-						boardID,
-					)
-					for _, chatID := range chatIDs {
-						a.Bot.SendMessage(chatID, warnMsg)
-					}
-				}
+			if len(expired) > 0 {
+				a.Notifiers.Wake()
+			}
+			for _, event := range expired {
+				log.Printf("[Alert] Safety-net expired event #%d for %s", event.ID, event.BoardID)
 
 				// Notify frontend
-				mqtt.Publish(a.Client, "fall-detection/"+boardID+"/alerts", "BOARD_EXPIRED")
+				mqtt.Publish(a.Client, "fall-detection/"+event.BoardID+"/alerts", "BOARD_EXPIRED")
 			}
 		}
 	}()
 
+	if a.OfflineGracePeriod > 0 {
+		go a.monitorOffline(a.OfflineGracePeriod)
+	}
+
 	log.Println("[Alert] Subscribing to fall-detection/+/alerts...")
 	token := a.Client.Subscribe("fall-detection/+/alerts", 1, func(_ pahomqtt.Client, msg pahomqtt.Message) {
 		payload := strings.TrimSpace(string(msg.Payload()))
@@ -75,61 +83,84 @@ func (a *Alert) Start() {
 		switch payload {
 
 		case "BOARD_RESET":
-			// NFC tap detected by the TCP server: resolve the active event.
+			// NFC tap or remote reset detected by the TCP server: resolve
+			// the active event.
 			log.Printf("[Alert] BOARD_RESET received for %s", boardID)
-			resolved, err := a.FallEventRepo.ResolveActiveForBoard(context.Background(), boardID)
+			via, by := ClearedByNFC, (*int64)(nil)
+			if user, ok := a.TCPServer.TakeRemoteReset(strings.TrimPrefix(boardID, "board")); ok {
+				via, by = ClearedByRemote, user
+			}
+			resolved, err := a.FallEventRepo.ResolveActiveForBoard(context.Background(), boardID, via, by, a.Notifiers.Resolved(via))
 			if err != nil {
 				log.Printf("[Alert] Failed to resolve fall event for %s: %v", boardID, err)
 				return
 			}
-			if !resolved {
+			if len(resolved) == 0 {
 				// Already expired or no active event — nothing to do.
 				return
 			}
-
-			// Notify Telegram subscribers that the board was reset via NFC.
-			chatIDs, _, _, err := a.SubscriptionRepo.GetSubscribers(context.Background(), boardID)
-			if err == nil {
-				resolvedMsg := fmt.Sprintf(
-					"✅ Fall alert on %s has been cleared — NFC device was tapped on the board.",
-					boardID,
-				)
-				for _, chatID := range chatIDs {
-					a.Bot.SendMessage(chatID, resolvedMsg)
-				}
-			}
+			a.Notifiers.Wake()
 
 			// Notify the frontend dashboard.
 			mqtt.Publish(a.Client, "fall-detection/"+boardID+"/alerts", "NFC_RESOLVED")
 
+		case "INACTIVITY":
+			// The TCP server saw no meaningful movement for the board's
+			// inactivity period.
+			active, err := a.FallEventRepo.GetActive(context.Background(), boardID, repository.EventKindInactivity)
+			if err == nil && active != nil {
+				log.Printf("[Alert] Duplicate inactivity alert for %s — active event #%d exists, skipping", boardID, active.ID)
+				return
+			}
+
+			eventID, err := a.FallEventRepo.Create(context.Background(), boardID, repository.EventKindInactivity, a.Notifiers.FallDetected())
+			if err != nil {
+				log.Printf("[Alert] Failed to create inactivity event for %s: %v", boardID, err)
+				return
+			}
+			log.Printf("[Alert] Created inactivity event #%d for %s", eventID, boardID)
+			a.Notifiers.Wake()
+
+		case "ACTIVITY_RESUMED":
+			resolved, err := a.FallEventRepo.ResolveActiveOfKind(context.Background(), boardID, repository.EventKindInactivity, ClearedByMovement, a.Notifiers.Resolved(ClearedByMovement))
+			if err != nil {
+				log.Printf("[Alert] Failed to resolve inactivity event for %s: %v", boardID, err)
+				return
+			}
+			if len(resolved) > 0 {
+				a.Notifiers.Wake()
+			}
+
 		case "NFC_RESOLVED", "BOARD_EXPIRED":
 			// Our own messages published above — ignore to avoid loopback processing.
 			return
 
 		default:
-			// Assume it's a CSV fall-alert line from the TCP server (fallStatus 0→1).
+			// A fall-alert reading from the TCP server (fallStatus 0→1).
+			reading, err := telemetry.Parse(payload)
+			if err != nil {
+				log.Printf("[Alert] Ignoring malformed alert for %s: %v", boardID, err)
+				return
+			}
+			if !reading.FallStatus || reading.Topic() != boardID {
+				log.Printf("[Alert] Ignoring alert for %s: reading is for %s with fall status %v", boardID, reading.Topic(), reading.FallStatus)
+				return
+			}
+
 			// Dedup: if there's already an active event for this board, skip.
-			active, err := a.FallEventRepo.GetActive(context.Background(), boardID)
+			active, err := a.FallEventRepo.GetActive(context.Background(), boardID, repository.EventKindFall)
 			if err == nil && active != nil {
 				log.Printf("[Alert] Duplicate alert for %s — active event #%d exists, skipping", boardID, active.ID)
 				return
 			}
 
-			eventID, err := a.FallEventRepo.Create(context.Background(), boardID)
+			eventID, err := a.FallEventRepo.Create(context.Background(), boardID, repository.EventKindFall, a.Notifiers.FallDetected())
 			if err != nil {
 				log.Printf("[Alert] Failed to create fall event for %s: %v", boardID, err)
 				return
 			}
 			log.Printf("[Alert] Created fall event #%d for %s", eventID, boardID)
-
-			chatIDs, _, _, err := a.SubscriptionRepo.GetSubscribers(context.Background(), boardID)
-			if err != nil {
-				log.Printf("[Alert] Failed to get subscribers for %s: %v", boardID, err)
-				return
-			}
-			for _, chatID := range chatIDs {
-				a.Bot.SendFallAlert(chatID, boardID, eventID)
-			}
+			a.Notifiers.Wake()
 		}
 	})
 
@@ -141,15 +172,25 @@ func (a *Alert) Start() {
 	}
 }
 
-func NewAlert(client pahomqtt.Client, subscriptionRepo *repository.SubscriptionRepo, fallEventRepo *repository.FallEventRepo, botToken string, tcpServer *tcp.TCPServer) (*Alert, error) {
+// NewAlert creates the alert service with Telegram as its only channel.
+// Further channels are added to Notifiers before Start.
+func NewAlert(client pahomqtt.Client, subscriptionRepo *repository.SubscriptionRepo, fallEventRepo *repository.FallEventRepo, outboxRepo *repository.OutboxRepo, botToken string, tcpServer *tcp.TCPServer) (*Alert, error) {
 	bot, err := NewBot(subscriptionRepo, botToken, tcpServer, client)
 	if err != nil {
 		return nil, err
 	}
+	telegram := NewTelegram(bot, subscriptionRepo)
+	notifiers := NewDispatcher(outboxRepo)
+	notifiers.Add(telegram, TelegramTimeout)
+	bot.Notifiers = notifiers
+
 	return &Alert{
 		Client:           client,
 		Bot:              bot,
 		SubscriptionRepo: subscriptionRepo,
 		FallEventRepo:    fallEventRepo,
+		TCPServer:        tcpServer,
+		Telegram:         telegram,
+		Notifiers:        notifiers,
 	}, nil
 }
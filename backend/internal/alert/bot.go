@@ -2,34 +2,60 @@ package alert
 
 import (
 	"context"
+	"errors"
 	"fall-detection/internal/repository"
 	"fall-detection/internal/tcp"
+	"fall-detection/internal/telemetry"
 	"fmt"
 	"log"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	pahomqtt "github.com/eclipse/paho.mqtt.golang"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/jackc/pgx/v5"
 )
 
 var boardIDPattern = regexp.MustCompile(`^board\d+$`)
 
+// Board names and locations in messages may be this stale. The registry is
+// reloaded as a whole rather than queried per board and message.
+const (
+	boardCacheTTL    = time.Minute
+	boardLookupLimit = 5 * time.Second
+)
+
 type Bot struct {
 	api              *tgbotapi.BotAPI
+	queue            *SendQueue
 	chatIDs          []int64
 	SubscriptionRepo *repository.SubscriptionRepo
+	Boards           *repository.BoardRepo           // Friendly board names when set
+	Residents        *repository.ResidentRepo        // Who wears each board when set
+	Acknowledgements *repository.AcknowledgementRepo // Records who has seen each alert
 	TCPServer        *tcp.TCPServer
 	AlertClient      pahomqtt.Client
+
+	// Notifiers is told when someone acknowledges an active event for the
+	// first time.
+	Notifiers *Dispatcher
+
+	// Recent wrong pairing codes per chat, only touched by ListenForCommands
+	pairingFailures map[int64][]time.Time
+
+	boardsMu       sync.Mutex
+	boardCache     map[string]repository.Board
+	boardsLoadedAt time.Time
 }
 
 func (b *Bot) SendAlert(message string) error {
 	var lastErr error
 
 	for _, chatID := range b.chatIDs {
-		if err := b.SendMessage(chatID, message); err != nil {
+		if err := b.SendMessage(context.Background(), chatID, message); err != nil {
 			lastErr = err
 			log.Printf("Failed to send to %d: %v", chatID, err)
 		}
@@ -38,12 +64,67 @@ func (b *Bot) SendAlert(message string) error {
 	return lastErr
 }
 
-func (b *Bot) SendMessage(chatID int64, text string) error {
-	msg := tgbotapi.NewMessage(chatID, text)
-	_, err := b.api.Send(msg)
+// SendMessage sends an informational message and waits until it is sent.
+func (b *Bot) SendMessage(ctx context.Context, chatID int64, text string) error {
+	_, err := b.sendAll(ctx, []int64{chatID}, PriorityInfo, func(chatID int64) tgbotapi.Chattable {
+		return tgbotapi.NewMessage(chatID, text)
+	})
 	return err
 }
 
+// reply answers a command without waiting for the message to go out.
+func (b *Bot) reply(msg tgbotapi.MessageConfig) {
+	b.queue.Post(msg.ChatID, msg, PriorityInfo)
+}
+
+// sendAll queues a message to each chat and waits until they are sent,
+// returning how many were and why the others were not. Within an outbox
+// delivery the queue owns the messages: chats an earlier attempt reached
+// are counted without being sent to again, and messages still queued when
+// ctx is done are sent anyway and picked up by the next attempt.
+func (b *Bot) sendAll(ctx context.Context, chatIDs []int64, priority SendPriority, message func(chatID int64) tgbotapi.Chattable) (int, error) {
+	reached := recipientsFrom(ctx)
+	sent := 0
+	var results []<-chan error
+	for _, chatID := range chatIDs {
+		recipient := strconv.FormatInt(chatID, 10)
+		if reached.reached(recipient) {
+			sent++
+			continue
+		}
+		if reached == nil {
+			results = append(results, b.queue.Send(ctx, chatID, message(chatID), priority))
+			continue
+		}
+
+		done := b.queue.Deliver(fmt.Sprintf("%d:%s", reached.id, recipient), chatID, message(chatID), priority)
+		result := make(chan error, 1)
+		go func() {
+			err := <-done
+			if err == nil {
+				reached.delivered(recipient)
+			}
+			result <- err
+		}()
+		results = append(results, result)
+	}
+
+	var errs []error
+	for _, done := range results {
+		select {
+		case err := <-done:
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			sent++
+		case <-ctx.Done():
+			return sent, errors.Join(append(errs, ctx.Err())...)
+		}
+	}
+	return sent, errors.Join(errs...)
+}
+
 func NewBot(subscriptionRepo *repository.SubscriptionRepo, botToken string, tcpServer *tcp.TCPServer, alertClient pahomqtt.Client) (*Bot, error) {
 	api, err := tgbotapi.NewBotAPI(botToken)
 	if err != nil {
@@ -52,6 +133,8 @@ func NewBot(subscriptionRepo *repository.SubscriptionRepo, botToken string, tcpS
 
 	return &Bot{
 		api:              api,
+		queue:            NewSendQueue(api),
+		pairingFailures:  make(map[int64][]time.Time),
 		SubscriptionRepo: subscriptionRepo,
 		TCPServer:        tcpServer,
 		AlertClient:      alertClient,
@@ -79,72 +162,74 @@ func (b *Bot) ListenForCommands(subscriptionRepo *repository.SubscriptionRepo, f
 
 		switch command {
 		case "start", "help":
-			b.api.Send(tgbotapi.NewMessage(chatID, `👋 Welcome to the Fall Detection Monitor!
+			if command == "start" && args != "" {
+				// Opened through an invite link, t.me/<bot>?start=<token>
+				b.redeemInvite(update.Message, strings.TrimSpace(args))
+				continue
+			}
+			b.reply(tgbotapi.NewMessage(chatID, `👋 Welcome to the Fall Detection Monitor!
 
 I'll send you real-time alerts whenever a fall is detected on your boards, and let you acknowledge them directly from Telegram.
 
-To get started:
-  /subscribe board1 — receive alerts for board 1
+To get started, open the invite link the care team sent you, or:
+  /subscribe board1 CODE — receive alerts for board 1 using its pairing code
 
 Available commands:
-  /subscribe board#    – Subscribe to a board
+  /subscribe board# CODE – Subscribe to a board
   /unsubscribe board#  – Unsubscribe from a board
   /myboards            – List your active subscriptions
   /statuses            – Show online/offline status of your boards
   /history board#      – Last 5 fall events for a board
+  /silence board#      – Silence the board's buzzer
+  /locate board#       – Beep the board to find it
+  /reset board#        – Clear a fall remotely (instead of NFC tap)
   /help                – Show this message again`))
 		case "subscribe":
-			if !boardIDPattern.MatchString(boardID) {
-				b.api.Send(tgbotapi.NewMessage(chatID, "Invalid format. Usage: /subscribe board#number\nExample: /subscribe board1"))
-				continue
-			}
-			user := update.Message.From
-			err := subscriptionRepo.CreateSubscription(context.Background(), chatID, boardID, user.FirstName, user.UserName)
-			if err != nil {
-				b.api.Send(tgbotapi.NewMessage(chatID, "Failed to subscribe"))
-			} else {
-				b.api.Send(tgbotapi.NewMessage(chatID, "Subscribed to "+boardID))
-			}
+			b.subscribe(update.Message, args)
 		case "unsubscribe":
 			if !boardIDPattern.MatchString(boardID) {
-				b.api.Send(tgbotapi.NewMessage(chatID, "Invalid format. Usage: /unsubscribe board#number\nExample: /unsubscribe board1"))
+				b.reply(tgbotapi.NewMessage(chatID, "Invalid format. Usage: /unsubscribe board#number\nExample: /unsubscribe board1"))
 				continue
 			}
 			err := subscriptionRepo.Unsubscribe(context.Background(), chatID, boardID)
 			if err != nil {
-				b.api.Send(tgbotapi.NewMessage(chatID, "Failed to unsubscribe"))
+				b.reply(tgbotapi.NewMessage(chatID, "Failed to unsubscribe"))
 			} else {
-				b.api.Send(tgbotapi.NewMessage(chatID, "Unsubscribed from "+boardID))
+				b.reply(tgbotapi.NewMessage(chatID, "Unsubscribed from "+boardID))
 			}
 
 		case "myboards":
 			// Lists the active subscriptions of the user
 			boardsSubscribedTo, err := b.SubscriptionRepo.GetBoardsSubscribedTo(context.Background(), chatID)
 			if err != nil {
-				b.api.Send(tgbotapi.NewMessage(chatID, "Failed to retrieve list of subscriptions: "+err.Error()))
+				b.reply(tgbotapi.NewMessage(chatID, "Failed to retrieve list of subscriptions: "+err.Error()))
 			}
 
 			if len(boardsSubscribedTo) == 0 {
-				b.api.Send(tgbotapi.NewMessage(chatID, "You are not subscribed to any boards.\n Use /subscribe board#number to get started."))
+				b.reply(tgbotapi.NewMessage(chatID, "You are not subscribed to any boards.\n Use /subscribe board#number CODE or an invite link to get started."))
 				continue
 			}
 
 			lines := make([]string, len(boardsSubscribedTo))
 			for i, id := range boardsSubscribedTo {
-				lines[i] = "• " + id
+				lines[i] = "• " + b.boardName(id)
+				// Legacy subscriptions get no alerts until authorized
+				if s, err := b.SubscriptionRepo.GetSubscription(context.Background(), chatID, id); err == nil && !s.Authorized() {
+					lines[i] += " 🔒 alerts paused, send /subscribe " + id + " CODE"
+				}
 			}
-			b.api.Send(tgbotapi.NewMessage(chatID, "Your subscriptions:\n\n"+strings.Join(lines, "\n")))
+			b.reply(tgbotapi.NewMessage(chatID, "Your subscriptions:\n\n"+strings.Join(lines, "\n")))
 
 		case "statuses":
 			// Get current status of boards subscribed to
 			boardsOnline := b.TCPServer.GetBoards()
 			boardsSubscribedTo, err := b.SubscriptionRepo.GetBoardsSubscribedTo(context.Background(), chatID)
 			if err != nil {
-				b.api.Send(tgbotapi.NewMessage(chatID, "Failed to retrieve list of subscriptions: "+err.Error()))
+				b.reply(tgbotapi.NewMessage(chatID, "Failed to retrieve list of subscriptions: "+err.Error()))
 			}
 
 			if len(boardsSubscribedTo) == 0 {
-				b.api.Send(tgbotapi.NewMessage(chatID, "You are not subscribed to any boards.\n Use /subscribe board#number to get started."))
+				b.reply(tgbotapi.NewMessage(chatID, "You are not subscribed to any boards.\n Use /subscribe board#number CODE or an invite link to get started."))
 				continue
 			}
 
@@ -157,28 +242,36 @@ Available commands:
 			lines := make([]string, len(boardsSubscribedTo))
 			for i, boardID := range boardsSubscribedTo {
 				if onlineSet[boardID] {
-					lines[i] = "• " + boardID + " — 🟢 Online"
+					lines[i] = "• " + b.boardName(boardID) + " — 🟢 Online"
 				} else {
-					lines[i] = "• " + boardID + " — 🔴 Offline"
+					lines[i] = "• " + b.boardName(boardID) + " — 🔴 Offline"
 				}
 			}
 
-			b.api.Send(tgbotapi.NewMessage(chatID, "Your subscriptions:\n\n"+strings.Join(lines, "\n")))
+			b.reply(tgbotapi.NewMessage(chatID, "Your subscriptions:\n\n"+strings.Join(lines, "\n")))
 
 		case "history":
 			if !boardIDPattern.MatchString(boardID) {
-				b.api.Send(tgbotapi.NewMessage(chatID, "Invalid format. Usage: /history board#\nExample: /history board1"))
+				b.reply(tgbotapi.NewMessage(chatID, "Invalid format. Usage: /history board#\nExample: /history board1"))
 				continue
 			}
 			events, err := fallEventRepo.GetLastFiveEvents(context.Background(), boardID)
 			if err != nil {
-				b.api.Send(tgbotapi.NewMessage(chatID, "Failed to retrieve history: "+err.Error()))
+				b.reply(tgbotapi.NewMessage(chatID, "Failed to retrieve history: "+err.Error()))
 				continue
 			}
 			if len(events) == 0 {
-				b.api.Send(tgbotapi.NewMessage(chatID, "No fall events recorded for "+boardID+"."))
+				b.reply(tgbotapi.NewMessage(chatID, "No fall events recorded for "+boardID+"."))
 				continue
 			}
+			ids := make([]int64, len(events))
+			for i, e := range events {
+				ids[i] = e.ID
+			}
+			acks, err := b.Acknowledgements.GetByEvents(context.Background(), ids)
+			if err != nil {
+				log.Printf("[Alert] Failed to load acknowledgements for %s: %v", boardID, err)
+			}
 			lines := make([]string, len(events))
 			for i, e := range events {
 				var status string
@@ -195,34 +288,178 @@ Available commands:
 				default:
 					status = "🔴 Active"
 				}
-				lines[i] = fmt.Sprintf("%d. %s\n   %s",
+				label := ""
+				if e.Kind == repository.EventKindInactivity {
+					label = " (inactivity)"
+				}
+				if e.ResidentName != nil {
+					label += " — " + *e.ResidentName
+				}
+				if seen := acks[e.ID]; len(seen) > 0 {
+					elapsed := seen[0].AcknowledgedAt.Sub(e.DetectedAt).Round(time.Second)
+					status += fmt.Sprintf("\n   👀 Seen by %s after %s", seen[0].DisplayName, elapsed)
+					if len(seen) > 1 {
+						status += fmt.Sprintf(" (+%d more)", len(seen)-1)
+					}
+				}
+				lines[i] = fmt.Sprintf("%d. %s%s\n   %s",
 					len(events)-i,
 					e.DetectedAt.Format("02 Jan 15:04:05"),
+					label,
 					status,
 				)
 			}
-			msg := fmt.Sprintf("Last %d fall events for %s:\n\n%s", len(events), boardID, strings.Join(lines, "\n\n"))
-			b.api.Send(tgbotapi.NewMessage(chatID, msg))
+			msg := fmt.Sprintf("Last %d fall events for %s:\n\n%s", len(events), b.boardName(boardID), strings.Join(lines, "\n\n"))
+			b.reply(tgbotapi.NewMessage(chatID, msg))
+
+		case "silence", "locate", "reset":
+			if !boardIDPattern.MatchString(boardID) {
+				b.reply(tgbotapi.NewMessage(chatID, "Invalid format. Usage: /"+command+" board#\nExample: /"+command+" board1"))
+				continue
+			}
+			cmdTypes := map[string]telemetry.CommandType{
+				"silence": telemetry.CmdSilenceBuzzer,
+				"locate":  telemetry.CmdLocate,
+				"reset":   telemetry.CmdResetFall,
+			}
+			// SendCommand blocks until the board acks, keep reading updates meanwhile
+			go b.sendBoardCommand(chatID, update.Message.From.ID, boardID, telemetry.Command{Type: cmdTypes[command]})
 
 		default:
-			b.api.Send(tgbotapi.NewMessage(chatID, "Unknown command. Available commands:\n/subscribe board#number\n/unsubscribe board#number"))
+			b.reply(tgbotapi.NewMessage(chatID, "Unknown command. Send /help to see the available commands."))
 		}
 
 	}
 }
 
-func (b *Bot) SendFallAlert(chatID int64, boardID string, eventID int64) {
-	text := fmt.Sprintf(
-		"🚨 FALL DETECTED — %s\n\n⚠️ The alert will only clear when an NFC device is tapped on the board.",
-		boardID,
-	)
+// boardInfo looks a board up in the registry, returning nil if it is unknown
+// or no registry is configured.
+func (b *Bot) boardInfo(boardID string) *repository.Board {
+	if b.Boards == nil {
+		return nil
+	}
+
+	b.boardsMu.Lock()
+	defer b.boardsMu.Unlock()
+
+	if time.Since(b.boardsLoadedAt) > boardCacheTTL {
+		// Retry no sooner than the TTL either, so a database outage does
+		// not hold up every message
+		b.boardsLoadedAt = time.Now()
+
+		ctx, cancel := context.WithTimeout(context.Background(), boardLookupLimit)
+		boards, err := b.Boards.List(ctx)
+		cancel()
+		if err != nil {
+			log.Printf("[Alert] Failed to load boards, using cached names: %v", err)
+		} else {
+			b.boardCache = make(map[string]repository.Board, len(boards))
+			for _, board := range boards {
+				b.boardCache[board.ID] = board
+			}
+		}
+	}
+
+	board, ok := b.boardCache[boardID]
+	if !ok {
+		return nil
+	}
+	return &board
+}
+
+// boardName names a board for messages, e.g. "Room 12 (board1)". The board ID
+// is kept since it is what commands such as /history take.
+func (b *Bot) boardName(boardID string) string {
+	if board := b.boardInfo(boardID); board != nil && board.DisplayName != "" {
+		return board.DisplayName + " (" + boardID + ")"
+	}
+	return boardID
+}
+
+// boardResident returns the resident wearing a board, or nil if it is
+// unassigned or no resident repo is configured.
+func (b *Bot) boardResident(boardID string) *repository.Resident {
+	if b.Residents == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), boardLookupLimit)
+	defer cancel()
+	resident, err := b.Residents.GetByBoard(ctx, boardID)
+	if err != nil {
+		return nil
+	}
+	return resident
+}
+
+// boardHeading says who wears a board and where they are, falling back to
+// the board's own name and location.
+func (b *Bot) boardHeading(boardID string) string {
+	resident := b.boardResident(boardID)
+	board := b.boardInfo(boardID)
+
+	var heading string
+	if resident != nil {
+		heading = resident.Name + " — " + b.boardName(boardID)
+	} else {
+		heading = b.boardName(boardID)
+	}
+
+	switch {
+	case resident != nil && resident.Room != "":
+		heading += "\n📍 " + resident.Room
+	case board != nil && board.Location != "":
+		heading += "\n📍 " + board.Location
+	}
+	if resident != nil && resident.FallRisk == repository.FallRiskHigh {
+		heading += "\n⚠️ High fall risk"
+	}
+	return heading
+}
+
+func (b *Bot) SendFallAlert(chatID int64, boardID string, eventID int64) error {
+	_, err := b.sendEventAlerts(context.Background(), []int64{chatID}, boardID, eventID, repository.EventKindFall, 0)
+	return err
+}
+
+func (b *Bot) SendInactivityAlert(chatID int64, boardID string, eventID int64) error {
+	_, err := b.sendEventAlerts(context.Background(), []int64{chatID}, boardID, eventID, repository.EventKindInactivity, 0)
+	return err
+}
+
+// sendEventAlerts sends an alert to each chat ahead of any informational
+// messages, returning how many were sent.
+func (b *Bot) sendEventAlerts(ctx context.Context, chatIDs []int64, boardID string, eventID int64, kind string, escalatedAfter time.Duration) (int, error) {
+	return b.sendAll(ctx, chatIDs, PriorityAlert, func(chatID int64) tgbotapi.Chattable {
+		return b.eventAlert(chatID, boardID, eventID, kind, escalatedAfter)
+	})
+}
+
+// eventAlert is an alert with a "seen" button. escalatedAfter is how long
+// the event has gone unacknowledged, zero for the first alert.
+func (b *Bot) eventAlert(chatID int64, boardID string, eventID int64, kind string, escalatedAfter time.Duration) tgbotapi.MessageConfig {
+	var text string
+	if kind == repository.EventKindInactivity {
+		text = fmt.Sprintf(
+			"🛑 PROLONGED INACTIVITY — %s\n\nThe board has not detected any movement. Please check on the resident.\n\nThe alert will clear when the board moves again.",
+			b.boardHeading(boardID),
+		)
+	} else {
+		text = fmt.Sprintf(
+			"🚨 FALL DETECTED — %s\n\n⚠️ The alert will only clear when an NFC device is tapped on the board.",
+			b.boardHeading(boardID),
+		)
+	}
+	if escalatedAfter > 0 {
+		text = fmt.Sprintf("⏫ Nobody has responded for %s\n\n%s", escalatedAfter.Round(time.Second), text)
+	}
+
 	msg := tgbotapi.NewMessage(chatID, text)
 	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
 			tgbotapi.NewInlineKeyboardButtonData("👀 I've seen this", fmt.Sprintf("seen:%d:%s", eventID, boardID)),
 		),
 	)
-	b.api.Send(msg)
+	return msg
 }
 
 func (b *Bot) handleCallback(callback *tgbotapi.CallbackQuery, repo *repository.FallEventRepo) {
@@ -233,8 +470,8 @@ func (b *Bot) handleCallback(callback *tgbotapi.CallbackQuery, repo *repository.
 		return
 	}
 
+	// parts[2] is the board, which is taken from the event instead
 	eventID, _ := strconv.ParseInt(parts[1], 10, 64)
-	boardID := parts[2]
 
 	displayName := callback.From.UserName
 	if displayName != "" {
@@ -251,6 +488,14 @@ func (b *Bot) handleCallback(callback *tgbotapi.CallbackQuery, repo *repository.
 
 	switch event.Status {
 	case "resolved":
+		if event.Kind == repository.EventKindInactivity {
+			b.api.Request(tgbotapi.NewCallback(callback.ID, "Alert already cleared — the board is moving again"))
+			return
+		}
+		if event.ResolvedVia != nil && *event.ResolvedVia == ClearedByRemote {
+			b.api.Request(tgbotapi.NewCallback(callback.ID, "Alert already cleared remotely"))
+			return
+		}
 		// Already cleared by NFC tap
 		b.api.Request(tgbotapi.NewCallback(callback.ID, "Alert already cleared via NFC tap"))
 
@@ -259,18 +504,58 @@ func (b *Bot) handleCallback(callback *tgbotapi.CallbackQuery, repo *repository.
 		b.api.Request(tgbotapi.NewCallback(callback.ID, "Alert expired — please check the board"))
 
 	default:
-		// Still active — broadcast that this person has seen it, no DB change
+		// Still active — record who has seen it and tell everyone else. The
+		// event itself stays active until the board clears it.
+		first, err := b.Acknowledgements.Create(context.Background(), eventID, callback.From.ID, displayName, b.Notifiers.Seen(displayName))
+		if err != nil {
+			log.Printf("[Alert] Failed to record acknowledgement of event #%d: %v", eventID, err)
+			b.api.Request(tgbotapi.NewCallback(callback.ID, "Error recording that you have seen this"))
+			return
+		}
+		if !first {
+			b.api.Request(tgbotapi.NewCallback(callback.ID, "You have already marked this as seen"))
+			return
+		}
+		b.Notifiers.Wake()
 		b.api.Request(tgbotapi.NewCallback(callback.ID, "Marked as seen 👀"))
+	}
+}
 
-		seenMsg := fmt.Sprintf(
-			"👀 %s has seen the fall alert on %s\n⚠️ Alert will only clear when the board is NFC-tapped.",
-			displayName, boardID,
-		)
-		chatIDs, _, _, err := b.SubscriptionRepo.GetSubscribers(context.Background(), boardID)
-		if err == nil {
-			for _, chatID := range chatIDs {
-				b.api.Send(tgbotapi.NewMessage(chatID, seenMsg))
-			}
-		}
+// sendBoardCommand forwards a command from Telegram to a board the chat has
+// an authorized subscription to and reports the outcome back to the chat.
+func (b *Bot) sendBoardCommand(chatID, userID int64, boardID string, cmd telemetry.Command) {
+	subscription, err := b.SubscriptionRepo.GetSubscription(context.Background(), chatID, boardID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		b.reply(tgbotapi.NewMessage(chatID, "You can only control boards you are subscribed to."))
+		return
+	}
+	if err != nil {
+		b.reply(tgbotapi.NewMessage(chatID, "Failed to retrieve your subscription: "+err.Error()))
+		return
+	}
+	// Commands reach the resident's board, so subscriptions made before
+	// pairing codes existed must be authorized first
+	if !subscription.Authorized() {
+		log.Printf("[Alert] Refused %s for %s from chat %d with a legacy subscription", cmd.Type, boardID, chatID)
+		b.reply(tgbotapi.NewMessage(chatID, "🔒 Controlling "+boardID+" needs an authorized subscription. Send /subscribe "+boardID+" CODE with the board's pairing code, or open an invite link from the care team."))
+		return
+	}
+
+	tcpID := strings.TrimPrefix(boardID, "board")
+	if cmd.Type == telemetry.CmdResetFall {
+		// Recorded as the resolver of the fall the reset clears
+		_, err = b.TCPServer.ResetFall(tcpID, &userID)
+	} else {
+		_, err = b.TCPServer.SendCommand(tcpID, cmd)
+	}
+	switch {
+	case errors.Is(err, tcp.ErrBoardOffline):
+		b.reply(tgbotapi.NewMessage(chatID, "🔴 "+boardID+" is offline."))
+	case errors.Is(err, tcp.ErrCommandTimeout):
+		b.reply(tgbotapi.NewMessage(chatID, "⏱ "+boardID+" did not respond. Please check the board physically."))
+	case err != nil:
+		b.reply(tgbotapi.NewMessage(chatID, "Failed to send command to "+boardID+": "+err.Error()))
+	default:
+		b.reply(tgbotapi.NewMessage(chatID, "✅ "+boardID+" acknowledged "+strings.ToLower(string(cmd.Type))+"."))
 	}
 }
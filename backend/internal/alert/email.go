@@ -0,0 +1,358 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fall-detection/internal/repository"
+	"fmt"
+	htmltemplate "html/template"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+const smtpTimeout = 30 * time.Second
+
+// SMTPConfig is the server alert emails are sent through. ImplicitTLS
+// starts TLS as soon as the connection opens (SMTPS, usually port 465) and
+// is assumed on port 465; otherwise StartTLS upgrades the connection, and
+// should only be disabled for a local test sink such as MailHog.
+type SMTPConfig struct {
+	Host        string
+	Port        string
+	Username    string // No authentication when empty
+	Password    string
+	From        string // e.g. "Fall Detection <alerts@example.com>"
+	StartTLS    bool
+	ImplicitTLS bool
+}
+
+// emailContent is rendered by both the HTML and the plain-text template.
+type emailContent struct {
+	Title      string // Also the subject
+	Paragraphs []string
+	Details    []emailDetail
+	Note       string
+}
+
+type emailDetail struct {
+	Label string
+	Value string
+}
+
+var emailHTML = htmltemplate.Must(htmltemplate.New("html").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: Arial, sans-serif; color: #222;">
+<h2 style="margin: 0 0 16px;">{{.Title}}</h2>
+{{range .Paragraphs}}<p>{{.}}</p>
+{{end}}{{if .Details}}<table cellpadding="4" style="border-collapse: collapse; margin: 8px 0;">
+{{range .Details}}<tr><td style="color: #666; padding-right: 16px;">{{.Label}}</td><td><strong>{{.Value}}</strong></td></tr>
+{{end}}</table>
+{{end}}{{if .Note}}<p style="color: #b00020;">{{.Note}}</p>
+{{end}}<p style="color: #999; font-size: 12px;">You are receiving this because you are subscribed to alerts for this board.</p>
+</body>
+</html>
+`))
+
+var emailText = texttemplate.Must(texttemplate.New("text").Parse(`{{.Title}}
+{{range .Paragraphs}}
+{{.}}
+{{end}}{{if .Details}}
+{{range .Details}}{{.Label}}: {{.Value}}
+{{end}}{{end}}{{if .Note}}
+{{.Note}}
+{{end}}
+--
+You are receiving this because you are subscribed to alerts for this board.
+`))
+
+// Mailer emails a board's alerts to the addresses subscribed to it.
+type Mailer struct {
+	Bot           *Bot
+	Subscriptions *repository.EmailSubscriptionRepo
+
+	config    SMTPConfig
+	from      *mail.Address
+	tlsConfig *tls.Config
+}
+
+func NewMailer(config SMTPConfig, subscriptions *repository.EmailSubscriptionRepo, bot *Bot) (*Mailer, error) {
+	from, err := mail.ParseAddress(config.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from address: %w", err)
+	}
+	if config.Port == "" {
+		config.Port = "587"
+		if config.ImplicitTLS {
+			config.Port = "465"
+		}
+	}
+	if config.Port == "465" {
+		config.ImplicitTLS = true
+	}
+	return &Mailer{
+		Bot:           bot,
+		Subscriptions: subscriptions,
+		config:        config,
+		from:          from,
+		tlsConfig:     &tls.Config{ServerName: config.Host},
+	}, nil
+}
+
+func (m *Mailer) Name() string { return "email" }
+
+// Recipients is how many addresses a board's notifications go to, each in
+// its own SMTP session.
+func (m *Mailer) Recipients(ctx context.Context, boardID string) (int, error) {
+	subs, err := m.Subscriptions.GetByBoard(ctx, boardID)
+	return len(subs), err
+}
+
+// FallDetected emails a board's subscribers about a new fall or inactivity
+// event.
+func (m *Mailer) FallDetected(ctx context.Context, e AlertEvent) error {
+	boardID := e.BoardID
+	resident := m.Bot.boardResident(boardID)
+	board := m.Bot.boardInfo(boardID)
+
+	who := m.Bot.boardName(boardID)
+	if resident != nil {
+		who = resident.Name
+	}
+
+	content := emailContent{}
+	if e.Kind == repository.EventKindInactivity {
+		content.Title = "Prolonged inactivity — " + who
+		content.Paragraphs = []string{"The board has not detected any movement. Please check on the resident."}
+		content.Note = "The alert will clear when the board moves again."
+	} else {
+		content.Title = "Fall detected — " + who
+		content.Paragraphs = []string{"A fall has been detected. Please check on the resident now."}
+		content.Note = "The alert will only clear when an NFC device is tapped on the board."
+	}
+
+	if resident != nil {
+		content.Details = append(content.Details, emailDetail{"Resident", resident.Name})
+		if resident.Room != "" {
+			content.Details = append(content.Details, emailDetail{"Room", resident.Room})
+		}
+		if resident.FallRisk == repository.FallRiskHigh {
+			content.Details = append(content.Details, emailDetail{"Fall risk", "High"})
+		}
+	} else if board != nil && board.Location != "" {
+		content.Details = append(content.Details, emailDetail{"Location", board.Location})
+	}
+	content.Details = append(content.Details,
+		emailDetail{"Board", m.Bot.boardName(boardID)},
+		emailDetail{"Detected at", e.At.Format("02 Jan 2006 15:04:05")},
+		emailDetail{"Event", fmt.Sprintf("#%d", e.ID)},
+	)
+
+	return m.sendToSubscribers(ctx, boardID, content)
+}
+
+// Seen is not emailed; subscribers only hear when an alert is cleared.
+func (m *Mailer) Seen(ctx context.Context, e AlertEvent) error {
+	return nil
+}
+
+func (m *Mailer) Resolved(ctx context.Context, e AlertEvent) error {
+	subject, text := resolvedMessage(m.Bot.boardName(e.BoardID), e.Kind, e.ClearedBy)
+	return m.sendNotice(ctx, e.BoardID, subject, text)
+}
+
+func (m *Mailer) Expired(ctx context.Context, e AlertEvent) error {
+	subject, text := expiredMessage(m.Bot.boardName(e.BoardID))
+	return m.sendNotice(ctx, e.BoardID, subject, text)
+}
+
+func (m *Mailer) BoardOffline(ctx context.Context, b BoardEvent) error {
+	subject, text := offlineMessage(m.Bot.boardName(b.BoardID), b.LastSeen)
+	return m.sendNotice(ctx, b.BoardID, subject, text)
+}
+
+func (m *Mailer) BoardOnline(ctx context.Context, b BoardEvent) error {
+	subject, text := onlineMessage(m.Bot.boardName(b.BoardID), b.Downtime)
+	return m.sendNotice(ctx, b.BoardID, subject, text)
+}
+
+// sendNotice emails a board's subscribers a follow-up such as an alert
+// being cleared. Blank lines in text separate paragraphs.
+func (m *Mailer) sendNotice(ctx context.Context, boardID, subject, text string) error {
+	return m.sendToSubscribers(ctx, boardID, emailContent{
+		Title:      subject,
+		Paragraphs: strings.Split(text, "\n\n"),
+	})
+}
+
+// SendTest sends a test message, e.g. to check the SMTP settings against a
+// local sink.
+func (m *Mailer) SendTest(to, name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), smtpTimeout)
+	defer cancel()
+	return m.send(ctx, &mail.Address{Name: name, Address: to}, emailContent{
+		Title:      "Test email from fall detection",
+		Paragraphs: []string{"Email alerts are set up correctly."},
+	})
+}
+
+func (m *Mailer) sendToSubscribers(ctx context.Context, boardID string, content emailContent) error {
+	subs, err := m.Subscriptions.GetByBoard(ctx, boardID)
+	if err != nil {
+		return fmt.Errorf("get email subscribers for %s: %w", boardID, err)
+	}
+	// One message per recipient so subscribers do not see each other's
+	// addresses. A recipient that hangs only uses up its own share of the
+	// time.
+	reached := recipientsFrom(ctx)
+	var errs []error
+	for _, s := range subs {
+		if reached.reached(s.Email) {
+			continue
+		}
+		sendCtx, cancel := context.WithTimeout(ctx, smtpTimeout)
+		err := m.send(sendCtx, &mail.Address{Name: s.Name, Address: s.Email}, content)
+		cancel()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.Email, err))
+			continue
+		}
+		reached.delivered(s.Email)
+	}
+	return errors.Join(errs...)
+}
+
+// send delivers one message, giving up at ctx's deadline.
+func (m *Mailer) send(ctx context.Context, to *mail.Address, content emailContent) error {
+	msg, err := m.compose(to, content)
+	if err != nil {
+		return err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(m.config.Host, m.config.Port))
+	if err != nil {
+		return err
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(smtpTimeout)
+	}
+	conn.SetDeadline(deadline)
+	if m.config.ImplicitTLS {
+		conn = tls.Client(conn, m.tlsConfig)
+	}
+
+	c, err := smtp.NewClient(conn, m.config.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if m.config.StartTLS && !m.config.ImplicitTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return errors.New("server does not support STARTTLS")
+		}
+		if err := c.StartTLS(m.tlsConfig); err != nil {
+			return err
+		}
+	}
+	if m.config.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(m.from.Address); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to.Address); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// compose builds a multipart/alternative message with plain-text and HTML
+// parts.
+func (m *Mailer) compose(to *mail.Address, content emailContent) ([]byte, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	headers := []string{
+		"From: " + m.from.String(),
+		"To: " + to.String(),
+		"Subject: " + mime.QEncoding.Encode("utf-8", content.Title),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"Message-ID: " + messageID(m.from.Address),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/alternative; boundary=" + mw.Boundary(),
+	}
+	buf.WriteString(strings.Join(headers, "\r\n") + "\r\n\r\n")
+
+	var text, html bytes.Buffer
+	if err := emailText.Execute(&text, content); err != nil {
+		return nil, err
+	}
+	if err := emailHTML.Execute(&html, content); err != nil {
+		return nil, err
+	}
+
+	for _, part := range []struct {
+		contentType string
+		body        []byte
+	}{
+		{"text/plain; charset=utf-8", text.Bytes()},
+		{"text/html; charset=utf-8", html.Bytes()},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write(part.body); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func messageID(from string) string {
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = from[at+1:]
+	}
+	b := make([]byte, 12)
+	rand.Read(b)
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">"
+}
@@ -0,0 +1,284 @@
+package alert
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http/httptest"
+	"net/mail"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// smtpSink is a minimal local SMTP server that keeps every message it
+// accepts, standing in for MailHog in tests.
+type smtpSink struct {
+	listener net.Listener
+	starttls bool // Advertise STARTTLS without being able to do it
+
+	mu       sync.Mutex
+	messages []sinkMessage
+}
+
+type sinkMessage struct {
+	from string
+	to   []string
+	data string
+}
+
+func newSMTPSink(t *testing.T) *smtpSink {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return startSMTPSink(t, l)
+}
+
+// newSMTPSSink starts a sink that speaks TLS from the first byte, like an
+// SMTPS server on port 465. It returns the pool that trusts its certificate.
+func newSMTPSSink(t *testing.T) (*smtpSink, *x509.CertPool) {
+	t.Helper()
+
+	// Borrow httptest's certificate, which is valid for 127.0.0.1
+	srv := httptest.NewUnstartedServer(nil)
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	pool := x509.NewCertPool()
+	pool.AddCert(srv.Certificate())
+
+	l, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: srv.TLS.Certificates})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return startSMTPSink(t, l), pool
+}
+
+func startSMTPSink(t *testing.T, l net.Listener) *smtpSink {
+	s := &smtpSink{listener: l}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpSink) addr() (host, port string) {
+	host, port, _ = net.SplitHostPort(s.listener.Addr().String())
+	return host, port
+}
+
+func (s *smtpSink) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+	var msg sinkMessage
+	reply("220 sink ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		switch verb {
+		case "EHLO", "HELO":
+			if s.starttls {
+				reply("250-sink")
+				reply("250 STARTTLS")
+			} else {
+				reply("250 sink")
+			}
+		case "MAIL":
+			msg = sinkMessage{from: strings.Trim(strings.TrimPrefix(line, "MAIL FROM:"), "<>")}
+			reply("250 OK")
+		case "RCPT":
+			msg.to = append(msg.to, strings.Trim(strings.TrimPrefix(line, "RCPT TO:"), "<>"))
+			reply("250 OK")
+		case "DATA":
+			reply("354 Go ahead")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(l, "."))
+			}
+			msg.data = data.String()
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			reply("250 Queued")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Not implemented")
+		}
+	}
+}
+
+func (s *smtpSink) received() []sinkMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]sinkMessage(nil), s.messages...)
+}
+
+func newTestMailer(t *testing.T, sink *smtpSink) *Mailer {
+	t.Helper()
+
+	host, port := sink.addr()
+	m, err := NewMailer(SMTPConfig{
+		Host: host,
+		Port: port,
+		From: "Fall Detection <alerts@example.com>",
+	}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestMailerSendsMultipartMessage(t *testing.T) {
+	sink := newSMTPSink(t)
+	m := newTestMailer(t, sink)
+
+	if err := m.SendTest("nurse@example.com", "Night Nurse"); err != nil {
+		t.Fatalf("SendTest: %v", err)
+	}
+
+	messages := sink.received()
+	if len(messages) != 1 {
+		t.Fatalf("sink received %d messages, want 1", len(messages))
+	}
+	got := messages[0]
+	if got.from != "alerts@example.com" {
+		t.Errorf("envelope from = %q, want alerts@example.com", got.from)
+	}
+	if len(got.to) != 1 || got.to[0] != "nurse@example.com" {
+		t.Errorf("envelope to = %q, want [nurse@example.com]", got.to)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(got.data))
+	if err != nil {
+		t.Fatalf("parse message: %v", err)
+	}
+	if to := msg.Header.Get("To"); to != `"Night Nurse" <nurse@example.com>` {
+		t.Errorf("To = %q", to)
+	}
+	if subject := msg.Header.Get("Subject"); subject != "Test email from fall detection" {
+		t.Errorf("Subject = %q", subject)
+	}
+	if id := msg.Header.Get("Message-ID"); !strings.HasSuffix(id, "@example.com>") {
+		t.Errorf("Message-ID = %q, want one in the sender's domain", id)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q, want multipart/alternative", msg.Header.Get("Content-Type"))
+	}
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+
+	var types []string
+	for {
+		p, err := parts.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(p) // Decodes quoted-printable
+		if err != nil {
+			t.Fatal(err)
+		}
+		types = append(types, p.Header.Get("Content-Type"))
+		if !strings.Contains(string(body), "Email alerts are set up correctly.") {
+			t.Errorf("%s part is missing the message text:\n%s", p.Header.Get("Content-Type"), body)
+		}
+	}
+	if len(types) != 2 || !strings.HasPrefix(types[0], "text/plain") || !strings.HasPrefix(types[1], "text/html") {
+		t.Errorf("parts = %q, want plain text then HTML", types)
+	}
+}
+
+func TestMailerEscapesHTML(t *testing.T) {
+	m := &Mailer{from: &mail.Address{Address: "alerts@example.com"}}
+	msg, err := m.compose(&mail.Address{Address: "a@example.com"}, emailContent{
+		Title:      "Fall detected — <script>",
+		Paragraphs: []string{"Room <b>4</b>"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The plain-text part keeps the text as is
+	if !strings.Contains(string(msg), "Room &lt;b&gt;4&lt;/b&gt;") {
+		t.Errorf("HTML part does not escape the content:\n%s", msg)
+	}
+}
+
+func TestMailerRequiresAdvertisedStartTLS(t *testing.T) {
+	sink := newSMTPSink(t)
+	m := newTestMailer(t, sink)
+	m.config.StartTLS = true
+
+	err := m.SendTest("nurse@example.com", "")
+	if err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Fatalf("SendTest error = %v, want STARTTLS not supported", err)
+	}
+	if n := len(sink.received()); n != 0 {
+		t.Errorf("sink received %d messages over plain text, want 0", n)
+	}
+}
+
+func TestMailerImplicitTLS(t *testing.T) {
+	sink, pool := newSMTPSSink(t)
+	m := newTestMailer(t, sink)
+	m.config.ImplicitTLS = true
+	m.config.StartTLS = true // Ignored once the connection is already TLS
+	m.tlsConfig.RootCAs = pool
+
+	if err := m.SendTest("nurse@example.com", ""); err != nil {
+		t.Fatalf("SendTest: %v", err)
+	}
+	if n := len(sink.received()); n != 1 {
+		t.Errorf("sink received %d messages, want 1", n)
+	}
+}
+
+func TestMailerImplicitTLSOnPort465(t *testing.T) {
+	m, err := NewMailer(SMTPConfig{Host: "smtp.example.com", Port: "465", From: "alerts@example.com"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.config.ImplicitTLS {
+		t.Error("ImplicitTLS = false on port 465, want true")
+	}
+
+	m, err = NewMailer(SMTPConfig{Host: "smtp.example.com", From: "alerts@example.com", ImplicitTLS: true}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.config.Port != "465" {
+		t.Errorf("Port = %q with ImplicitTLS, want 465", m.config.Port)
+	}
+}
@@ -0,0 +1,172 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"fall-detection/internal/repository"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// escalation is a running chain for one event. cancel receives the reason
+// the chain was stopped.
+type escalation struct {
+	boardID string
+	kind    string
+	cancel  chan string
+}
+
+// Escalator walks each new event through its board's escalation chain,
+// notifying wider circles of people until someone sees or resolves it.
+// Chains live in memory, so events raised before a restart are not escalated
+// further.
+type Escalator struct {
+	Bot              *Bot
+	SubscriptionRepo *repository.SubscriptionRepo
+	Repo             *repository.EscalationRepo // Per-board chains and step records when set
+
+	mu     sync.Mutex
+	active map[int64]*escalation
+}
+
+func NewEscalator(bot *Bot, subscriptionRepo *repository.SubscriptionRepo) *Escalator {
+	return &Escalator{
+		Bot:              bot,
+		SubscriptionRepo: subscriptionRepo,
+		active:           make(map[int64]*escalation),
+	}
+}
+
+// Start runs the board's chain for a new event. The first step usually has
+// no delay and is sent before Start returns. If it fails for any chat, the
+// chain is not started and Start may be called again, which within an
+// outbox delivery only alerts the chats that were missed. An event whose
+// chain is already running is left alone.
+func (e *Escalator) Start(ctx context.Context, eventID int64, boardID, kind string) error {
+	esc := &escalation{boardID: boardID, kind: kind, cancel: make(chan string, 1)}
+
+	e.mu.Lock()
+	if e.active[eventID] != nil {
+		e.mu.Unlock()
+		return nil
+	}
+	e.active[eventID] = esc
+	e.mu.Unlock()
+
+	start := time.Now()
+	steps := e.policy(boardID)
+	if len(steps) > 0 && steps[0].Delay == 0 {
+		if err := e.notify(ctx, eventID, esc, 0, steps[0]); err != nil {
+			e.mu.Lock()
+			delete(e.active, eventID)
+			e.mu.Unlock()
+			return err
+		}
+		go e.run(eventID, esc, start, steps[1:], 1)
+		return nil
+	}
+	go e.run(eventID, esc, start, steps, 0)
+	return nil
+}
+
+// Cancel stops an event's chain, e.g. when someone has seen it.
+func (e *Escalator) Cancel(eventID int64, reason string) {
+	e.mu.Lock()
+	esc := e.active[eventID]
+	delete(e.active, eventID)
+	e.mu.Unlock()
+
+	if esc != nil {
+		esc.cancel <- reason
+	}
+}
+
+func (e *Escalator) policy(boardID string) []repository.EscalationStep {
+	if e.Repo == nil {
+		return repository.DefaultEscalation()
+	}
+	steps, err := e.Repo.GetPolicy(context.Background(), boardID)
+	if err != nil {
+		log.Printf("[Alert] Failed to load escalation policy for %s, using default: %v", boardID, err)
+		return repository.DefaultEscalation()
+	}
+	if len(steps) == 0 {
+		return repository.DefaultEscalation()
+	}
+	return steps
+}
+
+// run waits out the remaining steps, whose delays count from start. first is
+// the position of steps[0] in the board's chain.
+func (e *Escalator) run(eventID int64, esc *escalation, start time.Time, steps []repository.EscalationStep, first int) {
+	defer func() {
+		e.mu.Lock()
+		if e.active[eventID] == esc {
+			delete(e.active, eventID)
+		}
+		e.mu.Unlock()
+	}()
+
+	for i, step := range steps {
+		timer := time.NewTimer(time.Until(start.Add(step.Delay)))
+		select {
+		case reason := <-esc.cancel:
+			timer.Stop()
+			for j, skipped := range steps[i:] {
+				e.record(eventID, first+i+j, skipped.Label, repository.EscalationCancelled, 0, reason)
+			}
+			log.Printf("[Alert] Escalation for event #%d stopped: %s", eventID, reason)
+			return
+		case <-timer.C:
+		}
+		if err := e.notify(context.Background(), eventID, esc, first+i, step); err != nil {
+			log.Printf("[Alert] Failed to escalate event #%d to %q: %v", eventID, step.Label, err)
+		}
+	}
+}
+
+// notify sends one step's alerts and records the step with the number of
+// chats reached. Chats that fail are skipped and reported in the error.
+func (e *Escalator) notify(ctx context.Context, eventID int64, esc *escalation, position int, step repository.EscalationStep) error {
+	var chatIDs []int64
+	if step.ChatID != nil {
+		// The chat may have unsubscribed since the policy was saved
+		sub, err := e.SubscriptionRepo.GetSubscription(ctx, *step.ChatID, esc.boardID)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("get subscription of chat %d to %s: %w", *step.ChatID, esc.boardID, err)
+		}
+		if sub != nil && sub.Authorized() {
+			chatIDs = []int64{*step.ChatID}
+		} else {
+			// Rather than nobody, alert whoever is subscribed
+			log.Printf("[Alert] Chat %d has no approved subscription to %s, escalating event #%d to its subscribers instead", *step.ChatID, esc.boardID, eventID)
+		}
+	}
+	if chatIDs == nil {
+		subscribers, _, _, err := e.SubscriptionRepo.GetSubscribers(ctx, esc.boardID)
+		if err != nil {
+			return fmt.Errorf("get subscribers for %s: %w", esc.boardID, err)
+		}
+		chatIDs = subscribers
+	}
+
+	recipients, err := e.Bot.sendEventAlerts(ctx, chatIDs, esc.boardID, eventID, esc.kind, step.Delay)
+	if position > 0 {
+		log.Printf("[Alert] Escalated event #%d on %s to %q (%d recipients)", eventID, esc.boardID, step.Label, recipients)
+	}
+	e.record(eventID, position, step.Label, repository.EscalationNotified, recipients, "")
+	return err
+}
+
+func (e *Escalator) record(eventID int64, position int, label, status string, recipients int, reason string) {
+	if e.Repo == nil {
+		return
+	}
+	if err := e.Repo.Record(context.Background(), eventID, position, label, status, recipients, reason); err != nil {
+		log.Printf("[Alert] Failed to record escalation step %d for event #%d: %v", position, eventID, err)
+	}
+}
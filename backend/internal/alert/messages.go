@@ -0,0 +1,56 @@
+package alert
+
+import (
+	"fall-detection/internal/repository"
+	"fmt"
+	"time"
+)
+
+// Follow-up messages shared by the Telegram and email channels. Each
+// returns a subject for email and the message text. name is the board's
+// display name, e.g. "Room 12 (board1)".
+
+func seenMessage(name, kind, seenBy string) (string, string) {
+	if kind == repository.EventKindInactivity {
+		return "Inactivity alert seen — " + name, fmt.Sprintf(
+			"👀 %s has seen the inactivity alert on %s\n⚠️ Alert will clear when the board moves again.",
+			seenBy, name,
+		)
+	}
+	return "Fall alert seen — " + name, fmt.Sprintf(
+		"👀 %s has seen the fall alert on %s\n⚠️ Alert will only clear when the board is NFC-tapped.",
+		seenBy, name,
+	)
+}
+
+func resolvedMessage(name, kind, clearedBy string) (string, string) {
+	alert := "Fall alert"
+	if kind == repository.EventKindInactivity {
+		alert = "Inactivity alert"
+	}
+	switch clearedBy {
+	case ClearedByMovement:
+		return alert + " cleared — " + name, fmt.Sprintf("✅ %s on %s has been cleared — the board is moving again.", alert, name)
+	case ClearedByRemote:
+		return alert + " cleared — " + name, fmt.Sprintf("✅ %s on %s has been cleared remotely.", alert, name)
+	}
+	return alert + " cleared — " + name, fmt.Sprintf("✅ %s on %s has been cleared — NFC device was tapped on the board.", alert, name)
+}
+
+func expiredMessage(name string) (string, string) {
+	return "Fall alert not cleared — " + name, fmt.Sprintf(
+		"⚠️ Fall alert on %s has not been cleared after 5 minutes.\n\nThe board may have lost power or be malfunctioning. Please check the board physically.",
+		name,
+	)
+}
+
+func offlineMessage(name string, lastSeen time.Time) (string, string) {
+	return "Board offline — " + name, fmt.Sprintf(
+		"📴 %s has been offline since %s.\n\nFalls cannot be detected until it reconnects. Please check the board's power and Wi-Fi.",
+		name, lastSeen.Format("15:04:05"),
+	)
+}
+
+func onlineMessage(name string, downtime time.Duration) (string, string) {
+	return "Board back online — " + name, fmt.Sprintf("🔌 %s is back online after %s offline.", name, downtime.Round(time.Second))
+}
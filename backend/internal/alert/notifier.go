@@ -0,0 +1,467 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"fall-detection/internal/repository"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// How an event was cleared.
+const (
+	ClearedByNFC      = "nfc"      // NFC device tapped on the board
+	ClearedByRemote   = "remote"   // RESET_FALL sent from Telegram or the HTTP API
+	ClearedByMovement = "movement" // Board moved again after inactivity
+)
+
+// Default per-channel timeouts for the built-in notifiers. Email sends one
+// message per subscriber, so its timeout is per recipient.
+const (
+	TelegramTimeout = 15 * time.Second
+	WebhookTimeout  = 10 * time.Second
+	EmailTimeout    = smtpTimeout
+)
+
+// Notification types stored in the outbox.
+const (
+	notifyFallDetected = "fall_detected"
+	notifySeen         = "seen"
+	notifyResolved     = "resolved"
+	notifyExpired      = "expired"
+	notifyBoardOffline = "board_offline"
+	notifyBoardOnline  = "board_online"
+)
+
+const (
+	// outboxPollInterval is how often channels look for due retries.
+	// Fresh notifications are picked up at once through Wake.
+	outboxPollInterval = 2 * time.Second
+
+	// A failed notification is retried after 5s, 10s, 20s, ... up to
+	// outboxMaxBackoff, and dead-lettered after outboxMaxAttempts.
+	outboxMaxAttempts     = 10
+	outboxInitialBackoff  = 5 * time.Second
+	outboxMaxBackoff      = 10 * time.Minute
+	outboxLeaseMargin     = 30 * time.Second
+	outboxDeliveredMaxAge = 7 * 24 * time.Hour
+)
+
+// AlertEvent is a fall or inactivity event as seen by notifiers.
+type AlertEvent struct {
+	ID        int64     `json:"id"`
+	BoardID   string    `json:"boardID"` // e.g. "board1"
+	Kind      string    `json:"kind"`    // fall or inactivity
+	At        time.Time `json:"at"`
+	SeenBy    string    `json:"seenBy,omitempty"`    // Seen only: who pressed "I've seen this"
+	ClearedBy string    `json:"clearedBy,omitempty"` // Resolved only: ClearedByNFC, ClearedByRemote or ClearedByMovement
+}
+
+// BoardEvent is a board going offline or coming back.
+type BoardEvent struct {
+	BoardID  string        `json:"boardID"`
+	LastSeen time.Time     `json:"lastSeen"` // Last data before going offline
+	Downtime time.Duration `json:"downtime"` // BoardOnline only
+}
+
+// Notifier is an outbound channel for alerts. Methods may block until the
+// notification is handed over, and should give up when ctx is done. A
+// returned error makes the notification be retried, so delivery is at
+// least once.
+type Notifier interface {
+	Name() string
+	FallDetected(ctx context.Context, e AlertEvent) error
+	Seen(ctx context.Context, e AlertEvent) error
+	Resolved(ctx context.Context, e AlertEvent) error
+	Expired(ctx context.Context, e AlertEvent) error
+	BoardOffline(ctx context.Context, b BoardEvent) error
+	BoardOnline(ctx context.Context, b BoardEvent) error
+}
+
+// fanout is implemented by notifiers that send each notification to a
+// board's recipients one at a time. Their channel timeout is per recipient.
+type fanout interface {
+	Recipients(ctx context.Context, boardID string) (int, error)
+}
+
+// recipients tracks which recipients an outbox message has reached, so that
+// a retry after a partial failure only goes to the rest. Notifiers find it
+// in their ctx; outside the outbox there is none and every recipient is
+// sent to.
+type recipients struct {
+	outbox  *repository.OutboxRepo
+	id      int64
+	attempt int // 1-based, counting this one
+
+	mu   sync.Mutex
+	sent map[string]bool
+}
+
+type recipientsKey struct{}
+
+func newRecipients(outbox *repository.OutboxRepo, id int64, attempt int, sent []string) *recipients {
+	r := &recipients{outbox: outbox, id: id, attempt: attempt, sent: make(map[string]bool, len(sent))}
+	for _, recipient := range sent {
+		r.sent[recipient] = true
+	}
+	return r
+}
+
+func withRecipients(ctx context.Context, r *recipients) context.Context {
+	return context.WithValue(ctx, recipientsKey{}, r)
+}
+
+// recipientsFrom returns the message's recipients, nil outside the outbox.
+func recipientsFrom(ctx context.Context) *recipients {
+	r, _ := ctx.Value(recipientsKey{}).(*recipients)
+	return r
+}
+
+// reached reports whether an earlier attempt already reached recipient.
+func (r *recipients) reached(recipient string) bool {
+	if r == nil {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.sent[recipient]
+}
+
+// delivered records that recipient has been reached. It may be called after
+// the attempt has timed out, for sends that finish late.
+func (r *recipients) delivered(recipient string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.sent[recipient] = true
+	r.mu.Unlock()
+	if err := r.outbox.RecordRecipient(context.Background(), r.id, recipient); err != nil {
+		log.Printf("[Alert] Failed to record recipient of notification #%d: %v", r.id, err)
+	}
+}
+
+// channel delivers one notifier's notifications from the outbox.
+type channel struct {
+	notifier Notifier
+	timeout  time.Duration
+	wake     chan struct{}
+}
+
+// Dispatcher fans notifications out to every configured channel through a
+// durable outbox. Event changes queue one outbox message per channel in
+// their own transaction, and each channel's worker delivers its messages
+// with its own timeout, retrying with backoff and dead-lettering those that
+// keep failing. A slow or failing channel neither delays nor breaks the
+// others.
+type Dispatcher struct {
+	Outbox *repository.OutboxRepo
+
+	mu       sync.RWMutex
+	channels []*channel
+	started  bool
+}
+
+func NewDispatcher(outbox *repository.OutboxRepo) *Dispatcher {
+	return &Dispatcher{Outbox: outbox}
+}
+
+// Add sends future notifications to n as well. Each attempt to deliver to
+// n is cancelled after timeout, or timeout per recipient if n sends to
+// recipients one at a time.
+func (d *Dispatcher) Add(n Notifier, timeout time.Duration) {
+	ch := &channel{notifier: n, timeout: timeout, wake: make(chan struct{}, 1)}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.channels = append(d.channels, ch)
+	if d.started {
+		go d.run(ch)
+	}
+}
+
+// Start runs the channel workers.
+func (d *Dispatcher) Start() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.started {
+		return
+	}
+	d.started = true
+	for _, ch := range d.channels {
+		go d.run(ch)
+	}
+	go d.prune()
+}
+
+// Wake has every channel check the outbox now, after notifications have
+// been committed.
+func (d *Dispatcher) Wake() {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	for _, ch := range d.channels {
+		select {
+		case ch.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// FallDetected queues alerts for a new event.
+func (d *Dispatcher) FallDetected() repository.OutboxFunc {
+	return d.eventMessages(notifyFallDetected, func(e repository.FallEvent) AlertEvent {
+		return AlertEvent{ID: e.ID, BoardID: e.BoardID, Kind: e.Kind, At: e.DetectedAt}
+	})
+}
+
+// Seen queues notifications that seenBy has acknowledged an event.
+func (d *Dispatcher) Seen(seenBy string) repository.OutboxFunc {
+	return d.eventMessages(notifySeen, func(e repository.FallEvent) AlertEvent {
+		return AlertEvent{ID: e.ID, BoardID: e.BoardID, Kind: e.Kind, At: time.Now(), SeenBy: seenBy}
+	})
+}
+
+// Resolved queues notifications that an event was cleared.
+func (d *Dispatcher) Resolved(clearedBy string) repository.OutboxFunc {
+	return d.eventMessages(notifyResolved, func(e repository.FallEvent) AlertEvent {
+		return AlertEvent{ID: e.ID, BoardID: e.BoardID, Kind: e.Kind, At: resolvedAt(e), ClearedBy: clearedBy}
+	})
+}
+
+// Expired queues warnings that an event timed out without being cleared.
+func (d *Dispatcher) Expired() repository.OutboxFunc {
+	return d.eventMessages(notifyExpired, func(e repository.FallEvent) AlertEvent {
+		return AlertEvent{ID: e.ID, BoardID: e.BoardID, Kind: e.Kind, At: resolvedAt(e)}
+	})
+}
+
+func resolvedAt(e repository.FallEvent) time.Time {
+	if e.ResolvedAt != nil {
+		return *e.ResolvedAt
+	}
+	return time.Now()
+}
+
+func (d *Dispatcher) eventMessages(notificationType string, toAlertEvent func(repository.FallEvent) AlertEvent) repository.OutboxFunc {
+	return func(e repository.FallEvent) []repository.OutboxMessage {
+		payload, _ := json.Marshal(toAlertEvent(e))
+		return d.messages(notificationType, e.BoardID, payload)
+	}
+}
+
+// messages makes one outbox message per channel.
+func (d *Dispatcher) messages(notificationType, boardID string, payload []byte) []repository.OutboxMessage {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	messages := make([]repository.OutboxMessage, 0, len(d.channels))
+	for _, ch := range d.channels {
+		messages = append(messages, repository.OutboxMessage{
+			Channel: ch.notifier.Name(),
+			Type:    notificationType,
+			BoardID: boardID,
+			Payload: payload,
+		})
+	}
+	return messages
+}
+
+// Send queues notify's notifications for an event outside of any event
+// change, and delivers them.
+func (d *Dispatcher) Send(e repository.FallEvent, notify repository.OutboxFunc) {
+	d.enqueue(e.BoardID, notify(e))
+}
+
+func (d *Dispatcher) BoardOffline(b BoardEvent) {
+	payload, _ := json.Marshal(b)
+	d.enqueue(b.BoardID, d.messages(notifyBoardOffline, b.BoardID, payload))
+}
+
+func (d *Dispatcher) BoardOnline(b BoardEvent) {
+	payload, _ := json.Marshal(b)
+	d.enqueue(b.BoardID, d.messages(notifyBoardOnline, b.BoardID, payload))
+}
+
+func (d *Dispatcher) enqueue(boardID string, messages []repository.OutboxMessage) {
+	if err := d.Outbox.Enqueue(context.Background(), messages); err != nil {
+		log.Printf("[Alert] Failed to queue notifications for %s: %v", boardID, err)
+		return
+	}
+	d.Wake()
+}
+
+// run delivers a channel's due messages whenever woken or polled.
+func (d *Dispatcher) run(ch *channel) {
+	ticker := time.NewTicker(outboxPollInterval)
+	defer ticker.Stop()
+	for {
+		for d.deliverNext(ch) {
+		}
+		select {
+		case <-ticker.C:
+		case <-ch.wake:
+		}
+	}
+}
+
+// deliverNext delivers the channel's oldest due message. Returns false when
+// there was nothing to deliver.
+func (d *Dispatcher) deliverNext(ch *channel) bool {
+	name := ch.notifier.Name()
+	m, err := d.Outbox.ClaimNext(context.Background(), name, ch.timeout+outboxLeaseMargin)
+	if err != nil {
+		log.Printf("[Alert] Failed to read %s outbox: %v", name, err)
+		return false
+	}
+	if m == nil {
+		return false
+	}
+
+	// Alerting about an event that was cleared while we were retrying would
+	// only confuse people
+	if m.Type == notifyFallDetected && m.Attempts > 0 && m.EventStatus != nil && *m.EventStatus != "active" {
+		log.Printf("[Alert] Dropping %s alert #%d for event #%d, already %s", name, m.ID, *m.FallEventID, *m.EventStatus)
+		d.markDelivered(m)
+		return true
+	}
+
+	err = d.deliver(ch, m)
+	if err == nil {
+		d.markDelivered(m)
+		return true
+	}
+
+	attempts := m.Attempts + 1
+	dead := attempts >= outboxMaxAttempts
+	next := time.Now().Add(outboxBackoff(attempts))
+	if dead {
+		log.Printf("[Alert] Dead-lettering %s %s notification #%d for %s after %d attempts: %v", name, m.Type, m.ID, m.BoardID, attempts, err)
+	} else {
+		log.Printf("[Alert] %s failed to send %s notification #%d for %s (attempt %d), retrying at %s: %v",
+			name, m.Type, m.ID, m.BoardID, attempts, next.Format("15:04:05"), err)
+	}
+	if err := d.Outbox.MarkFailed(context.Background(), m.ID, err.Error(), next, dead); err != nil {
+		log.Printf("[Alert] Failed to record failed notification #%d: %v", m.ID, err)
+	}
+	return true
+}
+
+func (d *Dispatcher) markDelivered(m *repository.OutboxMessage) {
+	if err := d.Outbox.MarkDelivered(context.Background(), m.ID); err != nil {
+		log.Printf("[Alert] Failed to mark notification #%d delivered: %v", m.ID, err)
+	}
+}
+
+func outboxBackoff(attempts int) time.Duration {
+	backoff := outboxInitialBackoff
+	for i := 1; i < attempts && backoff < outboxMaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, outboxMaxBackoff)
+}
+
+// prune deletes delivered messages once they are no longer interesting.
+func (d *Dispatcher) prune() {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for range ticker.C {
+		n, err := d.Outbox.DeleteDelivered(context.Background(), time.Now().Add(-outboxDeliveredMaxAge))
+		if err != nil {
+			log.Printf("[Alert] Failed to prune notification outbox: %v", err)
+			continue
+		}
+		if n > 0 {
+			log.Printf("[Alert] Pruned %d delivered notifications", n)
+		}
+	}
+}
+
+// deliver sends one message, cancelling it after the channel's timeout. It
+// waits for the notifier to return even then, so a message is never retried
+// while an earlier attempt is still sending it.
+func (d *Dispatcher) deliver(ch *channel, m *repository.OutboxMessage) error {
+	sent, err := d.Outbox.GetRecipients(context.Background(), m.ID)
+	if err != nil {
+		return fmt.Errorf("get recipients already reached: %w", err)
+	}
+
+	timeout := ch.timeoutFor(m)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	ctx = withRecipients(ctx, newRecipients(d.Outbox, m.ID, m.Attempts+1, sent))
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		done <- ch.send(ctx, m)
+	}()
+
+	// Keep the message leased for as long as it takes, so that no other
+	// worker claims it while this one is still sending
+	heartbeat := time.NewTicker(outboxLeaseMargin / 3)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case err := <-done:
+			if err != nil && ctx.Err() != nil {
+				return fmt.Errorf("timed out after %s: %w", timeout, err)
+			}
+			return err
+		case <-heartbeat.C:
+			if err := d.Outbox.Extend(context.Background(), m.ID, time.Now().Add(outboxLeaseMargin)); err != nil {
+				log.Printf("[Alert] Failed to extend lease on notification #%d: %v", m.ID, err)
+			}
+		}
+	}
+}
+
+// timeoutFor is how long a message may take to deliver.
+func (ch *channel) timeoutFor(m *repository.OutboxMessage) time.Duration {
+	f, ok := ch.notifier.(fanout)
+	if !ok {
+		return ch.timeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ch.timeout)
+	defer cancel()
+	n, err := f.Recipients(ctx, m.BoardID)
+	if err != nil || n < 1 {
+		return ch.timeout
+	}
+	return ch.timeout * time.Duration(n)
+}
+
+func (ch *channel) send(ctx context.Context, m *repository.OutboxMessage) error {
+	n := ch.notifier
+	switch m.Type {
+	case notifyBoardOffline, notifyBoardOnline:
+		var b BoardEvent
+		if err := json.Unmarshal(m.Payload, &b); err != nil {
+			return err
+		}
+		if m.Type == notifyBoardOffline {
+			return n.BoardOffline(ctx, b)
+		}
+		return n.BoardOnline(ctx, b)
+	}
+
+	var e AlertEvent
+	if err := json.Unmarshal(m.Payload, &e); err != nil {
+		return err
+	}
+	switch m.Type {
+	case notifyFallDetected:
+		return n.FallDetected(ctx, e)
+	case notifySeen:
+		return n.Seen(ctx, e)
+	case notifyResolved:
+		return n.Resolved(ctx, e)
+	case notifyExpired:
+		return n.Expired(ctx, e)
+	}
+	return fmt.Errorf("unknown notification type %q", m.Type)
+}
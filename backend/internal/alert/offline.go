@@ -0,0 +1,133 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"fall-detection/internal/mqtt"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	offlineCheckInterval = 10 * time.Second
+
+	// offlineRecoverAfter is how long a board that was reported offline must
+	// keep sending before subscribers are told it is back. A board that goes
+	// quiet for more than offlineRecoverGap during that time stays offline
+	// without a new notification, so a flapping Wi-Fi link does not flood
+	// caregivers.
+	offlineRecoverAfter = 30 * time.Second
+	offlineRecoverGap   = 5 * time.Second
+
+	// offlineSeedWindow bounds which registry boards are tracked after a
+	// restart. Boards silent for longer were reported before the restart, or
+	// have been retired without being disabled, and are not announced again.
+	offlineSeedWindow = 24 * time.Hour
+)
+
+type boardLiveness struct {
+	lastSeen     time.Time
+	offline      bool      // Subscribers have been told the board is offline
+	offlineSince time.Time // Last data before the board went offline
+	backAt       time.Time // When an offline board started sending again
+}
+
+// boardStatus is the retained payload on fall-detection/boardN/status.
+type boardStatus struct {
+	Status   string    `json:"status"` // "online" or "offline"
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+// monitorOffline warns subscribers when a board has been silent for longer
+// than grace, and again when it recovers. Boards seen within
+// offlineSeedWindow are tracked from their last-seen time in the registry, so
+// a board that never reconnects after a server restart is still reported, and
+// any board missing there from the first time the TCP server sees it.
+func (a *Alert) monitorOffline(grace time.Duration) {
+	boards := a.knownBoards()
+
+	ticker := time.NewTicker(offlineCheckInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		now := time.Now()
+
+		// The TCP server prunes stale boards, so keep our own last-seen times
+		for id, lastSeen := range a.TCPServer.LastSeen() {
+			b := boards[id]
+			if b == nil {
+				b = &boardLiveness{}
+				boards[id] = b
+			}
+			if lastSeen.After(b.lastSeen) {
+				b.lastSeen = lastSeen
+				if b.offline && b.backAt.IsZero() {
+					b.backAt = lastSeen
+				}
+			}
+		}
+
+		for id, b := range boards {
+			boardID := "board" + id
+			silent := now.Sub(b.lastSeen)
+
+			if !b.offline {
+				if silent > grace {
+					b.offline = true
+					b.offlineSince = b.lastSeen
+					log.Printf("[Alert] %s offline, last seen %s ago", boardID, silent.Round(time.Second))
+					a.publishStatus(boardID, "offline", b.lastSeen)
+					a.Notifiers.BoardOffline(BoardEvent{BoardID: boardID, LastSeen: b.offlineSince})
+				}
+				continue
+			}
+
+			if b.backAt.IsZero() {
+				continue
+			}
+			if silent > offlineRecoverGap {
+				// Dropped again before it had recovered
+				b.backAt = time.Time{}
+				continue
+			}
+			if now.Sub(b.backAt) >= offlineRecoverAfter {
+				downtime := b.backAt.Sub(b.offlineSince).Round(time.Second)
+				b.offline = false
+				b.backAt = time.Time{}
+				log.Printf("[Alert] %s back online after %s", boardID, downtime)
+				a.publishStatus(boardID, "online", b.lastSeen)
+				a.Notifiers.BoardOnline(BoardEvent{BoardID: boardID, LastSeen: b.lastSeen, Downtime: downtime})
+			}
+		}
+	}
+}
+
+// knownBoards seeds offline tracking with the enabled boards the registry has
+// seen within offlineSeedWindow, keyed by TCP board ID.
+func (a *Alert) knownBoards() map[string]*boardLiveness {
+	boards := make(map[string]*boardLiveness)
+	if a.Bot.Boards == nil {
+		return boards
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	registered, err := a.Bot.Boards.List(ctx)
+	if err != nil {
+		log.Printf("[Alert] Failed to load boards for offline tracking: %v", err)
+		return boards
+	}
+	since := time.Now().Add(-offlineSeedWindow)
+	for _, board := range registered {
+		if !board.Enabled || board.LastSeenAt == nil || board.LastSeenAt.Before(since) {
+			continue
+		}
+		boards[strings.TrimPrefix(board.ID, "board")] = &boardLiveness{lastSeen: *board.LastSeenAt}
+	}
+	return boards
+}
+
+func (a *Alert) publishStatus(boardID, status string, lastSeen time.Time) {
+	payload, _ := json.Marshal(boardStatus{Status: status, LastSeen: lastSeen})
+	mqtt.PublishRetained(a.Client, "fall-detection/"+boardID+"/status", string(payload))
+}
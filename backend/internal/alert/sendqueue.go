@@ -0,0 +1,248 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// SendPriority orders queued Telegram messages. Lower values go first.
+type SendPriority int
+
+const (
+	PriorityAlert SendPriority = iota // Fall and inactivity alerts, including escalations
+	PriorityInfo                      // Everything else: replies, seen/resolved/offline notices
+	numPriorities
+)
+
+// Telegram's documented limits: about 30 messages a second overall, one a
+// second to a single chat and 20 a minute to a group.
+const (
+	telegramGlobalInterval = time.Second / 30
+	telegramChatInterval   = time.Second
+	telegramGroupInterval  = 3 * time.Second
+
+	// telegramMaxFloodRetries is how often a message is retried after 429
+	// Too Many Requests before giving up.
+	telegramMaxFloodRetries = 3
+)
+
+// telegramSender is the part of the Bot API the queue uses.
+type telegramSender interface {
+	Send(c tgbotapi.Chattable) (tgbotapi.Message, error)
+}
+
+type sendJob struct {
+	ctx      context.Context
+	key      string // Deliver only
+	chatID   int64
+	msg      tgbotapi.Chattable
+	priority SendPriority
+	retries  int
+	waiters  []chan error // each buffered, receives the outcome once
+}
+
+// SendQueue sends Telegram messages without going over Telegram's global
+// and per-chat rate limits. Messages to a chat are sent in order, one at a
+// time, and alerts jump ahead of informational messages. When Telegram
+// answers 429 all sending is paused for the retry_after it asks for, since
+// its flood control covers the whole bot, and the message is sent again.
+type SendQueue struct {
+	api telegramSender
+
+	mu          sync.Mutex
+	queued      [numPriorities][]*sendJob
+	pending     map[string]*sendJob // Deliver's messages by key until sent
+	chatNext    map[int64]time.Time // When each chat may next be sent to
+	pausedUntil time.Time           // No sending before this after a 429
+	inFlight    map[int64]bool
+	wake        chan struct{}
+	startWorker sync.Once
+}
+
+func NewSendQueue(api telegramSender) *SendQueue {
+	return &SendQueue{
+		api:      api,
+		pending:  make(map[string]*sendJob),
+		chatNext: make(map[int64]time.Time),
+		inFlight: make(map[int64]bool),
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Send queues a message for chatID and returns a channel that receives the
+// outcome. A message whose ctx is done before it is sent is dropped with
+// ctx's error.
+func (q *SendQueue) Send(ctx context.Context, chatID int64, msg tgbotapi.Chattable, priority SendPriority) <-chan error {
+	done := make(chan error, 1)
+	q.enqueue(&sendJob{ctx: ctx, chatID: chatID, msg: msg, priority: priority, waiters: []chan error{done}})
+	return done
+}
+
+// Deliver queues a message that is sent even if the caller stops waiting,
+// so that an outbox delivery that times out still makes progress. key names
+// the message: while one with the same key is queued or being sent it is
+// not queued again, and the returned channel receives that one's outcome.
+func (q *SendQueue) Deliver(key string, chatID int64, msg tgbotapi.Chattable, priority SendPriority) <-chan error {
+	done := make(chan error, 1)
+	q.mu.Lock()
+	if job := q.pending[key]; job != nil {
+		job.waiters = append(job.waiters, done)
+		q.mu.Unlock()
+		return done
+	}
+	job := &sendJob{ctx: context.Background(), key: key, chatID: chatID, msg: msg, priority: priority, waiters: []chan error{done}}
+	q.pending[key] = job
+	q.mu.Unlock()
+
+	q.enqueue(job)
+	return done
+}
+
+func (q *SendQueue) enqueue(job *sendJob) {
+	q.startWorker.Do(func() { go q.run() })
+
+	q.mu.Lock()
+	q.queued[job.priority] = append(q.queued[job.priority], job)
+	q.mu.Unlock()
+	q.signal()
+}
+
+// finishLocked reports a job's outcome to everyone waiting for it. q.mu
+// must be held.
+func (q *SendQueue) finishLocked(job *sendJob, err error) {
+	if job.key != "" {
+		delete(q.pending, job.key)
+	}
+	for _, done := range job.waiters {
+		done <- err
+	}
+}
+
+// Post queues a message without waiting for it, logging if it fails.
+func (q *SendQueue) Post(chatID int64, msg tgbotapi.Chattable, priority SendPriority) {
+	done := q.Send(context.Background(), chatID, msg, priority)
+	go func() {
+		if err := <-done; err != nil {
+			log.Printf("[Alert] Failed to send Telegram message to %d: %v", chatID, err)
+		}
+	}()
+}
+
+func (q *SendQueue) signal() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run hands messages to senders no faster than the global limit.
+func (q *SendQueue) run() {
+	for {
+		job, wait := q.next(time.Now())
+		if job == nil {
+			if wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-timer.C:
+				case <-q.wake:
+					timer.Stop()
+				}
+			} else {
+				<-q.wake
+			}
+			continue
+		}
+		go q.send(job)
+		time.Sleep(telegramGlobalInterval)
+	}
+}
+
+// next takes the first message, by priority, whose chat may be sent to now.
+// Otherwise it returns how long until a waiting chat is free, or zero if
+// only a send finishing or a new message can change that.
+func (q *SendQueue) next(now time.Time) (*sendJob, time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if until := q.pausedUntil.Sub(now); until > 0 {
+		return nil, until
+	}
+
+	var wait time.Duration
+	for p := range q.queued {
+		jobs := q.queued[p]
+		for i := 0; i < len(jobs); i++ {
+			job := jobs[i]
+			if err := job.ctx.Err(); err != nil {
+				q.finishLocked(job, err)
+				jobs = append(jobs[:i], jobs[i+1:]...)
+				i--
+				continue
+			}
+			if q.inFlight[job.chatID] {
+				continue
+			}
+			if until := q.chatNext[job.chatID].Sub(now); until > 0 {
+				if wait == 0 || until < wait {
+					wait = until
+				}
+				continue
+			}
+
+			q.queued[p] = append(jobs[:i], jobs[i+1:]...)
+			q.inFlight[job.chatID] = true
+			return job, 0
+		}
+		q.queued[p] = jobs
+	}
+	return nil, wait
+}
+
+func (q *SendQueue) send(job *sendJob) {
+	_, err := q.api.Send(job.msg)
+	now := time.Now()
+
+	q.mu.Lock()
+	delete(q.inFlight, job.chatID)
+	q.chatNext[job.chatID] = now.Add(chatInterval(job.chatID))
+
+	var tgErr *tgbotapi.Error
+	flood := errors.As(err, &tgErr) && tgErr.RetryAfter > 0
+	if flood {
+		if until := now.Add(time.Duration(tgErr.RetryAfter) * time.Second); until.After(q.pausedUntil) {
+			q.pausedUntil = until
+		}
+	}
+	retry := flood && job.retries < telegramMaxFloodRetries
+	if retry {
+		job.retries++
+		// Back at the front so the chat's messages stay in order
+		q.queued[job.priority] = append([]*sendJob{job}, q.queued[job.priority]...)
+	} else {
+		if err != nil {
+			err = fmt.Errorf("chat %d: %w", job.chatID, err)
+		}
+		q.finishLocked(job, err)
+	}
+	q.mu.Unlock()
+	q.signal()
+
+	if flood {
+		log.Printf("[Alert] Telegram flood control at chat %d, pausing all sending for %ds", job.chatID, tgErr.RetryAfter)
+	}
+}
+
+// chatInterval is the least time between two messages to a chat. Group
+// chats have negative IDs.
+func chatInterval(chatID int64) time.Duration {
+	if chatID < 0 {
+		return telegramGroupInterval
+	}
+	return telegramChatInterval
+}
@@ -0,0 +1,164 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// fakeSender records the chats it is asked to send to and fails with the
+// queued errors first.
+type fakeSender struct {
+	mu    sync.Mutex
+	chats []int64
+	errs  []error
+}
+
+func (f *fakeSender) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.chats = append(f.chats, c.(tgbotapi.MessageConfig).ChatID)
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		return tgbotapi.Message{}, err
+	}
+	return tgbotapi.Message{}, nil
+}
+
+// newManualQueue returns a queue whose worker never starts, so tests step
+// it with next and send.
+func newManualQueue(api telegramSender) *SendQueue {
+	q := NewSendQueue(api)
+	q.startWorker.Do(func() {})
+	return q
+}
+
+func TestSendQueueAlertsGoFirst(t *testing.T) {
+	q := newManualQueue(&fakeSender{})
+	q.Send(context.Background(), 1, tgbotapi.NewMessage(1, "info"), PriorityInfo)
+	q.Send(context.Background(), 2, tgbotapi.NewMessage(2, "alert"), PriorityAlert)
+
+	now := time.Now()
+	for _, want := range []int64{2, 1} {
+		job, _ := q.next(now)
+		if job == nil || job.chatID != want {
+			t.Fatalf("next = %+v, want chat %d", job, want)
+		}
+	}
+}
+
+func TestSendQueueChatInterval(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		chatID   int64
+		interval time.Duration
+	}{
+		{"private chat", 1, telegramChatInterval},
+		{"group chat", -100, telegramGroupInterval},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newManualQueue(&fakeSender{})
+			first := q.Send(context.Background(), tt.chatID, tgbotapi.NewMessage(tt.chatID, "one"), PriorityInfo)
+			q.Send(context.Background(), tt.chatID, tgbotapi.NewMessage(tt.chatID, "two"), PriorityInfo)
+
+			job, _ := q.next(time.Now())
+			q.send(job)
+			if err := <-first; err != nil {
+				t.Fatalf("first message: %v", err)
+			}
+			sentAt := time.Now()
+
+			if job, wait := q.next(sentAt); job != nil || wait <= 0 || wait > tt.interval {
+				t.Fatalf("next right after sending = %+v, %s; want nothing for up to %s", job, wait, tt.interval)
+			}
+			if job, _ := q.next(sentAt.Add(tt.interval)); job == nil {
+				t.Fatalf("next after %s = nil, want the second message", tt.interval)
+			}
+		})
+	}
+}
+
+func TestSendQueueFloodControlPausesAllChats(t *testing.T) {
+	api := &fakeSender{errs: []error{&tgbotapi.Error{
+		Code:               429,
+		Message:            "Too Many Requests: retry after 5",
+		ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 5},
+	}}}
+	q := newManualQueue(api)
+	flooded := q.Send(context.Background(), 1, tgbotapi.NewMessage(1, "alert"), PriorityAlert)
+	q.Send(context.Background(), 2, tgbotapi.NewMessage(2, "info"), PriorityInfo)
+
+	job, _ := q.next(time.Now())
+	q.send(job)
+	select {
+	case err := <-flooded:
+		t.Fatalf("flooded message finished with %v, want it retried", err)
+	default:
+	}
+
+	// Another chat has to wait as well
+	now := time.Now()
+	if job, wait := q.next(now); job != nil || wait < 4*time.Second {
+		t.Fatalf("next during flood control = %+v, %s; want nothing for about 5s", job, wait)
+	}
+
+	job, _ = q.next(now.Add(5 * time.Second))
+	if job == nil || job.chatID != 1 || job.retries != 1 {
+		t.Fatalf("next after flood control = %+v, want the retried message to chat 1", job)
+	}
+	q.send(job)
+	if err := <-flooded; err != nil {
+		t.Fatalf("retried message: %v", err)
+	}
+	if len(api.chats) != 2 {
+		t.Errorf("sent %d times, want 2", len(api.chats))
+	}
+}
+
+func TestSendQueueFloodControlGivesUp(t *testing.T) {
+	flood := &tgbotapi.Error{Code: 429, ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 1}}
+	api := &fakeSender{}
+	for range telegramMaxFloodRetries + 1 {
+		api.errs = append(api.errs, flood)
+	}
+	q := newManualQueue(api)
+	done := q.Send(context.Background(), 1, tgbotapi.NewMessage(1, "alert"), PriorityAlert)
+
+	now := time.Now()
+	for i := 0; i <= telegramMaxFloodRetries; i++ {
+		job, _ := q.next(now.Add(time.Duration(i) * time.Minute))
+		if job == nil {
+			t.Fatalf("attempt %d: nothing to send", i+1)
+		}
+		q.send(job)
+	}
+	if err := <-done; !errors.As(err, &flood) {
+		t.Fatalf("error = %v, want the flood control error", err)
+	}
+}
+
+func TestSendQueueDeliverSharesQueuedMessage(t *testing.T) {
+	api := &fakeSender{}
+	q := newManualQueue(api)
+	first := q.Deliver("7:1", 1, tgbotapi.NewMessage(1, "alert"), PriorityAlert)
+	second := q.Deliver("7:1", 1, tgbotapi.NewMessage(1, "alert"), PriorityAlert)
+
+	job, _ := q.next(time.Now())
+	q.send(job)
+	if job, _ := q.next(time.Now().Add(time.Minute)); job != nil {
+		t.Fatalf("second Deliver queued another message: %+v", job)
+	}
+	for _, done := range []<-chan error{first, second} {
+		if err := <-done; err != nil {
+			t.Errorf("Deliver: %v", err)
+		}
+	}
+	if len(api.chats) != 1 {
+		t.Errorf("sent %d times, want 1", len(api.chats))
+	}
+}
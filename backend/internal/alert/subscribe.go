@@ -0,0 +1,147 @@
+package alert
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fall-detection/internal/repository"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+// A chat that gives maxPairingFailures wrong pairing codes within
+// pairingFailureWindow must wait before trying again, so codes cannot be
+// guessed.
+const (
+	maxPairingFailures   = 5
+	pairingFailureWindow = 15 * time.Minute
+)
+
+// subscribe handles "/subscribe board1 CODE". Boards in approval mode file
+// a request for an admin instead, and other boards need their pairing code.
+func (b *Bot) subscribe(msg *tgbotapi.Message, args string) {
+	chatID := msg.Chat.ID
+	fields := strings.Fields(args)
+	if len(fields) == 0 || len(fields) > 2 || !boardIDPattern.MatchString(fields[0]) {
+		b.reply(tgbotapi.NewMessage(chatID, "Invalid format. Usage: /subscribe board#number CODE\nExample: /subscribe board1 K7MP2QXR"))
+		return
+	}
+	boardID := fields[0]
+	ctx := context.Background()
+
+	// Legacy subscribers may subscribe again to authorize their subscription
+	existing, err := b.SubscriptionRepo.GetSubscription(ctx, chatID, boardID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		b.reply(tgbotapi.NewMessage(chatID, "Failed to subscribe"))
+		return
+	}
+	if err == nil && existing.Authorized() {
+		b.reply(tgbotapi.NewMessage(chatID, "You are already subscribed to "+b.boardName(boardID)))
+		return
+	}
+
+	access, err := b.SubscriptionRepo.GetAccess(ctx, boardID)
+	if err != nil {
+		log.Printf("[Alert] Failed to load subscription access for %s: %v", boardID, err)
+		b.reply(tgbotapi.NewMessage(chatID, "Failed to subscribe"))
+		return
+	}
+	user := msg.From
+
+	if access.ApprovalRequired {
+		created, err := b.SubscriptionRepo.CreateRequest(ctx, chatID, boardID, user.FirstName, user.UserName)
+		switch {
+		case err != nil:
+			log.Printf("[Alert] Failed to record subscription request for %s: %v", boardID, err)
+			b.reply(tgbotapi.NewMessage(chatID, "Failed to subscribe"))
+		case !created:
+			b.reply(tgbotapi.NewMessage(chatID, "Your request for "+boardID+" is still waiting for approval."))
+		default:
+			log.Printf("[Alert] Chat %d requested to subscribe to %s", chatID, boardID)
+			b.reply(tgbotapi.NewMessage(chatID, "📝 Your request to receive alerts for "+boardID+" has been sent to the care team. You will be told once it is approved."))
+		}
+		return
+	}
+
+	if access.PairingCode == nil {
+		b.reply(tgbotapi.NewMessage(chatID, "🔒 "+boardID+" can only be joined through an invite link. Please ask the care team for one."))
+		return
+	}
+	if len(fields) < 2 {
+		b.reply(tgbotapi.NewMessage(chatID, "🔒 "+boardID+" needs a pairing code. Usage: /subscribe "+boardID+" CODE\nAsk the care team for the code or an invite link."))
+		return
+	}
+	if b.pairingLocked(chatID) {
+		b.reply(tgbotapi.NewMessage(chatID, "Too many wrong pairing codes. Please try again later."))
+		return
+	}
+	if !pairingCodeMatches(*access.PairingCode, fields[1]) {
+		b.pairingFailures[chatID] = append(b.pairingFailures[chatID], time.Now())
+		log.Printf("[Alert] Chat %d gave a wrong pairing code for %s", chatID, boardID)
+		b.reply(tgbotapi.NewMessage(chatID, "❌ That pairing code is not valid for "+boardID+"."))
+		return
+	}
+	delete(b.pairingFailures, chatID)
+
+	approval := repository.Approval{Method: repository.ApprovalPairingCode}
+	if err := b.SubscriptionRepo.CreateSubscription(ctx, chatID, boardID, user.FirstName, user.UserName, approval); err != nil {
+		b.reply(tgbotapi.NewMessage(chatID, "Failed to subscribe"))
+		return
+	}
+	b.reply(tgbotapi.NewMessage(chatID, "Subscribed to "+b.boardName(boardID)))
+}
+
+// pairingLocked reports whether a chat has given too many wrong pairing
+// codes lately, forgetting older failures.
+func (b *Bot) pairingLocked(chatID int64) bool {
+	cutoff := time.Now().Add(-pairingFailureWindow)
+	recent := slices.DeleteFunc(b.pairingFailures[chatID], func(t time.Time) bool { return t.Before(cutoff) })
+	if len(recent) == 0 {
+		delete(b.pairingFailures, chatID)
+		return false
+	}
+	b.pairingFailures[chatID] = recent
+	return len(recent) >= maxPairingFailures
+}
+
+// pairingCodeMatches compares codes case-insensitively in constant time.
+func pairingCodeMatches(code, given string) bool {
+	return subtle.ConstantTimeCompare([]byte(strings.ToUpper(code)), []byte(strings.ToUpper(given))) == 1
+}
+
+// redeemInvite subscribes a chat through a one-time invite link.
+func (b *Bot) redeemInvite(msg *tgbotapi.Message, token string) {
+	chatID := msg.Chat.ID
+	invite, err := b.SubscriptionRepo.RedeemInvite(context.Background(), token, chatID, msg.From.FirstName, msg.From.UserName)
+	if errors.Is(err, repository.ErrInviteInvalid) {
+		b.reply(tgbotapi.NewMessage(chatID, "❌ This invite link is invalid, has expired or was already used. Please ask the care team for a new one."))
+		return
+	}
+	if err != nil {
+		log.Printf("[Alert] Failed to redeem invite for chat %d: %v", chatID, err)
+		b.reply(tgbotapi.NewMessage(chatID, "Failed to subscribe"))
+		return
+	}
+	log.Printf("[Alert] Chat %d subscribed to %s with invite #%d", chatID, invite.BoardID, invite.ID)
+	b.reply(tgbotapi.NewMessage(chatID, "👋 Welcome! You will now receive alerts for "+b.boardName(invite.BoardID)+".\nSend /help to see what else I can do."))
+}
+
+// SubscriptionDecided tells a chat that an admin decided on its request to
+// subscribe.
+func (b *Bot) SubscriptionDecided(request repository.SubscriptionRequest) {
+	text := "❌ Your request to receive alerts for " + request.BoardID + " was declined."
+	if request.Status == repository.RequestApproved {
+		text = "✅ Your request was approved. You will now receive alerts for " + b.boardName(request.BoardID) + "."
+	}
+	b.reply(tgbotapi.NewMessage(request.ChatID, text))
+}
+
+// Username is the bot's Telegram username, used in invite links.
+func (b *Bot) Username() string {
+	return b.api.Self.UserName
+}
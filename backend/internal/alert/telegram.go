@@ -0,0 +1,74 @@
+package alert
+
+import (
+	"context"
+	"fall-detection/internal/repository"
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Telegram notifies a board's Telegram subscribers through Bot, escalating
+// events that nobody acknowledges.
+type Telegram struct {
+	Bot              *Bot
+	SubscriptionRepo *repository.SubscriptionRepo
+	Escalation       *Escalator
+}
+
+func NewTelegram(bot *Bot, subscriptionRepo *repository.SubscriptionRepo) *Telegram {
+	return &Telegram{
+		Bot:              bot,
+		SubscriptionRepo: subscriptionRepo,
+		Escalation:       NewEscalator(bot, subscriptionRepo),
+	}
+}
+
+func (t *Telegram) Name() string { return "telegram" }
+
+// FallDetected starts the board's escalation chain, whose first step
+// usually alerts every subscriber at once.
+func (t *Telegram) FallDetected(ctx context.Context, e AlertEvent) error {
+	return t.Escalation.Start(ctx, e.ID, e.BoardID, e.Kind)
+}
+
+func (t *Telegram) Seen(ctx context.Context, e AlertEvent) error {
+	t.Escalation.Cancel(e.ID, repository.CancelSeen)
+	_, text := seenMessage(t.Bot.boardName(e.BoardID), e.Kind, e.SeenBy)
+	return t.broadcast(ctx, e.BoardID, text)
+}
+
+func (t *Telegram) Resolved(ctx context.Context, e AlertEvent) error {
+	t.Escalation.Cancel(e.ID, repository.CancelResolved)
+	_, text := resolvedMessage(t.Bot.boardName(e.BoardID), e.Kind, e.ClearedBy)
+	return t.broadcast(ctx, e.BoardID, text)
+}
+
+func (t *Telegram) Expired(ctx context.Context, e AlertEvent) error {
+	t.Escalation.Cancel(e.ID, repository.CancelExpired)
+	_, text := expiredMessage(t.Bot.boardName(e.BoardID))
+	return t.broadcast(ctx, e.BoardID, text)
+}
+
+func (t *Telegram) BoardOffline(ctx context.Context, b BoardEvent) error {
+	_, text := offlineMessage(t.Bot.boardName(b.BoardID), b.LastSeen)
+	return t.broadcast(ctx, b.BoardID, text)
+}
+
+func (t *Telegram) BoardOnline(ctx context.Context, b BoardEvent) error {
+	_, text := onlineMessage(t.Bot.boardName(b.BoardID), b.Downtime)
+	return t.broadcast(ctx, b.BoardID, text)
+}
+
+// broadcast sends text to every subscriber of a board, carrying on past
+// chats that fail.
+func (t *Telegram) broadcast(ctx context.Context, boardID, text string) error {
+	chatIDs, _, _, err := t.SubscriptionRepo.GetSubscribers(ctx, boardID)
+	if err != nil {
+		return fmt.Errorf("get subscribers for %s: %w", boardID, err)
+	}
+	_, err = t.Bot.sendAll(ctx, chatIDs, PriorityInfo, func(chatID int64) tgbotapi.Chattable {
+		return tgbotapi.NewMessage(chatID, text)
+	})
+	return err
+}
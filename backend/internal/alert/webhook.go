@@ -0,0 +1,253 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fall-detection/internal/repository"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Webhook event types. Inactivity events use the fall types with Kind set
+// to "inactivity".
+const (
+	WebhookFallDetected = "fall.detected"
+	WebhookFallSeen     = "fall.seen"
+	WebhookFallResolved = "fall.resolved"
+	WebhookFallExpired  = "fall.expired"
+	WebhookBoardOffline = "board.offline"
+	WebhookBoardOnline  = "board.online"
+)
+
+// WebhookEvent is the JSON body POSTed to a board's webhooks.
+type WebhookEvent struct {
+	Type      string     `json:"type"`
+	BoardID   string     `json:"boardID"`
+	BoardName string     `json:"boardName,omitempty"`
+	Resident  string     `json:"resident,omitempty"`
+	EventID   int64      `json:"eventID,omitempty"`
+	Kind      string     `json:"kind,omitempty"`      // fall or inactivity
+	SeenBy    string     `json:"seenBy,omitempty"`    // fall.seen only
+	ClearedBy string     `json:"clearedBy,omitempty"` // fall.resolved only: nfc, remote or movement
+	LastSeen  *time.Time `json:"lastSeen,omitempty"`  // board.offline and board.online only
+	At        time.Time  `json:"at"`
+}
+
+// Webhooks POSTs alert events to the URLs configured for each board. Each
+// request carries X-Webhook-Timestamp and an X-Webhook-Signature of
+// "sha256=" followed by the hex HMAC-SHA256 of "<timestamp>.<body>" keyed
+// with the webhook's secret. Every attempt is written to the delivery log;
+// failed deliveries are retried by the outbox.
+type Webhooks struct {
+	Bot  *Bot
+	Repo *repository.WebhookRepo
+
+	client *http.Client
+}
+
+func NewWebhooks(bot *Bot, repo *repository.WebhookRepo) *Webhooks {
+	return &Webhooks{
+		Bot:  bot,
+		Repo: repo,
+		client: &http.Client{
+			Timeout: WebhookTimeout,
+			Transport: &http.Transport{
+				// No proxy, which would dial internal addresses on our behalf
+				DialContext:         webhookDialer().DialContext,
+				TLSHandshakeTimeout: 5 * time.Second,
+			},
+		},
+	}
+}
+
+func (w *Webhooks) Name() string { return "webhooks" }
+
+// Recipients is how many webhooks a board's notifications are POSTed to, one
+// after another.
+func (w *Webhooks) Recipients(ctx context.Context, boardID string) (int, error) {
+	hooks, err := w.Repo.GetEnabled(ctx, boardID)
+	return len(hooks), err
+}
+
+func (w *Webhooks) FallDetected(ctx context.Context, e AlertEvent) error {
+	return w.send(ctx, WebhookEvent{Type: WebhookFallDetected, BoardID: e.BoardID, EventID: e.ID, Kind: e.Kind, At: e.At})
+}
+
+func (w *Webhooks) Seen(ctx context.Context, e AlertEvent) error {
+	return w.send(ctx, WebhookEvent{Type: WebhookFallSeen, BoardID: e.BoardID, EventID: e.ID, Kind: e.Kind, SeenBy: e.SeenBy, At: e.At})
+}
+
+func (w *Webhooks) Resolved(ctx context.Context, e AlertEvent) error {
+	return w.send(ctx, WebhookEvent{Type: WebhookFallResolved, BoardID: e.BoardID, EventID: e.ID, Kind: e.Kind, ClearedBy: e.ClearedBy, At: e.At})
+}
+
+func (w *Webhooks) Expired(ctx context.Context, e AlertEvent) error {
+	return w.send(ctx, WebhookEvent{Type: WebhookFallExpired, BoardID: e.BoardID, EventID: e.ID, Kind: e.Kind, At: e.At})
+}
+
+func (w *Webhooks) BoardOffline(ctx context.Context, b BoardEvent) error {
+	lastSeen := b.LastSeen
+	return w.send(ctx, WebhookEvent{Type: WebhookBoardOffline, BoardID: b.BoardID, LastSeen: &lastSeen, At: time.Now()})
+}
+
+func (w *Webhooks) BoardOnline(ctx context.Context, b BoardEvent) error {
+	lastSeen := b.LastSeen
+	return w.send(ctx, WebhookEvent{Type: WebhookBoardOnline, BoardID: b.BoardID, LastSeen: &lastSeen, At: time.Now()})
+}
+
+// send POSTs an event to the board's enabled webhooks that the outbox
+// message has not reached yet. A webhook that rejects the event outright is
+// not retried; the others are left for the outbox to retry.
+func (w *Webhooks) send(ctx context.Context, event WebhookEvent) error {
+	if event.At.IsZero() {
+		event.At = time.Now()
+	}
+	if board := w.Bot.boardInfo(event.BoardID); board != nil {
+		event.BoardName = board.DisplayName
+	}
+	if resident := w.Bot.boardResident(event.BoardID); resident != nil {
+		event.Resident = resident.Name
+	}
+
+	hooks, err := w.Repo.GetEnabled(ctx, event.BoardID)
+	if err != nil {
+		return fmt.Errorf("load webhooks for %s: %w", event.BoardID, err)
+	}
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	reached := recipientsFrom(ctx)
+	var errs []error
+	for _, hook := range hooks {
+		key := strconv.FormatInt(hook.ID, 10)
+		if reached.reached(key) {
+			continue
+		}
+		postCtx, cancel := context.WithTimeout(ctx, WebhookTimeout)
+		err := w.deliver(postCtx, reached, hook, event, body)
+		cancel()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("webhook #%d: %w", hook.ID, err))
+			continue
+		}
+		reached.delivered(key)
+	}
+	return errors.Join(errs...)
+}
+
+// deliver makes one attempt at POSTing body to a webhook and records it in
+// the delivery log. It returns nil once the webhook has accepted or
+// permanently rejected the event.
+func (w *Webhooks) deliver(ctx context.Context, reached *recipients, hook repository.Webhook, event WebhookEvent, body []byte) error {
+	deliveryID, attempt := webhookDelivery(reached, hook.ID)
+
+	start := time.Now()
+	statusCode, err := w.post(ctx, hook, deliveryID, event.Type, body)
+	succeeded := err == nil && statusCode >= 200 && statusCode < 300
+
+	d := repository.WebhookDelivery{
+		WebhookID:   hook.ID,
+		DeliveryID:  deliveryID,
+		EventType:   event.Type,
+		Attempt:     attempt,
+		Succeeded:   succeeded,
+		Duration:    time.Since(start),
+		AttemptedAt: start,
+	}
+	if event.EventID != 0 {
+		d.FallEventID = &event.EventID
+	}
+	if err != nil {
+		msg := err.Error()
+		d.Error = &msg
+	} else {
+		d.StatusCode = &statusCode
+	}
+	if err := w.Repo.RecordDelivery(context.Background(), d); err != nil {
+		log.Printf("[Alert] Failed to record webhook delivery %s: %v", deliveryID, err)
+	}
+
+	switch {
+	case err != nil:
+		return err
+	case succeeded:
+		return nil
+	case !retryableStatus(statusCode):
+		log.Printf("[Alert] Webhook #%d rejected %s with HTTP %d, not retrying", hook.ID, event.Type, statusCode)
+		return nil
+	default:
+		return fmt.Errorf("HTTP %d", statusCode)
+	}
+}
+
+// webhookDelivery names a webhook's delivery of an outbox message and
+// numbers the attempt. The ID is the same on every outbox attempt, so
+// receivers can drop repeats; outside the outbox each send is a delivery of
+// its own.
+func webhookDelivery(reached *recipients, hookID int64) (string, int) {
+	if reached == nil {
+		return newDeliveryID(), 1
+	}
+	return fmt.Sprintf("n%d-w%d", reached.id, hookID), reached.attempt
+}
+
+func (w *Webhooks) post(ctx context.Context, hook repository.Webhook, deliveryID, eventType string, body []byte) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "fall-detection-webhooks")
+	req.Header.Set("X-Webhook-Event", eventType)
+	req.Header.Set("X-Webhook-Delivery", deliveryID)
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", signWebhook(hook.Secret, timestamp, body))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, nil
+}
+
+func signWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// retryableStatus reports whether a delivery rejected with this status might
+// succeed later.
+func retryableStatus(code int) bool {
+	return code >= 500 || code == http.StatusTooManyRequests || code == http.StatusRequestTimeout
+}
+
+func newDeliveryID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
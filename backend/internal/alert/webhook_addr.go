@@ -0,0 +1,93 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// ErrWebhookAddress is returned for webhook URLs that point into the
+// server's own network, which would let anyone who can add a webhook probe
+// internal services or the cloud metadata endpoint.
+var ErrWebhookAddress = errors.New("webhook address is not public")
+
+// blockedWebhookPrefixes are special-purpose ranges not covered by the
+// netip.Addr predicates used in publicAddr.
+var blockedWebhookPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),      // "This" network
+	netip.MustParsePrefix("100.64.0.0/10"),  // Carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),   // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"),  // Benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),    // Reserved
+	netip.MustParsePrefix("64:ff9b::/96"),   // NAT64, can reach IPv4 private ranges
+	netip.MustParsePrefix("64:ff9b:1::/48"), // Local-use NAT64
+	netip.MustParsePrefix("2002::/16"),      // 6to4, embeds an IPv4 address
+	netip.MustParsePrefix("fec0::/10"),      // Deprecated site-local
+}
+
+// publicAddr reports whether a webhook may be delivered to addr. Loopback,
+// RFC 1918 and unique local, link-local (including 169.254.169.254) and
+// other special-purpose addresses are refused.
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsUnspecified() || addr.IsLoopback() || addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range blockedWebhookPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckWebhookURL checks that a webhook URL is an absolute http or https URL
+// whose host resolves only to public addresses. Delivery checks the address
+// again when connecting, since DNS can change in between.
+func CheckWebhookURL(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return errors.New("url must be an absolute http or https URL")
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", u.Hostname())
+	if err != nil {
+		return fmt.Errorf("cannot resolve %s: %w", u.Hostname(), err)
+	}
+	for _, addr := range addrs {
+		if !publicAddr(addr) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrWebhookAddress, u.Hostname(), addr)
+		}
+	}
+	return nil
+}
+
+// webhookDialer refuses connections to non-public addresses. The check runs
+// on the address actually dialled, after DNS resolution and for every
+// redirect, so a host cannot pass CheckWebhookURL and later rebind to an
+// internal address.
+func webhookDialer() *net.Dialer {
+	return &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !publicAddr(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", ErrWebhookAddress, addrPort.Addr())
+			}
+			return nil
+		},
+	}
+}
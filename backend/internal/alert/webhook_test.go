@@ -0,0 +1,173 @@
+package alert
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"testing"
+	"time"
+
+	"fall-detection/internal/repository"
+)
+
+func TestSignWebhook(t *testing.T) {
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		body      string
+		want      string
+	}{
+		{
+			name:      "event",
+			secret:    "whsec_test",
+			timestamp: "1739355304",
+			body:      `{"type":"fall.detected","boardID":"board1"}`,
+			want:      "sha256=7c249467f9735a9f61063508ab9bd4a9dc77135a063f8d0eeea8e857da98d054",
+		},
+		{
+			name:      "empty body",
+			secret:    "whsec_test",
+			timestamp: "1739355304",
+			body:      "",
+			want:      "sha256=da0b04c65b8e8a9b1e8632bd8bbf1dda2c7940f388cfa0edd833ab1c5ff5a77a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := signWebhook(tt.secret, tt.timestamp, []byte(tt.body)); got != tt.want {
+				t.Errorf("signWebhook() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignWebhookCoversTimestamp(t *testing.T) {
+	body := []byte(`{"type":"fall.seen"}`)
+	if signWebhook("s", "1", body) == signWebhook("s", "2", body) {
+		t.Error("signature does not change with the timestamp, so it can be replayed")
+	}
+	if signWebhook("s", "1", body) == signWebhook("t", "1", body) {
+		t.Error("signature does not change with the secret")
+	}
+}
+
+// TestWebhookPost checks a receiver can verify a request the way the
+// Webhooks doc comment tells integrators to.
+func TestWebhookPost(t *testing.T) {
+	const secret = "whsec_receiver"
+	body := []byte(`{"type":"fall.detected","boardID":"board1","eventID":7}`)
+
+	var got *http.Request
+	var gotBody []byte
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer receiver.Close()
+
+	w := &Webhooks{client: receiver.Client()}
+	hook := repository.Webhook{ID: 1, URL: receiver.URL, Secret: secret}
+	status, err := w.post(context.Background(), hook, "delivery-1", WebhookFallDetected, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != http.StatusAccepted {
+		t.Errorf("status = %d, want %d", status, http.StatusAccepted)
+	}
+
+	if e := got.Header.Get("X-Webhook-Event"); e != WebhookFallDetected {
+		t.Errorf("X-Webhook-Event = %q, want %q", e, WebhookFallDetected)
+	}
+	if d := got.Header.Get("X-Webhook-Delivery"); d != "delivery-1" {
+		t.Errorf("X-Webhook-Delivery = %q, want delivery-1", d)
+	}
+
+	timestamp := got.Header.Get("X-Webhook-Timestamp")
+	sent, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || time.Since(time.Unix(sent, 0)) > time.Minute {
+		t.Errorf("X-Webhook-Timestamp = %q, want the current Unix time", timestamp)
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(gotBody)
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(got.Header.Get("X-Webhook-Signature")), []byte(want)) {
+		t.Errorf("X-Webhook-Signature = %q, want %q", got.Header.Get("X-Webhook-Signature"), want)
+	}
+}
+
+func TestRetryableStatus(t *testing.T) {
+	for code, want := range map[int]bool{
+		http.StatusOK:                  false,
+		http.StatusBadRequest:          false,
+		http.StatusNotFound:            false,
+		http.StatusRequestTimeout:      true,
+		http.StatusTooManyRequests:     true,
+		http.StatusInternalServerError: true,
+		http.StatusBadGateway:          true,
+	} {
+		if got := retryableStatus(code); got != want {
+			t.Errorf("retryableStatus(%d) = %v, want %v", code, got, want)
+		}
+	}
+}
+
+// TestWebhookDelivery checks an outbox message keeps its delivery ID across
+// attempts, so receivers can drop repeats.
+func TestWebhookDelivery(t *testing.T) {
+	reached := newRecipients(nil, 12, 3, nil)
+	id, attempt := webhookDelivery(reached, 5)
+	if id != "n12-w5" || attempt != 3 {
+		t.Errorf("webhookDelivery() = %q, %d, want n12-w5, 3", id, attempt)
+	}
+
+	id, attempt = webhookDelivery(nil, 5)
+	if len(id) != 32 || attempt != 1 {
+		t.Errorf("webhookDelivery(nil) = %q, %d, want a random ID, 1", id, attempt)
+	}
+}
+
+func TestPublicAddr(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"93.184.215.14":    true,
+		"2606:2800:21f::1": true,
+		"127.0.0.1":        false,
+		"10.1.2.3":         false,
+		"172.16.0.1":       false,
+		"192.168.1.10":     false,
+		"169.254.169.254":  false,
+		"100.64.0.1":       false,
+		"0.0.0.0":          false,
+		"::1":              false,
+		"fe80::1":          false,
+		"fd00::1":          false,
+		"::ffff:127.0.0.1": false,
+		"64:ff9b::a00:1":   false,
+	} {
+		if got := publicAddr(netip.MustParseAddr(addr)); got != want {
+			t.Errorf("publicAddr(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func TestWebhookDialerRefusesLoopback(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer receiver.Close()
+
+	w := NewWebhooks(nil, nil)
+	_, err := w.client.Get(receiver.URL)
+	if !errors.Is(err, ErrWebhookAddress) {
+		t.Fatalf("Get(%s) error = %v, want ErrWebhookAddress", receiver.URL, err)
+	}
+}
@@ -0,0 +1,57 @@
+// Package capture records board TCP sessions to disk and plays them back, so
+// incidents seen in the field can be reproduced against a server.
+//
+// A capture file holds one session as JSON lines:
+//
+//	{"t":"2026-02-12T10:15:04.1Z","dir":"open","line":"10.0.0.12:50311"}
+//	{"t":"2026-02-12T10:15:04.3Z","dir":"in","line":"0.12,0.03,9.81,..."}
+//	{"t":"2026-02-12T10:15:09.9Z","dir":"out","line":"CMD,1,STATUS"}
+//	{"t":"2026-02-12T10:16:40.0Z","dir":"close"}
+//
+// A long session is split into several files once the recorder's size limit
+// is reached. Each part begins with its own open record and only the last
+// one ends with close.
+package capture
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Record directions.
+const (
+	DirOpen  = "open"  // Session started, Line is the remote address
+	DirIn    = "in"    // Line received from the board
+	DirOut   = "out"   // Line sent to the board
+	DirClose = "close" // Session ended
+)
+
+type Record struct {
+	Time time.Time `json:"t"`
+	Dir  string    `json:"dir"`
+	Line string    `json:"line,omitempty"`
+}
+
+// ReadFile loads every record of a capture file.
+func ReadFile(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+		records = append(records, r)
+	}
+	return records, scanner.Err()
+}
@@ -0,0 +1,199 @@
+package capture
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Recorder writes one capture file per board connection into a directory.
+type Recorder struct {
+	dir string
+	seq atomic.Uint64
+
+	// MaxBytes splits a session into a new file once its current one has
+	// grown this large. Zero never splits.
+	MaxBytes int64
+	// MaxAge deletes capture files not written to for this long whenever a
+	// new session starts. Zero keeps them forever.
+	MaxAge time.Duration
+}
+
+func NewRecorder(dir string) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create capture dir: %w", err)
+	}
+	return &Recorder{dir: dir}, nil
+}
+
+// Wrap returns a connection that records every line read from and written to
+// conn. If the capture file cannot be created the connection is returned
+// unwrapped, so recording never takes a board offline.
+func (r *Recorder) Wrap(conn net.Conn) net.Conn {
+	now := time.Now()
+	r.prune(now)
+
+	name := fmt.Sprintf("%s-%04d", now.Format("20060102-150405"), r.seq.Add(1))
+	c := &Conn{Conn: conn, recorder: r, name: name}
+	if err := c.openLocked(now); err != nil {
+		log.Printf("[Capture] Failed to create capture file: %v", err)
+		return conn
+	}
+	return c
+}
+
+// prune deletes capture files older than MaxAge.
+func (r *Recorder) prune(now time.Time) {
+	if r.MaxAge <= 0 {
+		return
+	}
+	entries, err := os.ReadDir(r.dir)
+	if err != nil {
+		log.Printf("[Capture] Failed to list capture files: %v", err)
+		return
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".jsonl" {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || now.Sub(info.ModTime()) < r.MaxAge {
+			continue
+		}
+		if err := os.Remove(filepath.Join(r.dir, entry.Name())); err != nil {
+			log.Printf("[Capture] Failed to delete %s: %v", entry.Name(), err)
+			continue
+		}
+		removed++
+	}
+	if removed > 0 {
+		log.Printf("[Capture] Deleted %d capture files older than %s", removed, r.MaxAge)
+	}
+}
+
+// Conn is a recorded connection.
+type Conn struct {
+	net.Conn
+
+	recorder *Recorder
+	name     string // File name of the session's first part, without extension
+	part     int    // Number of files the session has been written to
+
+	mu      sync.Mutex
+	file    *os.File
+	size    int64 // Bytes written to file
+	enc     *json.Encoder
+	partial []byte // Incoming bytes after the last newline
+	once    sync.Once
+}
+
+// NetConn returns the wrapped connection, e.g. to reach a *tls.Conn.
+func (c *Conn) NetConn() net.Conn {
+	return c.Conn
+}
+
+func (c *Conn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if n > 0 {
+		c.mu.Lock()
+		c.partial = append(c.partial, p[:n]...)
+		for {
+			i := bytes.IndexByte(c.partial, '\n')
+			if i < 0 {
+				break
+			}
+			c.writeLocked(Record{Time: time.Now(), Dir: DirIn, Line: strings.TrimRight(string(c.partial[:i]), "\r")})
+			c.partial = c.partial[i+1:]
+		}
+		c.mu.Unlock()
+	}
+	return n, err
+}
+
+func (c *Conn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	if n > 0 {
+		now := time.Now()
+		c.mu.Lock()
+		for _, line := range strings.Split(strings.TrimRight(string(p[:n]), "\n"), "\n") {
+			c.writeLocked(Record{Time: now, Dir: DirOut, Line: line})
+		}
+		c.mu.Unlock()
+	}
+	return n, err
+}
+
+// Close may be called by both the connection handler and a newer connection
+// taking over the board, so the capture file is only closed once.
+func (c *Conn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.writeLocked(Record{Time: time.Now(), Dir: DirClose})
+		c.file.Close()
+		c.file = nil
+	})
+	return err
+}
+
+// openLocked starts the session's next file with an open record, so every
+// part can be replayed on its own. Parts after the first are named
+// <name>_02.jsonl, <name>_03.jsonl and so on, so they sort after it.
+func (c *Conn) openLocked(now time.Time) error {
+	c.part++
+	name := c.name + ".jsonl"
+	if c.part > 1 {
+		name = fmt.Sprintf("%s_%02d.jsonl", c.name, c.part)
+	}
+	f, err := os.Create(filepath.Join(c.recorder.dir, name))
+	if err != nil {
+		return err
+	}
+
+	c.file = f
+	c.size = 0
+	c.enc = json.NewEncoder(countingWriter{f, &c.size})
+	c.writeLocked(Record{Time: now, Dir: DirOpen, Line: c.RemoteAddr().String()})
+	return nil
+}
+
+func (c *Conn) writeLocked(r Record) {
+	if c.file == nil {
+		return
+	}
+	if max := c.recorder.MaxBytes; max > 0 && c.size >= max && r.Dir != DirClose {
+		c.file.Close()
+		c.file = nil
+		if err := c.openLocked(r.Time); err != nil {
+			log.Printf("[Capture] Failed to create capture file, recording stopped: %v", err)
+			return
+		}
+	}
+	if err := c.enc.Encode(r); err != nil {
+		log.Printf("[Capture] Failed to write record: %v", err)
+	}
+}
+
+// countingWriter adds the bytes written through it to n.
+type countingWriter struct {
+	w io.Writer
+	n *int64
+}
+
+func (w countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	*w.n += int64(n)
+	return n, err
+}
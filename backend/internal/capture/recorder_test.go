@@ -0,0 +1,106 @@
+package capture
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecorderSplitsLongSessions(t *testing.T) {
+	dir := t.TempDir()
+	r, err := NewRecorder(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.MaxBytes = 512
+
+	board, server := net.Pipe()
+	conn := r.Wrap(server)
+	go func() {
+		for i := 0; i < 20; i++ {
+			board.Write([]byte("0.12,0.03,9.81,0.01,0.02,0.03,0,1013.25,1\n"))
+		}
+		board.Close()
+	}()
+	buf := make([]byte, 4096)
+	for {
+		if _, err := conn.Read(buf); err != nil {
+			break
+		}
+	}
+	conn.Close()
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) < 2 {
+		t.Fatalf("session written to %d files, want it split", len(paths))
+	}
+
+	var lines int
+	for i, path := range paths {
+		records, err := ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if records[0].Dir != DirOpen {
+			t.Errorf("%s starts with %q, want open", filepath.Base(path), records[0].Dir)
+		}
+		last := records[len(records)-1].Dir
+		if (last == DirClose) != (i == len(paths)-1) {
+			t.Errorf("%s ends with %q, only the last part should end with close", filepath.Base(path), last)
+		}
+		for _, rec := range records {
+			if rec.Dir == DirIn {
+				lines++
+			}
+		}
+	}
+	if lines != 20 {
+		t.Errorf("recorded %d lines across parts, want 20", lines)
+	}
+}
+
+func TestRecorderDeletesOldCaptures(t *testing.T) {
+	dir := t.TempDir()
+	old := filepath.Join(dir, "20260101-000000-0001.jsonl")
+	keep := filepath.Join(dir, "notes.txt")
+	for _, path := range []string{old, keep} {
+		if err := os.WriteFile(path, []byte("{}\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		past := time.Now().Add(-48 * time.Hour)
+		if err := os.Chtimes(path, past, past); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r, err := NewRecorder(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.MaxAge = 24 * time.Hour
+
+	board, server := net.Pipe()
+	defer board.Close()
+	r.Wrap(server).Close()
+
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Errorf("old capture still exists: %v", err)
+	}
+	if _, err := os.Stat(keep); err != nil {
+		t.Errorf("non-capture file was deleted: %v", err)
+	}
+	entries, _ := os.ReadDir(dir)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if len(names) != 2 || !strings.HasSuffix(names[0], "-0001.jsonl") {
+		t.Errorf("files = %q, want the new capture and notes.txt", names)
+	}
+}
@@ -0,0 +1,56 @@
+package capture
+
+import (
+	"fall-detection/internal/telemetry"
+	"io"
+	"time"
+)
+
+// Replay writes the board side of a capture to w, preserving the original
+// gaps between lines divided by speed. A speed of 0 replays as fast as
+// possible. Handshake lines are skipped because a recorded signature cannot
+// answer a fresh challenge; the caller authenticates first if needed.
+func Replay(w io.Writer, records []Record, speed float64) error {
+	var prev time.Time
+	for _, r := range records {
+		if r.Dir != DirIn || telemetry.IsHello(r.Line) || isAuth(r.Line) {
+			continue
+		}
+
+		if speed > 0 && !prev.IsZero() {
+			if gap := r.Time.Sub(prev); gap > 0 {
+				time.Sleep(time.Duration(float64(gap) / speed))
+			}
+		}
+		prev = r.Time
+
+		if _, err := io.WriteString(w, r.Line+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isAuth(line string) bool {
+	_, err := telemetry.ParseAuth(line)
+	return err == nil
+}
+
+// BoardID returns the board a capture belongs to, taken from the hello line
+// or the first valid reading.
+func BoardID(records []Record) string {
+	for _, r := range records {
+		if r.Dir != DirIn {
+			continue
+		}
+		if telemetry.IsHello(r.Line) {
+			if id, err := telemetry.ParseHello(r.Line); err == nil {
+				return id
+			}
+		}
+		if reading, err := telemetry.Parse(r.Line); err == nil {
+			return reading.BoardID
+		}
+	}
+	return ""
+}
@@ -1,8 +1,11 @@
 package config
 
 import (
+	"log"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -17,6 +20,58 @@ var (
 	CORSOrigins  []string
 	DatabaseURL  string
 	BotToken     string
+
+	// BoardAuthRequired rejects boards that do not complete the HMAC
+	// handshake. Off by default so legacy firmware keeps streaming; turn it
+	// on once every board has been provisioned through
+	// POST /boards/:boardID/credentials and flashed with its secret. Boards
+	// that have been provisioned must authenticate either way.
+	BoardAuthRequired bool
+
+	// AdminTokens maps bearer token to admin name for the admin HTTP
+	// routes, from ADMIN_TOKENS="alice:<token>,bob:<token>". Admin routes
+	// refuse every request when it is empty.
+	AdminTokens map[string]string
+
+	// TLS listener for boards. Enabled when TCPTLSPort, TCPTLSCert and
+	// TCPTLSKey are all set; TCPTLSClientCA additionally enables client
+	// certificate authentication.
+	TCPTLSPort     string
+	TCPTLSCert     string
+	TCPTLSKey      string
+	TCPTLSClientCA string
+
+	// BoardOfflineGrace is how long a board may be silent before
+	// subscribers are told it is offline. Zero disables offline alerts.
+	BoardOfflineGrace time.Duration
+
+	// InactivityPeriod is the default time without movement before a
+	// prolonged inactivity alert, for boards without their own settings.
+	// Zero disables inactivity alerts for those boards.
+	InactivityPeriod time.Duration
+
+	// FacilityLocation is the zone analytics group falls by hour and
+	// weekday in, and inactivity quiet hours are read in, from FACILITY_TZ.
+	// Defaults to the server's.
+	FacilityLocation *time.Location
+
+	// CaptureDir records every board session for replay when set. Session
+	// files are split at CaptureMaxBytes and deleted after CaptureMaxAge;
+	// zero disables either.
+	CaptureDir      string
+	CaptureMaxBytes int64
+	CaptureMaxAge   time.Duration
+
+	// SMTP server for email alerts. Email is disabled when SMTPHost is
+	// empty. SMTPImplicitTLS selects SMTPS, which port 465 implies;
+	// otherwise SMTPStartTLS may be turned off for a local test sink.
+	SMTPHost        string
+	SMTPPort        string
+	SMTPUsername    string
+	SMTPPassword    string
+	SMTPFrom        string
+	SMTPStartTLS    bool
+	SMTPImplicitTLS bool
 )
 
 func Load() {
@@ -34,4 +89,92 @@ func Load() {
 	}
 	DatabaseURL = os.Getenv("DATABASE_URL")
 	BotToken = os.Getenv("TELEGRAM_BOT_API_KEY")
+	BoardAuthRequired = os.Getenv("BOARD_AUTH_REQUIRED") == "true"
+	AdminTokens = adminTokensEnv("ADMIN_TOKENS")
+
+	TCPTLSPort = os.Getenv("TCP_TLS_PORT")
+	TCPTLSCert = os.Getenv("TCP_TLS_CERT")
+	TCPTLSKey = os.Getenv("TCP_TLS_KEY")
+	TCPTLSClientCA = os.Getenv("TCP_TLS_CLIENT_CA")
+
+	FacilityLocation = locationEnv("FACILITY_TZ")
+
+	CaptureDir = os.Getenv("TCP_CAPTURE_DIR")
+	CaptureMaxBytes = int64(intEnv("TCP_CAPTURE_MAX_MB", 64)) << 20
+	CaptureMaxAge = durationEnv("TCP_CAPTURE_MAX_AGE", 14*24*time.Hour)
+
+	SMTPHost = os.Getenv("SMTP_HOST")
+	SMTPPort = os.Getenv("SMTP_PORT")
+	SMTPUsername = os.Getenv("SMTP_USERNAME")
+	SMTPPassword = os.Getenv("SMTP_PASSWORD")
+	SMTPFrom = os.Getenv("SMTP_FROM")
+	SMTPStartTLS = os.Getenv("SMTP_STARTTLS") != "false"
+	SMTPImplicitTLS = os.Getenv("SMTP_IMPLICIT_TLS") == "true"
+
+	BoardOfflineGrace = durationEnv("BOARD_OFFLINE_GRACE", 2*time.Minute)
+	InactivityPeriod = durationEnv("INACTIVITY_PERIOD", 0)
+}
+
+// durationEnv parses a Go duration such as "90s", falling back to def when
+// the variable is unset or invalid.
+func durationEnv(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		log.Printf("[Config] Invalid %s %q, using %s", key, v, def)
+		return def
+	}
+	return d
+}
+
+// locationEnv loads an IANA time zone such as "Europe/London", falling back
+// to the server's local zone when the variable is unset or invalid.
+func locationEnv(key string) *time.Location {
+	v := os.Getenv(key)
+	if v == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(v)
+	if err != nil {
+		log.Printf("[Config] Invalid %s %q, using the server's time zone: %v", key, v, err)
+		return time.Local
+	}
+	return loc
+}
+
+// intEnv parses a non-negative integer, falling back to def when the
+// variable is unset or invalid.
+func intEnv(key string, def int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		log.Printf("[Config] Invalid %s %q, using %d", key, v, def)
+		return def
+	}
+	return n
+}
+
+// adminTokensEnv parses comma-separated "name:token" pairs, skipping
+// malformed entries.
+func adminTokensEnv(key string) map[string]string {
+	tokens := make(map[string]string)
+	for _, entry := range strings.Split(os.Getenv(key), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, token, ok := strings.Cut(entry, ":")
+		if !ok || name == "" || len(token) < 16 {
+			log.Printf("[Config] Ignoring malformed %s entry for %q, want name:token with a token of 16+ characters", key, name)
+			continue
+		}
+		tokens[token] = name
+	}
+	return tokens
 }
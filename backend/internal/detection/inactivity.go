@@ -0,0 +1,158 @@
+package detection
+
+import (
+	"errors"
+	"fall-detection/internal/telemetry"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// A reading within both limits counts as no meaningful movement. The gyro
+// limit is in the firmware's units, see GYRO_THRESHOLD in main.c.
+const (
+	inactivityAccelDelta = 1.0 // m/s² away from gravity
+	inactivityGyroMax    = 100.0
+
+	// inactivityMaxGap restarts the clock after a break in the stream, so a
+	// board that was offline is not reported inactive as soon as it returns.
+	inactivityMaxGap = time.Minute
+)
+
+var ErrInvalidInactivitySettings = errors.New("invalid inactivity settings")
+
+// QuietHours is a daily window, in the facility's time zone, during which
+// lying still is expected, e.g. overnight. End may be before Start to span
+// midnight.
+type QuietHours struct {
+	Start int // Minutes after midnight
+	End   int
+}
+
+// Contains reports whether t falls in the window, reading its time of day in
+// t's own location.
+func (q QuietHours) Contains(t time.Time) bool {
+	m := t.Hour()*60 + t.Minute()
+	if q.Start <= q.End {
+		return m >= q.Start && m < q.End
+	}
+	return m >= q.Start || m < q.End
+}
+
+type InactivitySettings struct {
+	Enabled bool
+	Period  time.Duration // No movement for this long raises an event
+	Quiet   *QuietHours
+}
+
+// Validate checks the settings. The period of disabled settings is not
+// used, so it is not checked either.
+func (s InactivitySettings) Validate() error {
+	if s.Enabled && s.Period < time.Minute {
+		return fmt.Errorf("%w: period must be at least 1m", ErrInvalidInactivitySettings)
+	}
+	if q := s.Quiet; q != nil {
+		if q.Start < 0 || q.Start >= 24*60 || q.End < 0 || q.End >= 24*60 || q.Start == q.End {
+			return fmt.Errorf("%w: quiet hours must be two different times of day", ErrInvalidInactivitySettings)
+		}
+	}
+	return nil
+}
+
+type InactivityEvent int
+
+const (
+	InactivityNone    InactivityEvent = iota
+	InactivityStarted                 // Board has not moved for the configured period
+	InactivityEnded                   // Movement resumed after InactivityStarted
+)
+
+type inactivityTrack struct {
+	stillSince  time.Time
+	lastReading time.Time
+	alerted     bool
+}
+
+// InactivityMonitor watches each board's motion for a resident who has
+// stopped moving without tripping the fall state machine, e.g. a slow slump.
+type InactivityMonitor struct {
+	mu       sync.Mutex
+	defaults InactivitySettings
+	settings map[string]InactivitySettings
+	boards   map[string]*inactivityTrack
+	loc      *time.Location // Quiet hours are read in this zone
+}
+
+// NewInactivityMonitor applies defaults to boards without their own
+// settings and reads quiet hours in loc.
+func NewInactivityMonitor(defaults InactivitySettings, loc *time.Location) *InactivityMonitor {
+	return &InactivityMonitor{
+		defaults: defaults,
+		settings: make(map[string]InactivitySettings),
+		boards:   make(map[string]*inactivityTrack),
+		loc:      loc,
+	}
+}
+
+func (m *InactivityMonitor) SetSettings(boardID string, s InactivitySettings) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.settings[boardID] = s
+}
+
+// Settings returns the settings in effect for a board.
+func (m *InactivityMonitor) Settings(boardID string) InactivitySettings {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.settingsFor(boardID)
+}
+
+func (m *InactivityMonitor) settingsFor(boardID string) InactivitySettings {
+	if s, ok := m.settings[boardID]; ok {
+		return s
+	}
+	return m.defaults
+}
+
+// Observe feeds a reading received at the given time and reports whether an
+// inactivity episode started or ended with it.
+func (m *InactivityMonitor) Observe(r telemetry.Reading, at time.Time) InactivityEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := m.settingsFor(r.BoardID)
+	t := m.boards[r.BoardID]
+	if t == nil {
+		t = &inactivityTrack{stillSince: at}
+		m.boards[r.BoardID] = t
+	}
+
+	gap := at.Sub(t.lastReading)
+	t.lastReading = at
+
+	// A confirmed fall is already being alerted on, so it ends the episode
+	// like movement does
+	moving := math.Abs(r.AccelMagnitude()-gravity) > inactivityAccelDelta || r.GyroMagnitude() > inactivityGyroMax
+	if moving || r.FallStatus || !s.Enabled {
+		t.stillSince = at
+		if t.alerted {
+			t.alerted = false
+			return InactivityEnded
+		}
+		return InactivityNone
+	}
+
+	// Quiet hours pause the clock rather than letting it run through the
+	// night, but do not clear an episode that is already being alerted on
+	if gap > inactivityMaxGap || (s.Quiet != nil && s.Quiet.Contains(at.In(m.loc))) {
+		t.stillSince = at
+		return InactivityNone
+	}
+
+	if !t.alerted && at.Sub(t.stillSince) >= s.Period {
+		t.alerted = true
+		return InactivityStarted
+	}
+	return InactivityNone
+}
@@ -0,0 +1,66 @@
+package detection
+
+import (
+	"errors"
+	"fall-detection/internal/telemetry"
+	"testing"
+	"time"
+)
+
+func TestInactivitySettingsValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings InactivitySettings
+		valid    bool
+	}{
+		{"enabled", InactivitySettings{Enabled: true, Period: 30 * time.Minute}, true},
+		{"period too short", InactivitySettings{Enabled: true, Period: 30 * time.Second}, false},
+		{"disabled without a period", InactivitySettings{}, true},
+		{"overnight quiet hours", InactivitySettings{Enabled: true, Period: time.Hour, Quiet: &QuietHours{Start: 22 * 60, End: 7 * 60}}, true},
+		{"empty quiet hours", InactivitySettings{Enabled: true, Period: time.Hour, Quiet: &QuietHours{Start: 60, End: 60}}, false},
+		{"quiet hours past midnight", InactivitySettings{Quiet: &QuietHours{Start: 0, End: 24 * 60}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.settings.Validate()
+			if tt.valid && err != nil {
+				t.Errorf("Validate() = %v, want nil", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidInactivitySettings) {
+				t.Errorf("Validate() = %v, want ErrInvalidInactivitySettings", err)
+			}
+		})
+	}
+}
+
+func TestInactivityQuietHoursUseMonitorZone(t *testing.T) {
+	settings := InactivitySettings{Enabled: true, Period: time.Minute, Quiet: &QuietHours{Start: 22 * 60, End: 7 * 60}}
+	still := telemetry.Reading{AccelZ: gravity, BoardID: "1"}
+	// 14:00 UTC is 22:00 in the facility, inside its quiet hours
+	start := time.Date(2024, 3, 1, 14, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		loc     *time.Location
+		alerted bool
+	}{
+		{"facility zone", time.FixedZone("UTC+8", 8*60*60), false},
+		{"UTC", time.UTC, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewInactivityMonitor(settings, tt.loc)
+			alerted := false
+			for at := start; at.Before(start.Add(5 * time.Minute)); at = at.Add(10 * time.Second) {
+				if m.Observe(still, at) == InactivityStarted {
+					alerted = true
+				}
+			}
+			if alerted != tt.alerted {
+				t.Errorf("alerted = %v, want %v", alerted, tt.alerted)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package detection
+
+import (
+	"fall-detection/internal/telemetry"
+	"time"
+)
+
+const (
+	// recoveryTime is how long after an impact the wearer may keep moving
+	// before the firmware gives up on the fall (hard-coded 5000 in main.c).
+	recoveryTime = 5 * time.Second
+
+	// baroBonus lowers the impact thresholds when the barometer agrees that
+	// the board dropped in altitude.
+	baroBonus = 0.85
+
+	// gravity seeds the previous magnitude like prev_accel_magnitude in main.c.
+	gravity = 9.8
+)
+
+// Machine replays the firmware's freefall → impact → lying → confirmed state
+// machine on one board's readings. It sees the 5 Hz telemetry stream rather
+// than the firmware's 20 Hz samples, so short spikes can be missed.
+//
+// The server cannot observe NFC taps, so a confirmed fall is cleared when the
+// board itself reports that it left the confirmed state.
+type Machine struct {
+	Thresholds telemetry.Thresholds
+
+	state      telemetry.FallState
+	freefallAt time.Time
+	impactAt   time.Time
+	lyingAt    time.Time
+
+	prevAccel    float64
+	prevPressure float64
+}
+
+func NewMachine(t telemetry.Thresholds) *Machine {
+	return &Machine{Thresholds: t, prevAccel: gravity}
+}
+
+func (m *Machine) State() telemetry.FallState {
+	return m.state
+}
+
+// Step advances the machine with a reading received at the given time and
+// returns the new state.
+func (m *Machine) Step(r telemetry.Reading, at time.Time) telemetry.FallState {
+	t := m.Thresholds
+	accel := r.AccelMagnitude()
+	gyro := r.GyroMagnitude()
+	accelDelta := accel - m.prevAccel
+
+	// Positive = pressure went up = altitude went down
+	var pressureDelta float64
+	if m.prevPressure != 0 {
+		pressureDelta = r.Pressure - m.prevPressure
+	}
+
+	switch m.state {
+	case telemetry.StateNormal:
+		if accel < t.FreefallThreshold {
+			m.state = telemetry.StateFreefallDetected
+			m.freefallAt = at
+		}
+
+	case telemetry.StateFreefallDetected:
+		effectiveImpact := t.ImpactThreshold
+		effectiveDelta := t.ImpactDelta
+		if pressureDelta > t.PressureFallThreshold {
+			effectiveImpact *= baroBonus
+			effectiveDelta *= baroBonus
+		}
+
+		if (accel > effectiveImpact || accelDelta > effectiveDelta) && gyro < t.ImpactGyroMax {
+			if at.Sub(m.freefallAt) < t.FallDetectionTime {
+				m.state = telemetry.StateImpactDetected
+				m.impactAt = at
+				m.lyingAt = time.Time{}
+			} else {
+				m.state = telemetry.StateNormal
+			}
+		} else if at.Sub(m.freefallAt) > t.FallDetectionTime {
+			m.state = telemetry.StateNormal
+		}
+
+	case telemetry.StateImpactDetected:
+		if accel < t.LyingThreshold && gyro < t.GyroThreshold {
+			if m.lyingAt.IsZero() {
+				m.lyingAt = at
+			} else if at.Sub(m.lyingAt) > t.LyingDetectionTime {
+				m.state = telemetry.StateFallConfirmed
+			}
+		} else {
+			m.lyingAt = time.Time{}
+			if at.Sub(m.impactAt) > recoveryTime {
+				m.state = telemetry.StateNormal
+			}
+		}
+
+	case telemetry.StateFallConfirmed:
+		if r.FallState != telemetry.StateFallConfirmed {
+			m.state = telemetry.StateNormal
+		}
+	}
+
+	m.prevAccel = accel
+	m.prevPressure = r.Pressure
+	return m.state
+}
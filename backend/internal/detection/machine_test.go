@@ -0,0 +1,222 @@
+package detection
+
+import (
+	"fall-detection/internal/telemetry"
+	"testing"
+	"time"
+)
+
+// interval is the firmware's WIFI_INTERVAL_MS telemetry cadence.
+const interval = 200 * time.Millisecond
+
+// sample is one telemetry frame in a replayed scenario.
+type sample struct {
+	accel    float64 // m/s², on the z axis
+	gyro     float64 // on the x axis
+	pressure float64 // hPa
+	board    telemetry.FallState
+}
+
+func (s sample) reading() telemetry.Reading {
+	pressure := s.pressure
+	if pressure == 0 {
+		pressure = 1013
+	}
+	return telemetry.Reading{
+		AccelZ:     s.accel,
+		GyroX:      s.gyro,
+		Pressure:   pressure,
+		BoardID:    "1",
+		FallState:  s.board,
+		FallStatus: s.board == telemetry.StateFallConfirmed,
+	}
+}
+
+// repeat returns n copies of s.
+func repeat(s sample, n int) []sample {
+	result := make([]sample, n)
+	for i := range result {
+		result[i] = s
+	}
+	return result
+}
+
+func concat(parts ...[]sample) []sample {
+	var result []sample
+	for _, p := range parts {
+		result = append(result, p...)
+	}
+	return result
+}
+
+// replay steps a machine with the firmware's default thresholds through the
+// samples and returns the state after each one.
+func replay(samples []sample) []telemetry.FallState {
+	m := NewMachine(telemetry.DefaultThresholds())
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	states := make([]telemetry.FallState, len(samples))
+	for i, s := range samples {
+		states[i] = m.Step(s.reading(), start.Add(time.Duration(i)*interval))
+	}
+	return states
+}
+
+var (
+	upright  = sample{accel: 9.8, gyro: 20}
+	freefall = sample{accel: 3.0}
+	impact   = sample{accel: 15.0, gyro: 50}
+	lying    = sample{accel: 9.8, gyro: 10}
+)
+
+func TestMachineReplay(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples []sample
+		want    telemetry.FallState
+	}{
+		{
+			name:    "standing still",
+			samples: repeat(upright, 20),
+			want:    telemetry.StateNormal,
+		},
+		{
+			name:    "freefall",
+			samples: concat(repeat(upright, 3), []sample{freefall}),
+			want:    telemetry.StateFreefallDetected,
+		},
+		{
+			name:    "impact after freefall",
+			samples: concat(repeat(upright, 3), []sample{freefall, impact}),
+			want:    telemetry.StateImpactDetected,
+		},
+		{
+			// The first still sample starts the lying timer, which must then
+			// run for more than LYING_DETECTION_TIME (2s = 10 samples).
+			name:    "lying after impact confirms",
+			samples: concat(repeat(upright, 3), []sample{freefall, impact}, repeat(lying, 12)),
+			want:    telemetry.StateFallConfirmed,
+		},
+		{
+			name:    "lying just under detection time",
+			samples: concat(repeat(upright, 3), []sample{freefall, impact}, repeat(lying, 11)),
+			want:    telemetry.StateImpactDetected,
+		},
+		{
+			// No impact within FALL_DETECTION_TIME, and no jump larger than
+			// IMPACT_DELTA between samples
+			name:    "freefall times out",
+			samples: concat(repeat(upright, 3), repeat(sample{accel: 5.0}, 11), []sample{{accel: 6.5}}),
+			want:    telemetry.StateNormal,
+		},
+		{
+			// A spinning board at impact is someone catching it, not a fall
+			name:    "impact with high gyro ignored",
+			samples: concat(repeat(upright, 3), []sample{freefall, {accel: 15.0, gyro: 500}}),
+			want:    telemetry.StateFreefallDetected,
+		},
+		{
+			name:    "moving after impact recovers",
+			samples: concat(repeat(upright, 3), []sample{freefall, impact}, repeat(sample{accel: 12.0, gyro: 300}, 26)),
+			want:    telemetry.StateNormal,
+		},
+		{
+			name:    "movement resets the lying timer",
+			samples: concat(repeat(upright, 3), []sample{freefall, impact}, repeat(lying, 8), []sample{{accel: 12.0}}, repeat(lying, 8)),
+			want:    telemetry.StateImpactDetected,
+		},
+		{
+			name:    "confirmed until the board clears",
+			samples: concat(repeat(upright, 3), []sample{freefall, impact}, repeat(lying, 12), repeat(sample{accel: 9.8, board: telemetry.StateFallConfirmed}, 20)),
+			want:    telemetry.StateFallConfirmed,
+		},
+		{
+			name:    "board clears a confirmed fall",
+			samples: concat(repeat(upright, 3), []sample{freefall, impact}, repeat(lying, 12), []sample{upright}),
+			want:    telemetry.StateNormal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			states := replay(tt.samples)
+			if got := states[len(states)-1]; got != tt.want {
+				t.Errorf("final state = %v, want %v (states %v)", got, tt.want, states)
+			}
+		})
+	}
+}
+
+// TestMachineBarometerBonus checks that a pressure rise lowers the impact
+// thresholds by 15%, like baro_confirms in main.c.
+func TestMachineBarometerBonus(t *testing.T) {
+	// A 1.8 m/s² jump is under IMPACT_DELTA (2.0) but over 0.85 × 2.0
+	rise := func(pressure float64) []sample {
+		return []sample{
+			{accel: 9.8, pressure: 1013},
+			{accel: 5.0, pressure: 1013},
+			{accel: 6.8, pressure: pressure},
+		}
+	}
+
+	if got := replay(rise(1013))[2]; got != telemetry.StateFreefallDetected {
+		t.Errorf("without pressure rise: state = %v, want %v", got, telemetry.StateFreefallDetected)
+	}
+	if got := replay(rise(1013.1))[2]; got != telemetry.StateImpactDetected {
+		t.Errorf("with pressure rise: state = %v, want %v", got, telemetry.StateImpactDetected)
+	}
+}
+
+func TestVerifier(t *testing.T) {
+	fall := concat(repeat(upright, 3), []sample{freefall, impact}, repeat(lying, 12))
+	confirmedAt := len(fall) - 1
+
+	tests := []struct {
+		name      string
+		boardFall bool // Whether the board reports the fall it confirmed
+		serverSee bool // Whether the readings show a fall
+		want      Stats
+		kinds     []DisagreementKind
+	}{
+		{"both confirm", true, true, Stats{Agreements: 1}, nil},
+		{"board only", true, false, Stats{BoardOnly: 1}, []DisagreementKind{BoardOnly}},
+		{"server only", false, true, Stats{ServerOnly: 1}, []DisagreementKind{ServerOnly}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			samples := repeat(upright, len(fall))
+			if tt.serverSee {
+				samples = concat(fall)
+			}
+			if tt.boardFall {
+				samples[confirmedAt].board = telemetry.StateFallConfirmed
+			}
+			// Keep the board confirmed and the wearer still for longer than
+			// the agreement window so pending confirmations are settled
+			tail := sample{accel: 9.8, gyro: 10}
+			if tt.boardFall {
+				tail.board = telemetry.StateFallConfirmed
+			}
+			samples = concat(samples, repeat(tail, 20))
+
+			var got []DisagreementKind
+			v := NewVerifier()
+			v.OnDisagreement = func(d Disagreement) { got = append(got, d.Kind) }
+
+			start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+			for i, s := range samples {
+				v.Observe(s.reading(), start.Add(time.Duration(i)*interval))
+			}
+
+			stats := v.Stats("1")[Primary]
+			stats.State = 0
+			if stats != tt.want {
+				t.Errorf("stats = %+v, want %+v", stats, tt.want)
+			}
+			if len(got) != len(tt.kinds) || (len(got) > 0 && got[0] != tt.kinds[0]) {
+				t.Errorf("disagreements = %v, want %v", got, tt.kinds)
+			}
+		})
+	}
+}
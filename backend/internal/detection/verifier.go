@@ -0,0 +1,198 @@
+package detection
+
+import (
+	"fall-detection/internal/telemetry"
+	"log"
+	"sync"
+	"time"
+)
+
+// agreementWindow is how far apart the board's and the server's fall
+// confirmations may be and still count as the same fall.
+const agreementWindow = 3 * time.Second
+
+// Primary is the experiment name of the machine that runs the board's own
+// thresholds.
+const Primary = "primary"
+
+type DisagreementKind string
+
+const (
+	BoardOnly  DisagreementKind = "board_only"  // Board confirmed a fall the server did not see
+	ServerOnly DisagreementKind = "server_only" // Server confirmed a fall the board did not raise
+)
+
+type Disagreement struct {
+	BoardID    string           `json:"boardID"`
+	Experiment string           `json:"experiment"`
+	Kind       DisagreementKind `json:"kind"`
+	At         time.Time        `json:"at"`
+}
+
+// Stats counts confirmed falls for one board and experiment.
+type Stats struct {
+	Agreements int
+	BoardOnly  int
+	ServerOnly int
+	State      telemetry.FallState // Current server-side state
+}
+
+// track is one machine's view of a board, with unmatched confirmations
+// waiting for the other side.
+type track struct {
+	machine       *Machine
+	stats         Stats
+	boardPending  time.Time
+	serverPending time.Time
+}
+
+type boardTracks struct {
+	thresholds telemetry.Thresholds
+	lastBoard  telemetry.FallState
+	tracks     map[string]*track
+}
+
+// Verifier runs the server-side state machine next to each board and flags
+// falls on which the two disagree. Extra experiments run alternative
+// thresholds in shadow against the same stream.
+type Verifier struct {
+	mu          sync.Mutex
+	boards      map[string]*boardTracks
+	experiments map[string]telemetry.Thresholds
+
+	// OnDisagreement is called outside the lock for every disagreement.
+	OnDisagreement func(Disagreement)
+}
+
+func NewVerifier() *Verifier {
+	return &Verifier{
+		boards:      make(map[string]*boardTracks),
+		experiments: make(map[string]telemetry.Thresholds),
+	}
+}
+
+// SetThresholds updates the primary machine for a board, e.g. after it
+// acknowledged a new detection profile.
+func (v *Verifier) SetThresholds(boardID string, t telemetry.Thresholds) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	b := v.board(boardID)
+	b.thresholds = t
+	b.tracks[Primary].machine.Thresholds = t
+}
+
+// SetExperiment adds or replaces a shadow experiment on every board.
+func (v *Verifier) SetExperiment(name string, t telemetry.Thresholds) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.experiments[name] = t
+	for _, b := range v.boards {
+		b.tracks[name] = &track{machine: NewMachine(t)}
+	}
+}
+
+func (v *Verifier) RemoveExperiment(name string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	delete(v.experiments, name)
+	for _, b := range v.boards {
+		delete(b.tracks, name)
+	}
+}
+
+func (v *Verifier) Experiments() map[string]telemetry.Thresholds {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	result := make(map[string]telemetry.Thresholds, len(v.experiments))
+	for name, t := range v.experiments {
+		result[name] = t
+	}
+	return result
+}
+
+// Stats returns per-experiment counters for a board, keyed by experiment name.
+func (v *Verifier) Stats(boardID string) map[string]Stats {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	b := v.boards[boardID]
+	if b == nil {
+		return nil
+	}
+	result := make(map[string]Stats, len(b.tracks))
+	for name, tr := range b.tracks {
+		s := tr.stats
+		s.State = tr.machine.State()
+		result[name] = s
+	}
+	return result
+}
+
+// Observe feeds a reading to every machine for the reading's board.
+func (v *Verifier) Observe(r telemetry.Reading, at time.Time) {
+	var found []Disagreement
+
+	v.mu.Lock()
+	b := v.board(r.BoardID)
+	boardConfirmed := r.FallState == telemetry.StateFallConfirmed && b.lastBoard != telemetry.StateFallConfirmed
+	b.lastBoard = r.FallState
+
+	for name, tr := range b.tracks {
+		prev := tr.machine.State()
+		serverConfirmed := tr.machine.Step(r, at) == telemetry.StateFallConfirmed && prev != telemetry.StateFallConfirmed
+
+		if boardConfirmed {
+			tr.boardPending = at
+		}
+		if serverConfirmed {
+			tr.serverPending = at
+		}
+
+		if !tr.boardPending.IsZero() && !tr.serverPending.IsZero() {
+			tr.stats.Agreements++
+			tr.boardPending, tr.serverPending = time.Time{}, time.Time{}
+		}
+		if !tr.boardPending.IsZero() && at.Sub(tr.boardPending) > agreementWindow {
+			tr.stats.BoardOnly++
+			found = append(found, Disagreement{BoardID: r.BoardID, Experiment: name, Kind: BoardOnly, At: tr.boardPending})
+			tr.boardPending = time.Time{}
+		}
+		if !tr.serverPending.IsZero() && at.Sub(tr.serverPending) > agreementWindow {
+			tr.stats.ServerOnly++
+			found = append(found, Disagreement{BoardID: r.BoardID, Experiment: name, Kind: ServerOnly, At: tr.serverPending})
+			tr.serverPending = time.Time{}
+		}
+	}
+	v.mu.Unlock()
+
+	for _, d := range found {
+		log.Printf("[Detection] %s disagreement on board %s (%s) at %s", d.Kind, d.BoardID, d.Experiment, d.At.Format(time.RFC3339))
+		if v.OnDisagreement != nil {
+			v.OnDisagreement(d)
+		}
+	}
+}
+
+// board returns the tracks for a board, creating them on first sight. Must be
+// called with v.mu held.
+func (v *Verifier) board(boardID string) *boardTracks {
+	b := v.boards[boardID]
+	if b != nil {
+		return b
+	}
+
+	b = &boardTracks{
+		thresholds: telemetry.DefaultThresholds(),
+		tracks:     make(map[string]*track),
+	}
+	b.tracks[Primary] = &track{machine: NewMachine(b.thresholds)}
+	for name, t := range v.experiments {
+		b.tracks[name] = &track{machine: NewMachine(t)}
+	}
+	v.boards[boardID] = b
+	return b
+}
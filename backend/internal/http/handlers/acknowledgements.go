@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"fall-detection/internal/repository"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultAckMetricsWindow is how far back metrics look without a since.
+const defaultAckMetricsWindow = 30 * 24 * time.Hour
+
+type AcknowledgementsHandler struct {
+	ackRepo *repository.AcknowledgementRepo
+}
+
+func NewAcknowledgementsHandler(ackRepo *repository.AcknowledgementRepo) *AcknowledgementsHandler {
+	return &AcknowledgementsHandler{ackRepo: ackRepo}
+}
+
+type ackMetricsResponse struct {
+	BoardID      string    `json:"boardID"`
+	Since        time.Time `json:"since"`
+	Events       int       `json:"events"`
+	Acknowledged int       `json:"acknowledged"`
+	MeanSecs     *float64  `json:"meanSecs"` // Null when no event was acknowledged
+	MedianSecs   *float64  `json:"medianSecs"`
+	MaxSecs      *float64  `json:"maxSecs"`
+}
+
+func durationSecs(d *time.Duration) *float64 {
+	if d == nil {
+		return nil
+	}
+	secs := d.Seconds()
+	return &secs
+}
+
+// GetMetrics returns how quickly a board's events were first seen. since is
+// an RFC 3339 timestamp and defaults to 30 days ago.
+func (h *AcknowledgementsHandler) GetMetrics(c *gin.Context) {
+	boardID := c.Param("boardID")
+
+	since := time.Now().Add(-defaultAckMetricsWindow)
+	if v := c.Query("since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid since: " + err.Error()})
+			return
+		}
+		since = t
+	}
+
+	m, err := h.ackRepo.GetMetrics(c.Request.Context(), boardID, since)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, ackMetricsResponse{
+		BoardID:      boardID,
+		Since:        since,
+		Events:       m.Events,
+		Acknowledged: m.Acknowledged,
+		MeanSecs:     durationSecs(m.Mean),
+		MedianSecs:   durationSecs(m.Median),
+		MaxSecs:      durationSecs(m.Max),
+	})
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"fall-detection/internal/repository"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultAnalyticsWindow = 30 * 24 * time.Hour
+	maxAnalyticsWindow     = 366 * 24 * time.Hour
+)
+
+type AnalyticsHandler struct {
+	fallEventRepo *repository.FallEventRepo
+	boardRepo     *repository.BoardRepo
+	location      *time.Location // Default zone for hour and weekday buckets
+}
+
+func NewAnalyticsHandler(fallEventRepo *repository.FallEventRepo, boardRepo *repository.BoardRepo, location *time.Location) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		fallEventRepo: fallEventRepo,
+		boardRepo:     boardRepo,
+		location:      location,
+	}
+}
+
+type responseTimesResponse struct {
+	MedianSecs *float64 `json:"medianSecs"` // Null when no fall reached this point
+	P90Secs    *float64 `json:"p90Secs"`
+}
+
+type fallStatsResponse struct {
+	BoardID       string                `json:"boardID,omitempty"`
+	Name          string                `json:"name,omitempty"`
+	Falls         int                   `json:"falls"`
+	Resolved      int                   `json:"resolved"`
+	Expired       int                   `json:"expired"`
+	Active        int                   `json:"active"`
+	Seen          int                   `json:"seen"`
+	ExpiryRate    float64               `json:"expiryRate"` // Expired share of resolved + expired
+	TimeToSeen    responseTimesResponse `json:"timeToSeen"`
+	TimeToResolve responseTimesResponse `json:"timeToResolve"`
+}
+
+type fallStatsReportResponse struct {
+	From      time.Time           `json:"from"`
+	To        time.Time           `json:"to"`
+	TimeZone  string              `json:"timeZone"` // Of byHour and byWeekday, e.g. Europe/London
+	Summary   fallStatsResponse   `json:"summary"`
+	Boards    []fallStatsResponse `json:"boards,omitempty"`
+	ByHour    [24]int             `json:"byHour"`    // Index is the hour of day
+	ByWeekday [7]int              `json:"byWeekday"` // Index 0 is Sunday
+}
+
+func toFallStatsResponse(s repository.FallStats, names map[string]string) fallStatsResponse {
+	return fallStatsResponse{
+		BoardID:       s.BoardID,
+		Name:          names[s.BoardID],
+		Falls:         s.Falls,
+		Resolved:      s.Resolved,
+		Expired:       s.Expired,
+		Active:        s.Active,
+		Seen:          s.Seen,
+		ExpiryRate:    s.ExpiryRate(),
+		TimeToSeen:    responseTimesResponse{MedianSecs: durationSecs(s.TimeToSeen.Median), P90Secs: durationSecs(s.TimeToSeen.P90)},
+		TimeToResolve: responseTimesResponse{MedianSecs: durationSecs(s.TimeToResolve.Median), P90Secs: durationSecs(s.TimeToResolve.P90)},
+	}
+}
+
+// GetFacilityStats reports on falls across every board, with a breakdown
+// per board.
+func (h *AnalyticsHandler) GetFacilityStats(c *gin.Context) {
+	h.getStats(c, "")
+}
+
+// GetBoardStats reports on one board's falls.
+func (h *AnalyticsHandler) GetBoardStats(c *gin.Context) {
+	h.getStats(c, c.Param("boardID"))
+}
+
+// getStats reports on falls between from and to, RFC 3339 timestamps that
+// default to the last 30 days. tz is the IANA zone to bucket falls by hour
+// and weekday in, defaulting to the facility's.
+func (h *AnalyticsHandler) getStats(c *gin.Context, boardID string) {
+	loc := h.location
+	if v := c.Query("tz"); v != "" {
+		l, err := time.LoadLocation(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tz: " + err.Error()})
+			return
+		}
+		loc = l
+	}
+
+	to := time.Now()
+	if v := c.Query("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to: " + err.Error()})
+			return
+		}
+		to = t
+	}
+	from := to.Add(-defaultAnalyticsWindow)
+	if v := c.Query("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from: " + err.Error()})
+			return
+		}
+		from = t
+	}
+	if !from.Before(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be before to"})
+		return
+	}
+	if to.Sub(from) > maxAnalyticsWindow {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "range must be at most 366 days"})
+		return
+	}
+
+	// detected_at holds the server's wall clock, and pgx sends a time's own
+	// wall clock for TIMESTAMP parameters, so a "Z" bound would be off by the
+	// server's UTC offset
+	from, to = from.In(time.Local), to.In(time.Local)
+
+	report, err := h.fallEventRepo.GetStats(c.Request.Context(), boardID, from, to, loc)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	names, err := h.boardRepo.Names(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := fallStatsReportResponse{
+		From:      report.From,
+		To:        report.To,
+		TimeZone:  report.Location.String(),
+		Summary:   toFallStatsResponse(report.Total, names),
+		ByHour:    report.ByHour,
+		ByWeekday: report.ByWeekday,
+	}
+	if boardID == "" {
+		result.Boards = make([]fallStatsResponse, 0, len(report.Boards))
+		for _, s := range report.Boards {
+			result.Boards = append(result.Boards, toFallStatsResponse(s, names))
+		}
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -1,34 +1,326 @@
 package handlers
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fall-detection/internal/http/middleware"
+	"fall-detection/internal/repository"
 	"fall-detection/internal/tcp"
+	"fall-detection/internal/telemetry"
+	"log"
+	"math"
 	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
 )
 
+// boardIDPattern matches board IDs in registry form, e.g. board1.
+var boardIDPattern = regexp.MustCompile(`^board[1-9]\d*$`)
+
+// boardSecretSize is the length of generated board secrets. The firmware
+// takes the secret as BOARD_SECRET_HEX at build time.
+const boardSecretSize = 32
+
 type BoardHandler struct {
-	tcpServer *tcp.TCPServer
+	tcpServer      *tcp.TCPServer
+	boardRepo      *repository.BoardRepo
+	residentRepo   *repository.ResidentRepo
+	credentialRepo *repository.BoardCredentialRepo
 }
 
-func NewBoardHandler(tcpServer *tcp.TCPServer) *BoardHandler {
+func NewBoardHandler(tcpServer *tcp.TCPServer, boardRepo *repository.BoardRepo, residentRepo *repository.ResidentRepo, credentialRepo *repository.BoardCredentialRepo) *BoardHandler {
 	return &BoardHandler{
-		tcpServer: tcpServer,
+		tcpServer:      tcpServer,
+		boardRepo:      boardRepo,
+		residentRepo:   residentRepo,
+		credentialRepo: credentialRepo,
 	}
 }
 
 func (h *BoardHandler) GetBoards(c *gin.Context) {
 	boards := h.tcpServer.GetBoards()
+	names, err := h.boardRepo.Names(c.Request.Context())
+	if err != nil {
+		// Names are cosmetic, still list the connected boards
+		log.Printf("[Boards] Failed to load board names: %v", err)
+	}
+
 	var result []gin.H
 	for _, board := range boards {
 		if time.Now().Sub(board.LastSeen) <= 2*time.Minute {
+			var packetLoss *float64
+			if stats, ok := h.tcpServer.SequenceStats(board.ID); ok {
+				rate := stats.LossRate()
+				packetLoss = &rate
+			}
 			result = append(result, gin.H{
 				"ID":          board.ID,
+				"Name":        names["board"+board.ID], // empty if unnamed
 				"ConnectedAt": board.ConnectedAt,
 				"LastSeen":    board.LastSeen,
+				"Protocol":    board.ProtocolVersion,
+				"Firmware":    board.Firmware,
+				"Profile":     board.ProfileVersion,
+				"PacketLoss":  packetLoss, // null for boards without sequence numbers
 			})
 		}
 	}
 	c.JSON(http.StatusOK, result)
 }
+
+type boardResponse struct {
+	ID          string     `json:"id"`
+	DisplayName string     `json:"displayName"`
+	Location    string     `json:"location"`
+	Firmware    string     `json:"firmware"`
+	FirstSeenAt *time.Time `json:"firstSeenAt"`
+	LastSeenAt  *time.Time `json:"lastSeenAt"`
+	Enabled     bool       `json:"enabled"`
+	Online      bool       `json:"online"`
+
+	Resident *boardResident `json:"resident"` // Who wears the board, null if unassigned
+}
+
+type boardResident struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	Room string `json:"room"`
+}
+
+func toBoardResponse(b repository.Board, online map[string]bool, residents map[string]repository.Resident) boardResponse {
+	var resident *boardResident
+	if r, ok := residents[b.ID]; ok {
+		resident = &boardResident{ID: r.ID, Name: r.Name, Room: r.Room}
+	}
+	return boardResponse{
+		Resident:    resident,
+		ID:          b.ID,
+		DisplayName: b.DisplayName,
+		Location:    b.Location,
+		Firmware:    b.Firmware,
+		FirstSeenAt: b.FirstSeenAt,
+		LastSeenAt:  b.LastSeenAt,
+		Enabled:     b.Enabled,
+		Online:      online[b.ID],
+	}
+}
+
+// onlineBoards returns the IDs of connected boards in registry form, e.g.
+// board1.
+func (h *BoardHandler) onlineBoards() map[string]bool {
+	online := make(map[string]bool)
+	for _, board := range h.tcpServer.GetBoards() {
+		online["board"+board.ID] = true
+	}
+	return online
+}
+
+// ListBoards returns every known board, connected or not.
+func (h *BoardHandler) ListBoards(c *gin.Context) {
+	boards, err := h.boardRepo.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	residents, err := h.residentRepo.GetAssigned(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	online := h.onlineBoards()
+	result := make([]boardResponse, 0, len(boards))
+	for _, b := range boards {
+		result = append(result, toBoardResponse(b, online, residents))
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *BoardHandler) GetBoard(c *gin.Context) {
+	board, err := h.boardRepo.Get(c.Request.Context(), c.Param("boardID"))
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "board not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.respondBoard(c, *board)
+}
+
+// respondBoard writes a single board with its online status and resident.
+func (h *BoardHandler) respondBoard(c *gin.Context, board repository.Board) {
+	residents := map[string]repository.Resident{}
+	resident, err := h.residentRepo.GetByBoard(c.Request.Context(), board.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if resident != nil {
+		residents[board.ID] = *resident
+	}
+	c.JSON(http.StatusOK, toBoardResponse(board, h.onlineBoards(), residents))
+}
+
+type boardDetailsRequest struct {
+	DisplayName string `json:"displayName"`
+	Location    string `json:"location"`
+	Enabled     *bool  `json:"enabled"` // Omit to keep the current value, true for new boards
+}
+
+// UpdateBoard sets a board's details. Boards can be registered this way
+// before they first connect. Disabling a board stops it streaming from its
+// next connection.
+func (h *BoardHandler) UpdateBoard(c *gin.Context) {
+	boardID := c.Param("boardID")
+	if !boardIDPattern.MatchString(boardID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "board ID must look like board1"})
+		return
+	}
+
+	var req boardDetailsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	details := repository.BoardDetails{
+		DisplayName: strings.TrimSpace(req.DisplayName),
+		Location:    strings.TrimSpace(req.Location),
+		Enabled:     req.Enabled,
+	}
+
+	board, err := h.boardRepo.Save(c.Request.Context(), boardID, details)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.respondBoard(c, *board)
+}
+
+type credentialsResponse struct {
+	BoardID string `json:"boardID"`
+	Secret  string `json:"secret"` // Hex, only returned here
+}
+
+// CreateCredentials generates a new handshake secret for a board, replacing
+// any previous one. The board must be reflashed with the returned secret
+// before its next connection, as the old one stops working immediately.
+func (h *BoardHandler) CreateCredentials(c *gin.Context) {
+	boardID := c.Param("boardID")
+	if !boardIDPattern.MatchString(boardID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "board ID must look like board1"})
+		return
+	}
+
+	secret := make([]byte, boardSecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.credentialRepo.SetSecret(c.Request.Context(), boardID, secret); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	log.Printf("[Boards] %s provisioned a new secret for %s", middleware.Admin(c), boardID)
+	c.JSON(http.StatusCreated, credentialsResponse{BoardID: boardID, Secret: hex.EncodeToString(secret)})
+}
+
+type sequenceStatsResponse struct {
+	tcp.SequenceStats
+	LossRate float64 `json:"lossRate"`
+}
+
+// GetSequenceStats reports dropped, duplicated and reordered frames for a
+// board sending sequence numbers.
+func (h *BoardHandler) GetSequenceStats(c *gin.Context) {
+	boardID := strings.TrimPrefix(c.Param("boardID"), "board")
+
+	stats, ok := h.tcpServer.SequenceStats(boardID)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no sequenced frames received from this board"})
+		return
+	}
+	c.JSON(http.StatusOK, sequenceStatsResponse{SequenceStats: stats, LossRate: stats.LossRate()})
+}
+
+type sendCommandRequest struct {
+	Command    string             `json:"command" binding:"required"`
+	Thresholds map[string]float64 `json:"thresholds"`
+}
+
+type commandResponse struct {
+	ID     uint32 `json:"id"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail"`
+}
+
+// SendCommand forwards a command to a connected board and waits for its ack.
+func (h *BoardHandler) SendCommand(c *gin.Context) {
+	boardID := strings.TrimPrefix(c.Param("boardID"), "board")
+
+	var req sendCommandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cmdType, err := telemetry.ParseCommandType(req.Command)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cmd := telemetry.Command{Type: cmdType}
+	if cmdType == telemetry.CmdSetThresholds {
+		if len(req.Thresholds) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "thresholds are required for " + string(cmdType)})
+			return
+		}
+		// Profile versions are assigned by the profiles endpoint only, so
+		// they keep increasing
+		if _, ok := req.Thresholds["PROFILE_VERSION"]; ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "PROFILE_VERSION is set by the server, use PUT /boards/board" + boardID + "/profile"})
+			return
+		}
+		for name, value := range req.Thresholds {
+			if !telemetry.IsThresholdName(name) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "unknown threshold " + strconv.Quote(name)})
+				return
+			}
+			if math.IsNaN(value) || math.IsInf(value, 0) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": name + " must be a finite number"})
+				return
+			}
+		}
+		cmd = telemetry.NewSetThresholds(req.Thresholds)
+	}
+
+	log.Printf("[Boards] %s sent %s to board%s", middleware.Admin(c), cmdType, boardID)
+	var ack telemetry.Ack
+	if cmdType == telemetry.CmdResetFall {
+		ack, err = h.tcpServer.ResetFall(boardID, nil)
+	} else {
+		ack, err = h.tcpServer.SendCommand(boardID, cmd)
+	}
+	switch {
+	case errors.Is(err, tcp.ErrBoardOffline):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, tcp.ErrCommandTimeout):
+		c.JSON(http.StatusGatewayTimeout, gin.H{"error": err.Error()})
+	case errors.Is(err, tcp.ErrCommandRejected):
+		c.JSON(http.StatusUnprocessableEntity, commandResponse{ID: ack.ID, OK: false, Detail: ack.Detail})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusOK, commandResponse{ID: ack.ID, OK: true, Detail: ack.Detail})
+	}
+}
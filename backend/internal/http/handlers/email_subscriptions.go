@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"errors"
+	"fall-detection/internal/alert"
+	"fall-detection/internal/http/middleware"
+	"fall-detection/internal/repository"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+type EmailSubscriptionsHandler struct {
+	subscriptionRepo *repository.EmailSubscriptionRepo
+	mailer           *alert.Mailer // nil when SMTP is not configured
+}
+
+func NewEmailSubscriptionsHandler(subscriptionRepo *repository.EmailSubscriptionRepo, mailer *alert.Mailer) *EmailSubscriptionsHandler {
+	return &EmailSubscriptionsHandler{
+		subscriptionRepo: subscriptionRepo,
+		mailer:           mailer,
+	}
+}
+
+type emailSubscriptionRequest struct {
+	Email   string `json:"email" binding:"required"`
+	Name    string `json:"name"`
+	BoardID string `json:"boardID" binding:"required"`
+}
+
+type emailSubscriptionResponse struct {
+	ID         int64     `json:"id"`
+	Email      string    `json:"email"`
+	Name       string    `json:"name"`
+	BoardID    string    `json:"boardID"`
+	ApprovedBy string    `json:"approvedBy"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+func toEmailSubscriptionResponse(s repository.EmailSubscription) emailSubscriptionResponse {
+	return emailSubscriptionResponse{
+		ID:         s.ID,
+		Email:      s.Email,
+		Name:       s.Name,
+		BoardID:    s.BoardID,
+		ApprovedBy: s.ApprovedBy,
+		CreatedAt:  s.CreatedAt,
+	}
+}
+
+// emailSubscriptionID parses the :id parameter, responding with 400 if it is
+// invalid.
+func emailSubscriptionID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid subscription id"})
+		return 0, false
+	}
+	return id, true
+}
+
+// ListSubscriptions returns every email subscription, or those of one board
+// or one address with ?boardID= or ?email=.
+func (h *EmailSubscriptionsHandler) ListSubscriptions(c *gin.Context) {
+	var subs []repository.EmailSubscription
+	var err error
+	switch {
+	case c.Query("boardID") != "":
+		subs, err = h.subscriptionRepo.GetByBoard(c.Request.Context(), c.Query("boardID"))
+	case c.Query("email") != "":
+		subs, err = h.subscriptionRepo.GetByEmail(c.Request.Context(), strings.ToLower(c.Query("email")))
+	default:
+		subs, err = h.subscriptionRepo.List(c.Request.Context())
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := make([]emailSubscriptionResponse, 0, len(subs))
+	for _, s := range subs {
+		result = append(result, toEmailSubscriptionResponse(s))
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// Subscribe adds an address to a board's alerts, recording the admin who
+// approved it. Subscribing again updates the recipient's name.
+func (h *EmailSubscriptionsHandler) Subscribe(c *gin.Context) {
+	var req emailSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	addr, err := mail.ParseAddress(req.Email)
+	if err != nil || addr.Address != req.Email {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid email address"})
+		return
+	}
+	if !strings.HasPrefix(req.BoardID, "board") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "board ID must look like board1"})
+		return
+	}
+
+	id, err := h.subscriptionRepo.Subscribe(c.Request.Context(), strings.ToLower(req.Email), req.Name, req.BoardID, middleware.Admin(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	sub, err := h.subscriptionRepo.Get(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, toEmailSubscriptionResponse(*sub))
+}
+
+func (h *EmailSubscriptionsHandler) Unsubscribe(c *gin.Context) {
+	id, ok := emailSubscriptionID(c)
+	if !ok {
+		return
+	}
+	deleted, err := h.subscriptionRepo.Delete(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{"error": "subscription not found"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// SendTest emails the subscriber a test message, to check the SMTP settings.
+func (h *EmailSubscriptionsHandler) SendTest(c *gin.Context) {
+	id, ok := emailSubscriptionID(c)
+	if !ok {
+		return
+	}
+	if h.mailer == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "email is not configured"})
+		return
+	}
+
+	sub, err := h.subscriptionRepo.Get(c.Request.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "subscription not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.mailer.SendTest(sub.Email, sub.Name); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"errors"
+	"fall-detection/internal/repository"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// maxEscalationSteps bounds a chain to something staff can reason about.
+const maxEscalationSteps = 10
+
+type EscalationHandler struct {
+	escalationRepo   *repository.EscalationRepo
+	fallEventRepo    *repository.FallEventRepo
+	subscriptionRepo *repository.SubscriptionRepo
+}
+
+func NewEscalationHandler(escalationRepo *repository.EscalationRepo, fallEventRepo *repository.FallEventRepo, subscriptionRepo *repository.SubscriptionRepo) *EscalationHandler {
+	return &EscalationHandler{
+		escalationRepo:   escalationRepo,
+		fallEventRepo:    fallEventRepo,
+		subscriptionRepo: subscriptionRepo,
+	}
+}
+
+// escalationStepBody notifies chatID, or the board's subscribers when it is
+// null, delaySecs after the event was raised.
+type escalationStepBody struct {
+	DelaySecs int    `json:"delaySecs"`
+	ChatID    *int64 `json:"chatID"`
+	Label     string `json:"label"`
+}
+
+type escalationPolicyResponse struct {
+	BoardID string               `json:"boardID"`
+	Default bool                 `json:"default"` // Board has no chain of its own
+	Steps   []escalationStepBody `json:"steps"`
+}
+
+func toEscalationSteps(steps []repository.EscalationStep) []escalationStepBody {
+	result := make([]escalationStepBody, 0, len(steps))
+	for _, s := range steps {
+		result = append(result, escalationStepBody{
+			DelaySecs: int(s.Delay / time.Second),
+			ChatID:    s.ChatID,
+			Label:     s.Label,
+		})
+	}
+	return result
+}
+
+func (h *EscalationHandler) GetPolicy(c *gin.Context) {
+	boardID := c.Param("boardID")
+	steps, err := h.escalationRepo.GetPolicy(c.Request.Context(), boardID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	isDefault := len(steps) == 0
+	if isDefault {
+		steps = repository.DefaultEscalation()
+	}
+	c.JSON(http.StatusOK, escalationPolicyResponse{BoardID: boardID, Default: isDefault, Steps: toEscalationSteps(steps)})
+}
+
+// UpdatePolicy replaces a board's chain. Steps must be in order of delay and
+// the first must notify at once, so a fall is never held back. A step may
+// only name a chat with an approved subscription to the board. Events
+// already being escalated keep their old chain.
+func (h *EscalationHandler) UpdatePolicy(c *gin.Context) {
+	boardID := c.Param("boardID")
+
+	var body []escalationStepBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(body) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "a policy needs at least one step, DELETE it to restore the default"})
+		return
+	}
+	if body[0].DelaySecs != 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "the first step must have a delay of 0"})
+		return
+	}
+	if len(body) > maxEscalationSteps {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "at most " + strconv.Itoa(maxEscalationSteps) + " steps are allowed"})
+		return
+	}
+
+	steps := make([]repository.EscalationStep, 0, len(body))
+	for i, s := range body {
+		if s.DelaySecs < 0 || (i > 0 && s.DelaySecs < body[i-1].DelaySecs) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "step delays must be non-negative and in increasing order"})
+			return
+		}
+		if s.ChatID != nil {
+			sub, err := h.subscriptionRepo.GetSubscription(c.Request.Context(), *s.ChatID, boardID)
+			if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			if sub == nil || !sub.Authorized() {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "chat " + strconv.FormatInt(*s.ChatID, 10) + " has no approved subscription to " + boardID})
+				return
+			}
+		}
+		steps = append(steps, repository.EscalationStep{
+			Delay:  time.Duration(s.DelaySecs) * time.Second,
+			ChatID: s.ChatID,
+			Label:  s.Label,
+		})
+	}
+
+	if err := h.escalationRepo.SavePolicy(c.Request.Context(), boardID, steps); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, escalationPolicyResponse{BoardID: boardID, Steps: toEscalationSteps(steps)})
+}
+
+// DeletePolicy restores the default of alerting subscribers at once.
+func (h *EscalationHandler) DeletePolicy(c *gin.Context) {
+	boardID := c.Param("boardID")
+	if err := h.escalationRepo.SavePolicy(c.Request.Context(), boardID, nil); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	steps := repository.DefaultEscalation()
+	c.JSON(http.StatusOK, escalationPolicyResponse{BoardID: boardID, Default: true, Steps: toEscalationSteps(steps)})
+}
+
+type escalationRecordResponse struct {
+	Position   int       `json:"position"`
+	Label      string    `json:"label"`
+	Status     string    `json:"status"` // notified or cancelled
+	Recipients int       `json:"recipients"`
+	Reason     *string   `json:"reason"` // seen, resolved or expired for cancelled steps
+	RecordedAt time.Time `json:"recordedAt"`
+}
+
+// GetEventEscalations returns how far a fall event was escalated.
+func (h *EscalationHandler) GetEventEscalations(c *gin.Context) {
+	boardID := c.Param("boardID")
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid fall event id"})
+		return
+	}
+
+	event, err := h.fallEventRepo.GetByID(c.Request.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && event.BoardID != boardID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "fall event not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	records, err := h.escalationRepo.GetByEvent(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := make([]escalationRecordResponse, 0, len(records))
+	for _, r := range records {
+		result = append(result, escalationRecordResponse{
+			Position:   r.Position,
+			Label:      r.Label,
+			Status:     r.Status,
+			Recipients: r.Recipients,
+			Reason:     r.Reason,
+			RecordedAt: r.RecordedAt,
+		})
+	}
+	c.JSON(http.StatusOK, result)
+}
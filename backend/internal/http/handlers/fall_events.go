@@ -1,46 +1,70 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fall-detection/internal/repository"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
 )
 
 type FallEventsHandler struct {
 	fallEventRepo *repository.FallEventRepo
+	traceRepo     *repository.FallEventTraceRepo
+	ackRepo       *repository.AcknowledgementRepo
 }
 
-func NewFallEventsHandler(fallEventRepo *repository.FallEventRepo) *FallEventsHandler {
-	return &FallEventsHandler{fallEventRepo: fallEventRepo}
+func NewFallEventsHandler(fallEventRepo *repository.FallEventRepo, traceRepo *repository.FallEventTraceRepo, ackRepo *repository.AcknowledgementRepo) *FallEventsHandler {
+	return &FallEventsHandler{
+		fallEventRepo: fallEventRepo,
+		traceRepo:     traceRepo,
+		ackRepo:       ackRepo,
+	}
 }
 
 type fallEventResponse struct {
 	ID           int64      `json:"id"`
 	BoardID      string     `json:"boardID"`
+	Kind         string     `json:"kind"` // fall or inactivity
 	DetectedAt   time.Time  `json:"detectedAt"`
 	ResolvedAt   *time.Time `json:"resolvedAt"`
+	ResolvedVia  *string    `json:"resolvedVia"` // nfc, remote or movement; null unless resolved
+	ResolvedBy   *int64     `json:"resolvedBy"`  // Telegram user who sent a remote reset
 	Status       string     `json:"status"`
 	DurationSecs *float64   `json:"durationSecs"`
+	ResidentID   *int64     `json:"residentID"` // Who wore the board, null if unassigned
+	ResidentName *string    `json:"residentName"`
+
+	Acknowledgements []acknowledgementResponse `json:"acknowledgements"` // Oldest first
+	TimeToAckSecs    *float64                  `json:"timeToAckSecs"`    // Until the first acknowledgement
 }
 
-func (h *FallEventsHandler) GetFallEvents(c *gin.Context) {
-	boardID := c.Param("boardID")
-	events, err := h.fallEventRepo.GetByBoard(c.Request.Context(), boardID, 50)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
+type acknowledgementResponse struct {
+	UserID         int64     `json:"userID"` // Telegram user
+	Name           string    `json:"name"`
+	AcknowledgedAt time.Time `json:"acknowledgedAt"`
+}
 
+func toFallEventResponses(events []repository.FallEvent) []fallEventResponse {
 	result := make([]fallEventResponse, 0, len(events))
 	for _, e := range events {
 		r := fallEventResponse{
-			ID:         e.ID,
-			BoardID:    e.BoardID,
-			DetectedAt: e.DetectedAt,
-			ResolvedAt: e.ResolvedAt,
-			Status:     e.Status,
+			ID:           e.ID,
+			BoardID:      e.BoardID,
+			Kind:         e.Kind,
+			DetectedAt:   e.DetectedAt,
+			ResolvedAt:   e.ResolvedAt,
+			ResolvedVia:  e.ResolvedVia,
+			ResolvedBy:   e.ResolvedBy,
+			Status:       e.Status,
+			ResidentID:   e.ResidentID,
+			ResidentName: e.ResidentName,
+
+			Acknowledgements: []acknowledgementResponse{},
 		}
 		if e.ResolvedAt != nil {
 			d := e.ResolvedAt.Sub(e.DetectedAt).Seconds()
@@ -48,6 +72,89 @@ func (h *FallEventsHandler) GetFallEvents(c *gin.Context) {
 		}
 		result = append(result, r)
 	}
+	return result
+}
+
+// withAcknowledgements fills in who has seen each event.
+func withAcknowledgements(ctx context.Context, ackRepo *repository.AcknowledgementRepo, events []fallEventResponse) error {
+	if len(events) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(events))
+	for i, e := range events {
+		ids[i] = e.ID
+	}
+	acks, err := ackRepo.GetByEvents(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range events {
+		for _, a := range acks[events[i].ID] {
+			events[i].Acknowledgements = append(events[i].Acknowledgements, acknowledgementResponse{
+				UserID:         a.UserID,
+				Name:           a.DisplayName,
+				AcknowledgedAt: a.AcknowledgedAt,
+			})
+		}
+		if len(events[i].Acknowledgements) > 0 {
+			d := events[i].Acknowledgements[0].AcknowledgedAt.Sub(events[i].DetectedAt).Seconds()
+			events[i].TimeToAckSecs = &d
+		}
+	}
+	return nil
+}
+
+func (h *FallEventsHandler) GetFallEvents(c *gin.Context) {
+	boardID := c.Param("boardID")
+	events, err := h.fallEventRepo.GetByBoard(c.Request.Context(), boardID, 50)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
+	result := toFallEventResponses(events)
+	if err := withAcknowledgements(c.Request.Context(), h.ackRepo, result); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 	c.JSON(http.StatusOK, result)
 }
+
+type traceResponse struct {
+	FallEventID int64                   `json:"fallEventID"`
+	DetectedAt  time.Time               `json:"detectedAt"`
+	Readings    []sensorReadingResponse `json:"readings"`
+}
+
+// GetFallEventTrace returns the readings captured before and after a fall.
+func (h *FallEventsHandler) GetFallEventTrace(c *gin.Context) {
+	boardID := c.Param("boardID")
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid fall event id"})
+		return
+	}
+
+	event, err := h.fallEventRepo.GetByID(c.Request.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && event.BoardID != boardID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "fall event not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	readings, err := h.traceRepo.GetByEvent(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, traceResponse{
+		FallEventID: id,
+		DetectedAt:  event.DetectedAt,
+		Readings:    toSensorReadingResponses(readings),
+	})
+}
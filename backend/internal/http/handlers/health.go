@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"fall-detection/internal/mqtt"
+	"fall-detection/internal/tcp"
 	"net/http"
 	"time"
 
@@ -9,13 +10,19 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// Boards losing more than this fraction of frames are reported as degraded.
+// Packet loss does not fail the health check, as the server itself is fine.
+const degradedLossRate = 0.05
+
 type HealthHandler struct {
-	Clients map[string]pahomqtt.Client
+	Clients   map[string]pahomqtt.Client
+	TCPServer *tcp.TCPServer
 }
 
-func NewHealthHandler(clients map[string]pahomqtt.Client) HealthHandler {
+func NewHealthHandler(clients map[string]pahomqtt.Client, tcpServer *tcp.TCPServer) HealthHandler {
 	return HealthHandler{
-		Clients: clients,
+		Clients:   clients,
+		TCPServer: tcpServer,
 	}
 }
 
@@ -51,5 +58,35 @@ func (h HealthHandler) GetHealth(c *gin.Context) {
 			"ok":      allOK,
 			"clients": status,
 		},
+		"ingest": h.ingestHealth(),
 	})
 }
+
+// ingestHealth summarises frame loss across all boards sending sequence
+// numbers.
+func (h HealthHandler) ingestHealth() gin.H {
+	boards := gin.H{}
+	var received, lost uint64
+
+	for id, stats := range h.TCPServer.AllSequenceStats() {
+		rate := stats.LossRate()
+		boards["board"+id] = gin.H{
+			"lossRate":   rate,
+			"lost":       stats.Lost,
+			"duplicates": stats.Duplicates,
+			"reordered":  stats.Reordered,
+			"degraded":   rate > degradedLossRate,
+		}
+		received += stats.Received
+		lost += stats.Lost
+	}
+
+	var rate float64
+	if received+lost > 0 {
+		rate = float64(lost) / float64(received+lost)
+	}
+	return gin.H{
+		"lossRate": rate,
+		"boards":   boards,
+	}
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"errors"
+	"fall-detection/internal/detection"
+	"fall-detection/internal/repository"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InactivityHandler struct {
+	settingsRepo *repository.InactivitySettingsRepo
+	monitor      *detection.InactivityMonitor
+}
+
+func NewInactivityHandler(settingsRepo *repository.InactivitySettingsRepo, monitor *detection.InactivityMonitor) *InactivityHandler {
+	return &InactivityHandler{
+		settingsRepo: settingsRepo,
+		monitor:      monitor,
+	}
+}
+
+// inactivityBody gives quiet hours as "HH:MM" in the facility's time zone,
+// FACILITY_TZ. Both are empty when the board has no quiet hours.
+type inactivityBody struct {
+	Enabled    bool   `json:"enabled"`
+	PeriodMins int    `json:"periodMins"`
+	QuietStart string `json:"quietStart"`
+	QuietEnd   string `json:"quietEnd"`
+}
+
+func toInactivityBody(s detection.InactivitySettings) inactivityBody {
+	body := inactivityBody{
+		Enabled:    s.Enabled,
+		PeriodMins: int(s.Period / time.Minute),
+	}
+	if s.Quiet != nil {
+		body.QuietStart = formatMinutes(s.Quiet.Start)
+		body.QuietEnd = formatMinutes(s.Quiet.End)
+	}
+	return body
+}
+
+func (b inactivityBody) settings() (detection.InactivitySettings, error) {
+	s := detection.InactivitySettings{
+		Enabled: b.Enabled,
+		Period:  time.Duration(b.PeriodMins) * time.Minute,
+	}
+	if b.QuietStart == "" && b.QuietEnd == "" {
+		return s, nil
+	}
+	start, err := parseMinutes(b.QuietStart)
+	if err != nil {
+		return s, err
+	}
+	end, err := parseMinutes(b.QuietEnd)
+	if err != nil {
+		return s, err
+	}
+	s.Quiet = &detection.QuietHours{Start: start, End: end}
+	return s, nil
+}
+
+func formatMinutes(m int) string {
+	return fmt.Sprintf("%02d:%02d", m/60, m%60)
+}
+
+func parseMinutes(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, errors.New("quiet hours must be given as HH:MM")
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// GetSettings returns the inactivity settings in effect for a board.
+func (h *InactivityHandler) GetSettings(c *gin.Context) {
+	boardID := c.Param("boardID")
+	settings := h.monitor.Settings(strings.TrimPrefix(boardID, "board"))
+	c.JSON(http.StatusOK, toInactivityBody(settings))
+}
+
+// UpdateSettings stores a board's inactivity settings and applies them
+// immediately.
+func (h *InactivityHandler) UpdateSettings(c *gin.Context) {
+	boardID := c.Param("boardID")
+
+	var body inactivityBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	settings, err := body.settings()
+	if err == nil {
+		err = settings.Validate()
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.settingsRepo.Save(c.Request.Context(), boardID, settings); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.monitor.SetSettings(strings.TrimPrefix(boardID, "board"), settings)
+
+	c.JSON(http.StatusOK, toInactivityBody(settings))
+}
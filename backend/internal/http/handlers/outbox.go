@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fall-detection/internal/alert"
+	"fall-detection/internal/repository"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	defaultOutboxLimit = 100
+	maxOutboxLimit     = 1000
+)
+
+type OutboxHandler struct {
+	outboxRepo *repository.OutboxRepo
+	notifiers  *alert.Dispatcher
+}
+
+func NewOutboxHandler(outboxRepo *repository.OutboxRepo, notifiers *alert.Dispatcher) *OutboxHandler {
+	return &OutboxHandler{
+		outboxRepo: outboxRepo,
+		notifiers:  notifiers,
+	}
+}
+
+type outboxMessageResponse struct {
+	ID            int64           `json:"id"`
+	Channel       string          `json:"channel"` // telegram, webhooks or email
+	Type          string          `json:"type"`    // fall_detected, seen, resolved, expired, board_offline or board_online
+	BoardID       string          `json:"boardID"`
+	FallEventID   *int64          `json:"fallEventID"` // null for board notifications
+	Payload       json.RawMessage `json:"payload"`
+	Status        string          `json:"status"` // pending, delivered or dead
+	Attempts      int             `json:"attempts"`
+	LastError     *string         `json:"lastError"`
+	NextAttemptAt time.Time       `json:"nextAttemptAt"`
+	CreatedAt     time.Time       `json:"createdAt"`
+	DeliveredAt   *time.Time      `json:"deliveredAt"`
+}
+
+func toOutboxMessageResponse(m repository.OutboxMessage) outboxMessageResponse {
+	return outboxMessageResponse{
+		ID:            m.ID,
+		Channel:       m.Channel,
+		Type:          m.Type,
+		BoardID:       m.BoardID,
+		FallEventID:   m.FallEventID,
+		Payload:       m.Payload,
+		Status:        m.Status,
+		Attempts:      m.Attempts,
+		LastError:     m.LastError,
+		NextAttemptAt: m.NextAttemptAt,
+		CreatedAt:     m.CreatedAt,
+		DeliveredAt:   m.DeliveredAt,
+	}
+}
+
+// outboxID parses the :id parameter, responding with 400 if it is invalid.
+func outboxID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid notification id"})
+		return 0, false
+	}
+	return id, true
+}
+
+// ListUndelivered returns notifications still waiting to be delivered and
+// those that were given up on, newest first. ?status= picks pending or dead
+// and ?channel= a single channel.
+func (h *OutboxHandler) ListUndelivered(c *gin.Context) {
+	status := c.Query("status")
+	if status != "" && status != repository.OutboxPending && status != repository.OutboxDead {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be pending or dead"})
+		return
+	}
+
+	limit := defaultOutboxLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxOutboxLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(maxOutboxLimit)})
+			return
+		}
+		limit = n
+	}
+
+	messages, err := h.outboxRepo.GetUndelivered(c.Request.Context(), status, c.Query("channel"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := make([]outboxMessageResponse, 0, len(messages))
+	for _, m := range messages {
+		result = append(result, toOutboxMessageResponse(m))
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *OutboxHandler) GetMessage(c *gin.Context) {
+	id, ok := outboxID(c)
+	if !ok {
+		return
+	}
+	h.respondMessage(c, id)
+}
+
+func (h *OutboxHandler) respondMessage(c *gin.Context, id int64) {
+	m, err := h.outboxRepo.Get(c.Request.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "notification not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, toOutboxMessageResponse(*m))
+}
+
+// RetryMessage sends a pending or dead notification again straight away,
+// with a fresh set of attempts.
+func (h *OutboxHandler) RetryMessage(c *gin.Context) {
+	id, ok := outboxID(c)
+	if !ok {
+		return
+	}
+
+	retried, err := h.outboxRepo.Retry(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !retried {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no undelivered notification with that id"})
+		return
+	}
+	h.notifiers.Wake()
+	h.respondMessage(c, id)
+}
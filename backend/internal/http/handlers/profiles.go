@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"errors"
+	"fall-detection/internal/repository"
+	"fall-detection/internal/tcp"
+	"fall-detection/internal/telemetry"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+type ProfilesHandler struct {
+	profileRepo *repository.DetectionProfileRepo
+	tcpServer   *tcp.TCPServer
+}
+
+func NewProfilesHandler(profileRepo *repository.DetectionProfileRepo, tcpServer *tcp.TCPServer) *ProfilesHandler {
+	return &ProfilesHandler{
+		profileRepo: profileRepo,
+		tcpServer:   tcpServer,
+	}
+}
+
+type thresholdsBody struct {
+	FreefallThreshold     float64 `json:"freefallThreshold"`
+	ImpactThreshold       float64 `json:"impactThreshold"`
+	ImpactDelta           float64 `json:"impactDelta"`
+	ImpactGyroMax         float64 `json:"impactGyroMax"`
+	GyroThreshold         float64 `json:"gyroThreshold"`
+	LyingThreshold        float64 `json:"lyingThreshold"`
+	FallDetectionTimeMs   int64   `json:"fallDetectionTimeMs"`
+	LyingDetectionTimeMs  int64   `json:"lyingDetectionTimeMs"`
+	PressureFallThreshold float64 `json:"pressureFallThreshold"`
+}
+
+type profileResponse struct {
+	BoardID      string         `json:"boardID"`
+	Version      int            `json:"version"` // 0 means firmware defaults
+	Thresholds   thresholdsBody `json:"thresholds"`
+	AckedVersion int            `json:"ackedVersion"`
+	AckedAt      *time.Time     `json:"ackedAt"`
+	UpdatedAt    *time.Time     `json:"updatedAt"`
+}
+
+func toThresholdsBody(t telemetry.Thresholds) thresholdsBody {
+	return thresholdsBody{
+		FreefallThreshold:     t.FreefallThreshold,
+		ImpactThreshold:       t.ImpactThreshold,
+		ImpactDelta:           t.ImpactDelta,
+		ImpactGyroMax:         t.ImpactGyroMax,
+		GyroThreshold:         t.GyroThreshold,
+		LyingThreshold:        t.LyingThreshold,
+		FallDetectionTimeMs:   t.FallDetectionTime.Milliseconds(),
+		LyingDetectionTimeMs:  t.LyingDetectionTime.Milliseconds(),
+		PressureFallThreshold: t.PressureFallThreshold,
+	}
+}
+
+func (b thresholdsBody) thresholds() telemetry.Thresholds {
+	return telemetry.Thresholds{
+		FreefallThreshold:     b.FreefallThreshold,
+		ImpactThreshold:       b.ImpactThreshold,
+		ImpactDelta:           b.ImpactDelta,
+		ImpactGyroMax:         b.ImpactGyroMax,
+		GyroThreshold:         b.GyroThreshold,
+		LyingThreshold:        b.LyingThreshold,
+		FallDetectionTime:     time.Duration(b.FallDetectionTimeMs) * time.Millisecond,
+		LyingDetectionTime:    time.Duration(b.LyingDetectionTimeMs) * time.Millisecond,
+		PressureFallThreshold: b.PressureFallThreshold,
+	}
+}
+
+func (h *ProfilesHandler) GetProfile(c *gin.Context) {
+	boardID := c.Param("boardID")
+	if !boardIDPattern.MatchString(boardID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "board ID must look like board1"})
+		return
+	}
+	profile, err := h.profileRepo.Get(c.Request.Context(), boardID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusOK, profileResponse{
+			BoardID:    boardID,
+			Thresholds: toThresholdsBody(telemetry.DefaultThresholds()),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, profileResponse{
+		BoardID:      profile.BoardID,
+		Version:      profile.Version,
+		Thresholds:   toThresholdsBody(profile.Thresholds),
+		AckedVersion: profile.AckedVersion,
+		AckedAt:      profile.AckedAt,
+		UpdatedAt:    &profile.UpdatedAt,
+	})
+}
+
+// UpdateProfile replaces a board's thresholds and pushes them to the board if
+// it is connected. Offline boards receive the profile when they next connect.
+func (h *ProfilesHandler) UpdateProfile(c *gin.Context) {
+	boardID := c.Param("boardID")
+	if !boardIDPattern.MatchString(boardID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "board ID must look like board1"})
+		return
+	}
+
+	var body thresholdsBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	thresholds := body.thresholds()
+	if err := thresholds.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	version, err := h.profileRepo.Save(c.Request.Context(), boardID, thresholds)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	go func() {
+		err := h.tcpServer.PushProfile(strings.TrimPrefix(boardID, "board"))
+		if err != nil && !errors.Is(err, tcp.ErrBoardOffline) {
+			log.Printf("[Profiles] Failed to push profile v%d to %s: %v", version, boardID, err)
+		}
+	}()
+
+	c.JSON(http.StatusOK, gin.H{"boardID": boardID, "version": version})
+}
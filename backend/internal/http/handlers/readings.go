@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"fall-detection/internal/repository"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultReadingsWindow = 5 * time.Minute
+	defaultReadingsLimit  = 1000
+	maxReadingsLimit      = 10000
+)
+
+type ReadingsHandler struct {
+	sensorReadingRepo *repository.SensorReadingRepo
+}
+
+func NewReadingsHandler(sensorReadingRepo *repository.SensorReadingRepo) *ReadingsHandler {
+	return &ReadingsHandler{sensorReadingRepo: sensorReadingRepo}
+}
+
+type sensorReadingResponse struct {
+	ReceivedAt time.Time `json:"receivedAt"`
+	AccelX     float64   `json:"accelX"`
+	AccelY     float64   `json:"accelY"`
+	AccelZ     float64   `json:"accelZ"`
+	GyroX      float64   `json:"gyroX"`
+	GyroY      float64   `json:"gyroY"`
+	GyroZ      float64   `json:"gyroZ"`
+	FallStatus bool      `json:"fallStatus"`
+	FallState  int       `json:"fallState"`
+	Pressure   float64   `json:"pressure"`
+	Seq        *uint32   `json:"seq"`
+}
+
+func toSensorReadingResponses(readings []repository.SensorReading) []sensorReadingResponse {
+	result := make([]sensorReadingResponse, 0, len(readings))
+	for _, s := range readings {
+		r := s.Reading
+		resp := sensorReadingResponse{
+			ReceivedAt: s.ReceivedAt,
+			AccelX:     r.AccelX,
+			AccelY:     r.AccelY,
+			AccelZ:     r.AccelZ,
+			GyroX:      r.GyroX,
+			GyroY:      r.GyroY,
+			GyroZ:      r.GyroZ,
+			FallStatus: r.FallStatus,
+			FallState:  int(r.FallState),
+			Pressure:   r.Pressure,
+		}
+		if r.Version > 1 {
+			seq := r.Seq
+			resp.Seq = &seq
+		}
+		result = append(result, resp)
+	}
+	return result
+}
+
+// GetReadings returns a board's stored telemetry. The range defaults to the
+// last five minutes; from/to are RFC 3339 timestamps.
+func (h *ReadingsHandler) GetReadings(c *gin.Context) {
+	boardID := c.Param("boardID")
+
+	to := time.Now()
+	if v := c.Query("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to: " + err.Error()})
+			return
+		}
+		to = t
+	}
+	from := to.Add(-defaultReadingsWindow)
+	if v := c.Query("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from: " + err.Error()})
+			return
+		}
+		from = t
+	}
+	if !from.Before(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be before to"})
+		return
+	}
+
+	limit := defaultReadingsLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxReadingsLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(maxReadingsLimit)})
+			return
+		}
+		limit = n
+	}
+
+	readings, err := h.sensorReadingRepo.GetRange(c.Request.Context(), boardID, from, to, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, toSensorReadingResponses(readings))
+}
@@ -0,0 +1,276 @@
+package handlers
+
+import (
+	"errors"
+	"fall-detection/internal/repository"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+type ResidentsHandler struct {
+	residentRepo  *repository.ResidentRepo
+	fallEventRepo *repository.FallEventRepo
+	ackRepo       *repository.AcknowledgementRepo
+}
+
+func NewResidentsHandler(residentRepo *repository.ResidentRepo, fallEventRepo *repository.FallEventRepo, ackRepo *repository.AcknowledgementRepo) *ResidentsHandler {
+	return &ResidentsHandler{
+		residentRepo:  residentRepo,
+		fallEventRepo: fallEventRepo,
+		ackRepo:       ackRepo,
+	}
+}
+
+type residentBody struct {
+	Name              string                        `json:"name" binding:"required"`
+	Room              string                        `json:"room"`
+	CareNotes         string                        `json:"careNotes"`
+	EmergencyContacts []repository.EmergencyContact `json:"emergencyContacts"`
+	FallRisk          string                        `json:"fallRisk"` // low, medium or high, defaults to medium
+}
+
+type residentResponse struct {
+	ID                int64                         `json:"id"`
+	Name              string                        `json:"name"`
+	Room              string                        `json:"room"`
+	CareNotes         string                        `json:"careNotes"`
+	EmergencyContacts []repository.EmergencyContact `json:"emergencyContacts"`
+	FallRisk          string                        `json:"fallRisk"`
+	BoardID           *string                       `json:"boardID"` // null if no board assigned
+	CreatedAt         time.Time                     `json:"createdAt"`
+	UpdatedAt         time.Time                     `json:"updatedAt"`
+}
+
+func toResidentResponse(r repository.Resident) residentResponse {
+	return residentResponse{
+		ID:                r.ID,
+		Name:              r.Name,
+		Room:              r.Room,
+		CareNotes:         r.CareNotes,
+		EmergencyContacts: r.EmergencyContacts,
+		FallRisk:          r.FallRisk,
+		BoardID:           r.BoardID,
+		CreatedAt:         r.CreatedAt,
+		UpdatedAt:         r.UpdatedAt,
+	}
+}
+
+func (b residentBody) details() (repository.ResidentDetails, error) {
+	d := repository.ResidentDetails{
+		Name:              strings.TrimSpace(b.Name),
+		Room:              strings.TrimSpace(b.Room),
+		CareNotes:         b.CareNotes,
+		EmergencyContacts: b.EmergencyContacts,
+		FallRisk:          b.FallRisk,
+	}
+	if d.FallRisk == "" {
+		d.FallRisk = repository.FallRiskMedium
+	}
+	if !repository.ValidFallRisk(d.FallRisk) {
+		return d, repository.ErrInvalidFallRisk
+	}
+	if d.Name == "" {
+		return d, errors.New("name is required")
+	}
+	return d, nil
+}
+
+// residentID parses the :id parameter, responding with 400 if it is invalid.
+func residentID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid resident id"})
+		return 0, false
+	}
+	return id, true
+}
+
+func (h *ResidentsHandler) ListResidents(c *gin.Context) {
+	residents, err := h.residentRepo.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := make([]residentResponse, 0, len(residents))
+	for _, r := range residents {
+		result = append(result, toResidentResponse(r))
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *ResidentsHandler) GetResident(c *gin.Context) {
+	id, ok := residentID(c)
+	if !ok {
+		return
+	}
+	h.respondResident(c, http.StatusOK, id)
+}
+
+func (h *ResidentsHandler) respondResident(c *gin.Context, code int, id int64) {
+	resident, err := h.residentRepo.Get(c.Request.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "resident not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(code, toResidentResponse(*resident))
+}
+
+func (h *ResidentsHandler) CreateResident(c *gin.Context) {
+	var body residentBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	details, err := body.details()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := h.residentRepo.Create(c.Request.Context(), details)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.respondResident(c, http.StatusCreated, id)
+}
+
+func (h *ResidentsHandler) UpdateResident(c *gin.Context) {
+	id, ok := residentID(c)
+	if !ok {
+		return
+	}
+
+	var body residentBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	details, err := body.details()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.residentRepo.Update(c.Request.Context(), id, details)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "resident not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.respondResident(c, http.StatusOK, id)
+}
+
+type assignBoardRequest struct {
+	BoardID string `json:"boardID" binding:"required"`
+}
+
+// AssignBoard gives the resident a board, taking it from whoever wore it
+// before.
+func (h *ResidentsHandler) AssignBoard(c *gin.Context) {
+	id, ok := residentID(c)
+	if !ok {
+		return
+	}
+
+	var req assignBoardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !strings.HasPrefix(req.BoardID, "board") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "board ID must look like board1"})
+		return
+	}
+
+	if _, err := h.residentRepo.Get(c.Request.Context(), id); errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "resident not found"})
+		return
+	}
+	if err := h.residentRepo.AssignBoard(c.Request.Context(), id, req.BoardID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.respondResident(c, http.StatusOK, id)
+}
+
+func (h *ResidentsHandler) UnassignBoard(c *gin.Context) {
+	id, ok := residentID(c)
+	if !ok {
+		return
+	}
+
+	unassigned, err := h.residentRepo.UnassignBoard(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !unassigned {
+		c.JSON(http.StatusNotFound, gin.H{"error": "resident has no board"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+type boardAssignmentResponse struct {
+	BoardID      string     `json:"boardID"`
+	AssignedAt   time.Time  `json:"assignedAt"`
+	UnassignedAt *time.Time `json:"unassignedAt"`
+}
+
+// GetAssignments returns the boards a resident has worn, newest first.
+func (h *ResidentsHandler) GetAssignments(c *gin.Context) {
+	id, ok := residentID(c)
+	if !ok {
+		return
+	}
+
+	assignments, err := h.residentRepo.GetAssignments(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := make([]boardAssignmentResponse, 0, len(assignments))
+	for _, a := range assignments {
+		result = append(result, boardAssignmentResponse{
+			BoardID:      a.BoardID,
+			AssignedAt:   a.AssignedAt,
+			UnassignedAt: a.UnassignedAt,
+		})
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// GetFallEvents returns a resident's events across every board they wore.
+func (h *ResidentsHandler) GetFallEvents(c *gin.Context) {
+	id, ok := residentID(c)
+	if !ok {
+		return
+	}
+
+	events, err := h.fallEventRepo.GetByResident(c.Request.Context(), id, 50)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	result := toFallEventResponses(events)
+	if err := withAcknowledgements(c.Request.Context(), h.ackRepo, result); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -14,22 +14,25 @@ type SubscribersHandler struct {
 func (h *SubscribersHandler) GetSubscribers(c *gin.Context) {
 	// Filter the request from the context
 	boardID := c.Param("boardID")
-	chatIDs, firstNames, usernames, err := h.subscriptionRepo.GetSubscribers(c.Request.Context(),boardID)
+	subscriptions, err := h.subscriptionRepo.List(c.Request.Context(), boardID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	if len(chatIDs) != len(firstNames) || len(chatIDs) != len(usernames) {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Length of chatIDs, firstNames, and usernames do not match"})
-		return
-	}
 
+	// approvalMethod is legacy for subscriptions made before they needed
+	// authorizing, which have no approver and receive no alerts
 	result := []gin.H{}
-	for i := range chatIDs {
+	for _, s := range subscriptions {
 		result = append(result, gin.H{
-			"chatID": chatIDs[i],
-			"firstName": firstNames[i],
-			"username": usernames[i],
+			"chatID":         s.ChatID,
+			"firstName":      s.FirstName,
+			"username":       s.Username,
+			"approvalMethod": s.ApprovalMethod,
+			"approvedBy":     s.ApprovedBy,
+			"inviteID":       s.InviteID,
+			"approvedAt":     s.ApprovedAt,
+			"subscribedAt":   s.CreatedAt,
 		})
 	}
 
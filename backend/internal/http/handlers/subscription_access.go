@@ -0,0 +1,322 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fall-detection/internal/alert"
+	"fall-detection/internal/http/middleware"
+	"fall-detection/internal/repository"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	defaultInviteLifetime = 7 * 24 * time.Hour
+	maxInviteLifetime     = 30 * 24 * time.Hour
+
+	// Pairing codes avoid characters that are easily mistaken for others
+	pairingCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	pairingCodeLength   = 8
+)
+
+type SubscriptionAccessHandler struct {
+	subscriptionRepo *repository.SubscriptionRepo
+	bot              *alert.Bot
+}
+
+func NewSubscriptionAccessHandler(subscriptionRepo *repository.SubscriptionRepo, bot *alert.Bot) *SubscriptionAccessHandler {
+	return &SubscriptionAccessHandler{
+		subscriptionRepo: subscriptionRepo,
+		bot:              bot,
+	}
+}
+
+type subscriptionAccessResponse struct {
+	BoardID          string     `json:"boardID"`
+	PairingCodeSet   bool       `json:"pairingCodeSet"`
+	PairingCode      string     `json:"pairingCode,omitempty"` // Only returned when the code is regenerated
+	ApprovalRequired bool       `json:"approvalRequired"`
+	UpdatedAt        *time.Time `json:"updatedAt"`
+}
+
+type subscriptionAccessBody struct {
+	ApprovalRequired bool `json:"approvalRequired"`
+}
+
+type inviteBody struct {
+	Note           string `json:"note"`
+	ExpiresInHours int    `json:"expiresInHours"` // defaults to a week
+}
+
+type inviteResponse struct {
+	ID           int64      `json:"id"`
+	BoardID      string     `json:"boardID"`
+	Link         string     `json:"link,omitempty"` // Only returned when the invite is created
+	Note         string     `json:"note"`
+	CreatedBy    string     `json:"createdBy"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	ExpiresAt    time.Time  `json:"expiresAt"`
+	UsedAt       *time.Time `json:"usedAt"`
+	UsedByChatID *int64     `json:"usedByChatID"`
+}
+
+func toInviteResponse(i repository.SubscriptionInvite) inviteResponse {
+	return inviteResponse{
+		ID:           i.ID,
+		BoardID:      i.BoardID,
+		Note:         i.Note,
+		CreatedBy:    i.CreatedBy,
+		CreatedAt:    i.CreatedAt,
+		ExpiresAt:    i.ExpiresAt,
+		UsedAt:       i.UsedAt,
+		UsedByChatID: i.UsedByChatID,
+	}
+}
+
+type subscriptionRequestResponse struct {
+	ID          int64      `json:"id"`
+	ChatID      int64      `json:"chatID"`
+	BoardID     string     `json:"boardID"`
+	FirstName   string     `json:"firstName"`
+	Username    string     `json:"username"`
+	Status      string     `json:"status"` // pending, approved or rejected
+	RequestedAt time.Time  `json:"requestedAt"`
+	DecidedAt   *time.Time `json:"decidedAt"`
+	DecidedBy   *string    `json:"decidedBy"`
+}
+
+func toSubscriptionRequestResponse(q repository.SubscriptionRequest) subscriptionRequestResponse {
+	return subscriptionRequestResponse{
+		ID:          q.ID,
+		ChatID:      q.ChatID,
+		BoardID:     q.BoardID,
+		FirstName:   q.FirstName,
+		Username:    q.Username,
+		Status:      q.Status,
+		RequestedAt: q.RequestedAt,
+		DecidedAt:   q.DecidedAt,
+		DecidedBy:   q.DecidedBy,
+	}
+}
+
+func newPairingCode() (string, error) {
+	b := make([]byte, pairingCodeLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = pairingCodeAlphabet[int(b[i])%len(pairingCodeAlphabet)]
+	}
+	return string(b), nil
+}
+
+func newInviteToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// respondAccess returns a board's access settings. The pairing code itself
+// is left out unless showCode is set, so that it only goes to the admin who
+// generated it.
+func (h *SubscriptionAccessHandler) respondAccess(c *gin.Context, boardID string, showCode bool) {
+	access, err := h.subscriptionRepo.GetAccess(c.Request.Context(), boardID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	result := subscriptionAccessResponse{
+		BoardID:          access.BoardID,
+		PairingCodeSet:   access.PairingCode != nil,
+		ApprovalRequired: access.ApprovalRequired,
+		UpdatedAt:        access.UpdatedAt,
+	}
+	if showCode && access.PairingCode != nil {
+		result.PairingCode = *access.PairingCode
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// GetAccess returns how people may subscribe to a board, without the
+// pairing code.
+func (h *SubscriptionAccessHandler) GetAccess(c *gin.Context) {
+	h.respondAccess(c, c.Param("boardID"), false)
+}
+
+// UpdateAccess turns approval mode on or off. In approval mode /subscribe
+// files a request for an admin rather than taking the pairing code.
+func (h *SubscriptionAccessHandler) UpdateAccess(c *gin.Context) {
+	boardID := c.Param("boardID")
+
+	var body subscriptionAccessBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.subscriptionRepo.SetApprovalRequired(c.Request.Context(), boardID, body.ApprovalRequired); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.respondAccess(c, boardID, false)
+}
+
+// RegeneratePairingCode gives the board a new pairing code, which is only
+// returned here. The old code stops working; existing subscriptions are
+// kept.
+func (h *SubscriptionAccessHandler) RegeneratePairingCode(c *gin.Context) {
+	boardID := c.Param("boardID")
+
+	code, err := newPairingCode()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.subscriptionRepo.SetPairingCode(c.Request.Context(), boardID, &code); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	log.Printf("[Subscriptions] %s regenerated the pairing code for %s", middleware.Admin(c), boardID)
+	h.respondAccess(c, boardID, true)
+}
+
+// DeletePairingCode turns pairing codes off, leaving invites as the only way
+// to subscribe outside approval mode.
+func (h *SubscriptionAccessHandler) DeletePairingCode(c *gin.Context) {
+	if err := h.subscriptionRepo.SetPairingCode(c.Request.Context(), c.Param("boardID"), nil); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *SubscriptionAccessHandler) ListInvites(c *gin.Context) {
+	invites, err := h.subscriptionRepo.GetInvites(c.Request.Context(), c.Param("boardID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := make([]inviteResponse, 0, len(invites))
+	for _, i := range invites {
+		result = append(result, toInviteResponse(i))
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// CreateInvite makes a one-time Telegram link that subscribes whoever opens
+// it to the board, recording the admin as its creator. The link is only
+// returned here.
+func (h *SubscriptionAccessHandler) CreateInvite(c *gin.Context) {
+	boardID := c.Param("boardID")
+
+	var body inviteBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	lifetime := defaultInviteLifetime
+	if body.ExpiresInHours != 0 {
+		lifetime = time.Duration(body.ExpiresInHours) * time.Hour
+		if lifetime <= 0 || lifetime > maxInviteLifetime {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "expiresInHours must be between 1 and " + strconv.Itoa(int(maxInviteLifetime/time.Hour))})
+			return
+		}
+	}
+
+	token, err := newInviteToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	invite, err := h.subscriptionRepo.CreateInvite(c.Request.Context(), boardID, token, strings.TrimSpace(body.Note), middleware.Admin(c), time.Now().Add(lifetime))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := toInviteResponse(*invite)
+	result.Link = "https://t.me/" + h.bot.Username() + "?start=" + invite.Token
+	c.JSON(http.StatusCreated, result)
+}
+
+// DeleteInvite revokes an invite that has not been used yet.
+func (h *SubscriptionAccessHandler) DeleteInvite(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid invite id"})
+		return
+	}
+
+	deleted, err := h.subscriptionRepo.DeleteInvite(c.Request.Context(), c.Param("boardID"), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no unused invite with that id"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListRequests returns subscription requests, oldest first. ?status=
+// defaults to pending and ?boardID= narrows the list to one board.
+func (h *SubscriptionAccessHandler) ListRequests(c *gin.Context) {
+	status := c.DefaultQuery("status", repository.RequestPending)
+	if status != repository.RequestPending && status != repository.RequestApproved && status != repository.RequestRejected {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be pending, approved or rejected"})
+		return
+	}
+
+	requests, err := h.subscriptionRepo.GetRequests(c.Request.Context(), status, c.Query("boardID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := make([]subscriptionRequestResponse, 0, len(requests))
+	for _, q := range requests {
+		result = append(result, toSubscriptionRequestResponse(q))
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// ApproveRequest subscribes the requesting chat, recording the admin as the
+// approver, and lets the chat know.
+func (h *SubscriptionAccessHandler) ApproveRequest(c *gin.Context) {
+	h.decideRequest(c, h.subscriptionRepo.ApproveRequest)
+}
+
+func (h *SubscriptionAccessHandler) RejectRequest(c *gin.Context) {
+	h.decideRequest(c, h.subscriptionRepo.RejectRequest)
+}
+
+func (h *SubscriptionAccessHandler) decideRequest(c *gin.Context, decide func(ctx context.Context, id int64, admin string) (*repository.SubscriptionRequest, error)) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request id"})
+		return
+	}
+
+	request, err := decide(c.Request.Context(), id, middleware.Admin(c))
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no pending request with that id"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.bot.SubscriptionDecided(*request)
+	c.JSON(http.StatusOK, toSubscriptionRequestResponse(*request))
+}
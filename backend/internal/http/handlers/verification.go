@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"fall-detection/internal/detection"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type VerificationHandler struct {
+	verifier *detection.Verifier
+}
+
+func NewVerificationHandler(verifier *detection.Verifier) *VerificationHandler {
+	return &VerificationHandler{verifier: verifier}
+}
+
+type verificationStatsResponse struct {
+	Agreements  int    `json:"agreements"`
+	BoardOnly   int    `json:"boardOnly"`
+	ServerOnly  int    `json:"serverOnly"`
+	ServerState string `json:"serverState"`
+}
+
+// GetBoardVerification returns how often the server-side state machine (and
+// each shadow experiment) agreed with the board's own fall verdicts.
+func (h *VerificationHandler) GetBoardVerification(c *gin.Context) {
+	boardID := c.Param("boardID")
+	stats := h.verifier.Stats(strings.TrimPrefix(boardID, "board"))
+
+	result := make(map[string]verificationStatsResponse, len(stats))
+	for name, s := range stats {
+		result[name] = verificationStatsResponse{
+			Agreements:  s.Agreements,
+			BoardOnly:   s.BoardOnly,
+			ServerOnly:  s.ServerOnly,
+			ServerState: s.State.String(),
+		}
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *VerificationHandler) GetExperiments(c *gin.Context) {
+	experiments := h.verifier.Experiments()
+	result := make(map[string]thresholdsBody, len(experiments))
+	for name, t := range experiments {
+		result[name] = toThresholdsBody(t)
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// PutExperiment starts (or restarts) a shadow experiment on every board.
+func (h *VerificationHandler) PutExperiment(c *gin.Context) {
+	name := c.Param("name")
+	if name == detection.Primary {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "experiment name is reserved"})
+		return
+	}
+
+	var body thresholdsBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	thresholds := body.thresholds()
+	if err := thresholds.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.verifier.SetExperiment(name, thresholds)
+	c.JSON(http.StatusOK, gin.H{"name": name})
+}
+
+func (h *VerificationHandler) DeleteExperiment(c *gin.Context) {
+	h.verifier.RemoveExperiment(c.Param("name"))
+	c.Status(http.StatusNoContent)
+}
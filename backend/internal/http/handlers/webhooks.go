@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fall-detection/internal/alert"
+	"fall-detection/internal/repository"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 500
+	minWebhookSecretLength = 16
+)
+
+type WebhooksHandler struct {
+	webhookRepo *repository.WebhookRepo
+}
+
+func NewWebhooksHandler(webhookRepo *repository.WebhookRepo) *WebhooksHandler {
+	return &WebhooksHandler{webhookRepo: webhookRepo}
+}
+
+// webhookBody configures a webhook. Enabled defaults to true, and a secret is
+// generated when none is given.
+type webhookBody struct {
+	URL     string `json:"url" binding:"required"`
+	Secret  string `json:"secret"`
+	Enabled *bool  `json:"enabled"`
+}
+
+type webhookResponse struct {
+	ID        int64     `json:"id"`
+	BoardID   string    `json:"boardID"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"` // Only returned when the webhook is created
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func toWebhookResponse(w repository.Webhook) webhookResponse {
+	return webhookResponse{
+		ID:        w.ID,
+		BoardID:   w.BoardID,
+		URL:       w.URL,
+		Enabled:   w.Enabled,
+		CreatedAt: w.CreatedAt,
+	}
+}
+
+// boardWebhook loads the :id webhook, responding with 404 unless it belongs
+// to :boardID.
+func (h *WebhooksHandler) boardWebhook(c *gin.Context) (*repository.Webhook, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid webhook id"})
+		return nil, false
+	}
+	webhook, err := h.webhookRepo.Get(c.Request.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && webhook.BoardID != c.Param("boardID")) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "webhook not found"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return webhook, true
+}
+
+func (h *WebhooksHandler) ListWebhooks(c *gin.Context) {
+	webhooks, err := h.webhookRepo.GetByBoard(c.Request.Context(), c.Param("boardID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := make([]webhookResponse, 0, len(webhooks))
+	for _, w := range webhooks {
+		result = append(result, toWebhookResponse(w))
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// CreateWebhook adds a webhook to a board. The response is the only time the
+// secret is shown.
+func (h *WebhooksHandler) CreateWebhook(c *gin.Context) {
+	boardID := c.Param("boardID")
+
+	var body webhookBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := alert.CheckWebhookURL(c.Request.Context(), body.URL); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid url: " + err.Error()})
+		return
+	}
+
+	secret := body.Secret
+	if secret == "" {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		secret = hex.EncodeToString(b)
+	} else if len(secret) < minWebhookSecretLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "secret must be at least " + strconv.Itoa(minWebhookSecretLength) + " characters"})
+		return
+	}
+	enabled := body.Enabled == nil || *body.Enabled
+
+	id, err := h.webhookRepo.Create(c.Request.Context(), boardID, body.URL, secret, enabled)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	webhook, err := h.webhookRepo.Get(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := toWebhookResponse(*webhook)
+	resp.Secret = webhook.Secret
+	c.JSON(http.StatusCreated, resp)
+}
+
+// UpdateWebhook changes a webhook's URL or enables/disables it. The secret
+// cannot be changed; create a new webhook to rotate it.
+func (h *WebhooksHandler) UpdateWebhook(c *gin.Context) {
+	webhook, ok := h.boardWebhook(c)
+	if !ok {
+		return
+	}
+
+	var body webhookBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := alert.CheckWebhookURL(c.Request.Context(), body.URL); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid url: " + err.Error()})
+		return
+	}
+	if body.Secret != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "secret cannot be changed, create a new webhook instead"})
+		return
+	}
+	webhook.URL = body.URL
+	if body.Enabled != nil {
+		webhook.Enabled = *body.Enabled
+	}
+
+	if _, err := h.webhookRepo.Update(c.Request.Context(), webhook.ID, webhook.URL, webhook.Enabled); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, toWebhookResponse(*webhook))
+}
+
+func (h *WebhooksHandler) DeleteWebhook(c *gin.Context) {
+	webhook, ok := h.boardWebhook(c)
+	if !ok {
+		return
+	}
+	if _, err := h.webhookRepo.Delete(c.Request.Context(), webhook.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+type webhookDeliveryResponse struct {
+	DeliveryID  string    `json:"deliveryID"` // Shared by retries of one payload
+	EventType   string    `json:"eventType"`
+	FallEventID *int64    `json:"fallEventID"`
+	Attempt     int       `json:"attempt"`
+	StatusCode  *int      `json:"statusCode"` // Null when no response was received
+	Error       *string   `json:"error"`
+	Succeeded   bool      `json:"succeeded"`
+	DurationMs  int64     `json:"durationMs"`
+	AttemptedAt time.Time `json:"attemptedAt"`
+}
+
+// GetDeliveries returns a webhook's delivery log, newest first.
+func (h *WebhooksHandler) GetDeliveries(c *gin.Context) {
+	webhook, ok := h.boardWebhook(c)
+	if !ok {
+		return
+	}
+
+	limit := defaultDeliveriesLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxDeliveriesLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(maxDeliveriesLimit)})
+			return
+		}
+		limit = n
+	}
+
+	deliveries, err := h.webhookRepo.GetDeliveries(c.Request.Context(), webhook.ID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := make([]webhookDeliveryResponse, 0, len(deliveries))
+	for _, d := range deliveries {
+		result = append(result, webhookDeliveryResponse{
+			DeliveryID:  d.DeliveryID,
+			EventType:   d.EventType,
+			FallEventID: d.FallEventID,
+			Attempt:     d.Attempt,
+			StatusCode:  d.StatusCode,
+			Error:       d.Error,
+			Succeeded:   d.Succeeded,
+			DurationMs:  d.Duration.Milliseconds(),
+			AttemptedAt: d.AttemptedAt,
+		})
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,57 @@
+// Package middleware holds gin middleware shared by the HTTP routes.
+package middleware
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// adminKey is the gin context key holding the authenticated admin's name.
+const adminKey = "admin"
+
+// RequireAdmin rejects requests without an "Authorization: Bearer <token>"
+// header naming one of the configured admin tokens, which map token to admin
+// name. With no tokens configured every request is rejected, so admin routes
+// are closed until an operator sets ADMIN_TOKENS.
+func RequireAdmin(tokens map[string]string) gin.HandlerFunc {
+	// Compare fixed-size digests so the comparison takes the same time
+	// whatever the token length
+	digests := make(map[[sha256.Size]byte]string, len(tokens))
+	for token, name := range tokens {
+		digests[sha256.Sum256([]byte(token))] = name
+	}
+
+	return func(c *gin.Context) {
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			c.Header("WWW-Authenticate", `Bearer realm="admin"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "admin token required"})
+			return
+		}
+
+		sum := sha256.Sum256([]byte(token))
+		var name string
+		for digest, n := range digests {
+			if subtle.ConstantTimeCompare(sum[:], digest[:]) == 1 {
+				name = n
+			}
+		}
+		if name == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "invalid admin token"})
+			return
+		}
+
+		c.Set(adminKey, name)
+		c.Next()
+	}
+}
+
+// Admin returns the name of the admin authenticated by RequireAdmin, or ""
+// on routes without it.
+func Admin(c *gin.Context) string {
+	return c.GetString(adminKey)
+}
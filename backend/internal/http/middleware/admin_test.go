@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		tokens map[string]string
+		header string
+		status int
+		admin  string
+	}{
+		{"valid token", map[string]string{"tok-alice-0123456789": "alice"}, "Bearer tok-alice-0123456789", http.StatusOK, "alice"},
+		{"second admin", map[string]string{"tok-alice-0123456789": "alice", "tok-bob-0123456789ab": "bob"}, "Bearer tok-bob-0123456789ab", http.StatusOK, "bob"},
+		{"missing header", map[string]string{"tok-alice-0123456789": "alice"}, "", http.StatusUnauthorized, ""},
+		{"wrong scheme", map[string]string{"tok-alice-0123456789": "alice"}, "Basic tok-alice-0123456789", http.StatusUnauthorized, ""},
+		{"wrong token", map[string]string{"tok-alice-0123456789": "alice"}, "Bearer tok-mallory-0123456", http.StatusForbidden, ""},
+		{"no tokens configured", nil, "Bearer anything-at-all-here", http.StatusForbidden, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var admin string
+			r := gin.New()
+			r.GET("/", RequireAdmin(tt.tokens), func(c *gin.Context) {
+				admin = Admin(c)
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			if admin != tt.admin {
+				t.Errorf("Admin() = %q, want %q", admin, tt.admin)
+			}
+		})
+	}
+}
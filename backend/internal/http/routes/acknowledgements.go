@@ -0,0 +1,11 @@
+package routes
+
+import (
+	"fall-detection/internal/http/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterAcknowledgementsRoutes(r *gin.Engine, h *handlers.AcknowledgementsHandler) {
+	r.GET("/boards/:boardID/acknowledgements/metrics", h.GetMetrics)
+}
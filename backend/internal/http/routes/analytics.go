@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"fall-detection/internal/http/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterAnalyticsRoutes(r *gin.Engine, h *handlers.AnalyticsHandler) {
+	r.GET("/analytics/falls", h.GetFacilityStats)
+	r.GET("/boards/:boardID/analytics/falls", h.GetBoardStats)
+}
@@ -6,10 +6,16 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func RegisterBoardRoutes(r *gin.Engine, boardHandler *handlers.BoardHandler) {
+func RegisterBoardRoutes(r *gin.Engine, boardHandler *handlers.BoardHandler, admin gin.HandlerFunc) {
 	boards := r.Group("/boards")
 	{
+		boards.GET("", boardHandler.ListBoards)
 		boards.GET("/connected", boardHandler.GetBoards)
+		boards.GET("/:boardID", boardHandler.GetBoard)
+		boards.PUT("/:boardID", admin, boardHandler.UpdateBoard)
+		boards.POST("/:boardID/commands", admin, boardHandler.SendCommand)
+		boards.GET("/:boardID/sequence", boardHandler.GetSequenceStats)
+		boards.POST("/:boardID/credentials", admin, boardHandler.CreateCredentials)
 	}
 
 }
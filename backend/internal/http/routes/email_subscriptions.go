@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"fall-detection/internal/http/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterEmailSubscriptionsRoutes(r *gin.Engine, h *handlers.EmailSubscriptionsHandler, admin gin.HandlerFunc) {
+	subscriptions := r.Group("/email-subscriptions", admin)
+	{
+		subscriptions.GET("", h.ListSubscriptions)
+		subscriptions.POST("", h.Subscribe)
+		subscriptions.DELETE("/:id", h.Unsubscribe)
+		subscriptions.POST("/:id/test", h.SendTest)
+	}
+}
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"fall-detection/internal/http/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterEscalationRoutes(r *gin.Engine, h *handlers.EscalationHandler, admin gin.HandlerFunc) {
+	r.GET("/boards/:boardID/escalation", h.GetPolicy)
+	r.PUT("/boards/:boardID/escalation", admin, h.UpdatePolicy)
+	r.DELETE("/boards/:boardID/escalation", admin, h.DeletePolicy)
+	r.GET("/boards/:boardID/fall-events/:id/escalations", h.GetEventEscalations)
+}
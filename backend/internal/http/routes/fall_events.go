@@ -8,4 +8,5 @@ import (
 
 func RegisterFallEventsRoutes(r *gin.Engine, h *handlers.FallEventsHandler) {
 	r.GET("/boards/:boardID/fall-events", h.GetFallEvents)
+	r.GET("/boards/:boardID/fall-events/:id/trace", h.GetFallEventTrace)
 }
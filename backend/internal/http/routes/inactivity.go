@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"fall-detection/internal/http/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterInactivityRoutes(r *gin.Engine, h *handlers.InactivityHandler, admin gin.HandlerFunc) {
+	r.GET("/boards/:boardID/inactivity", h.GetSettings)
+	r.PUT("/boards/:boardID/inactivity", admin, h.UpdateSettings)
+}
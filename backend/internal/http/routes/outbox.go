@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"fall-detection/internal/http/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterOutboxRoutes(r *gin.Engine, h *handlers.OutboxHandler, admin gin.HandlerFunc) {
+	outbox := r.Group("/notifications/outbox", admin)
+	{
+		outbox.GET("", h.ListUndelivered)
+		outbox.GET("/:id", h.GetMessage)
+		outbox.POST("/:id/retry", h.RetryMessage)
+	}
+}
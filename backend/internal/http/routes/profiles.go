@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"fall-detection/internal/http/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterProfilesRoutes(r *gin.Engine, h *handlers.ProfilesHandler, admin gin.HandlerFunc) {
+	r.GET("/boards/:boardID/profile", h.GetProfile)
+	r.PUT("/boards/:boardID/profile", admin, h.UpdateProfile)
+}
@@ -0,0 +1,11 @@
+package routes
+
+import (
+	"fall-detection/internal/http/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterReadingsRoutes(r *gin.Engine, h *handlers.ReadingsHandler) {
+	r.GET("/boards/:boardID/readings", h.GetReadings)
+}
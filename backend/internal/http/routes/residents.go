@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"fall-detection/internal/http/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterResidentsRoutes(r *gin.Engine, h *handlers.ResidentsHandler, admin gin.HandlerFunc) {
+	residents := r.Group("/residents", admin)
+	{
+		residents.GET("", h.ListResidents)
+		residents.POST("", h.CreateResident)
+		residents.GET("/:id", h.GetResident)
+		residents.PUT("/:id", h.UpdateResident)
+		residents.PUT("/:id/board", h.AssignBoard)
+		residents.DELETE("/:id/board", h.UnassignBoard)
+		residents.GET("/:id/boards", h.GetAssignments)
+		residents.GET("/:id/fall-events", h.GetFallEvents)
+	}
+}
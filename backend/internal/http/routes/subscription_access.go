@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"fall-detection/internal/http/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterSubscriptionAccessRoutes(r *gin.Engine, h *handlers.SubscriptionAccessHandler, admin gin.HandlerFunc) {
+	access := r.Group("/boards/:boardID/subscription-access", admin)
+	{
+		access.GET("", h.GetAccess)
+		access.PUT("", h.UpdateAccess)
+		access.POST("/pairing-code", h.RegeneratePairingCode)
+		access.DELETE("/pairing-code", h.DeletePairingCode)
+	}
+
+	invites := r.Group("/boards/:boardID/invites", admin)
+	{
+		invites.GET("", h.ListInvites)
+		invites.POST("", h.CreateInvite)
+		invites.DELETE("/:id", h.DeleteInvite)
+	}
+
+	requests := r.Group("/subscription-requests", admin)
+	{
+		requests.GET("", h.ListRequests)
+		requests.POST("/:id/approve", h.ApproveRequest)
+		requests.POST("/:id/reject", h.RejectRequest)
+	}
+}
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"fall-detection/internal/http/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterVerificationRoutes(r *gin.Engine, h *handlers.VerificationHandler, admin gin.HandlerFunc) {
+	r.GET("/boards/:boardID/verification", h.GetBoardVerification)
+
+	experiments := r.Group("/verification/experiments")
+	{
+		experiments.GET("", h.GetExperiments)
+		experiments.PUT("/:name", admin, h.PutExperiment)
+		experiments.DELETE("/:name", admin, h.DeleteExperiment)
+	}
+}
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"fall-detection/internal/http/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterWebhooksRoutes(r *gin.Engine, h *handlers.WebhooksHandler, admin gin.HandlerFunc) {
+	webhooks := r.Group("/boards/:boardID/webhooks", admin)
+	{
+		webhooks.GET("", h.ListWebhooks)
+		webhooks.POST("", h.CreateWebhook)
+		webhooks.PUT("/:id", h.UpdateWebhook)
+		webhooks.DELETE("/:id", h.DeleteWebhook)
+		webhooks.GET("/:id/deliveries", h.GetDeliveries)
+	}
+}
@@ -2,6 +2,7 @@ package http
 
 import (
 	"fall-detection/internal/http/handlers"
+	"fall-detection/internal/http/middleware"
 	"fall-detection/internal/http/routes"
 	"fmt"
 
@@ -14,19 +15,42 @@ type Server struct {
 	port   string
 }
 
-func New(port string, healthHandler handlers.HealthHandler, boardHandler *handlers.BoardHandler, subscribersHandler *handlers.SubscribersHandler, fallEventsHandler *handlers.FallEventsHandler) *Server {
+func New(port string, corsOrigins []string, adminTokens map[string]string, healthHandler handlers.HealthHandler, boardHandler *handlers.BoardHandler, subscribersHandler *handlers.SubscribersHandler, fallEventsHandler *handlers.FallEventsHandler, profilesHandler *handlers.ProfilesHandler, verificationHandler *handlers.VerificationHandler, readingsHandler *handlers.ReadingsHandler, inactivityHandler *handlers.InactivityHandler, residentsHandler *handlers.ResidentsHandler, escalationHandler *handlers.EscalationHandler, acknowledgementsHandler *handlers.AcknowledgementsHandler, analyticsHandler *handlers.AnalyticsHandler, webhooksHandler *handlers.WebhooksHandler, emailSubscriptionsHandler *handlers.EmailSubscriptionsHandler, outboxHandler *handlers.OutboxHandler, subscriptionAccessHandler *handlers.SubscriptionAccessHandler) *Server {
 	r := gin.Default()
 
-	r.Use(cors.New(cors.Config{
-		AllowAllOrigins: true,
-		AllowMethods:    []string{"GET", "OPTIONS"},
-		AllowHeaders:    []string{"Origin", "Content-Type"},
-	}))
+	// Browsers may only call the API from the configured dashboard origins,
+	// or from anywhere when none are configured. Admin routes rely on the
+	// bearer token rather than the origin either way.
+	corsConfig := cors.Config{
+		AllowMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowHeaders: []string{"Origin", "Content-Type", "Authorization"},
+	}
+	if len(corsOrigins) > 0 {
+		corsConfig.AllowOrigins = corsOrigins
+	} else {
+		corsConfig.AllowAllOrigins = true
+	}
+	r.Use(cors.New(corsConfig))
+
+	// Guards routes that change how boards or alerts behave
+	admin := middleware.RequireAdmin(adminTokens)
 
 	routes.RegisterHealthRoutes(r, healthHandler)
-	routes.RegisterBoardRoutes(r, boardHandler)
+	routes.RegisterBoardRoutes(r, boardHandler, admin)
 	routes.RegisterSubscribersRoutes(r, subscribersHandler)
 	routes.RegisterFallEventsRoutes(r, fallEventsHandler)
+	routes.RegisterProfilesRoutes(r, profilesHandler, admin)
+	routes.RegisterVerificationRoutes(r, verificationHandler, admin)
+	routes.RegisterReadingsRoutes(r, readingsHandler)
+	routes.RegisterInactivityRoutes(r, inactivityHandler, admin)
+	routes.RegisterResidentsRoutes(r, residentsHandler, admin)
+	routes.RegisterEscalationRoutes(r, escalationHandler, admin)
+	routes.RegisterAcknowledgementsRoutes(r, acknowledgementsHandler)
+	routes.RegisterAnalyticsRoutes(r, analyticsHandler)
+	routes.RegisterWebhooksRoutes(r, webhooksHandler, admin)
+	routes.RegisterEmailSubscriptionsRoutes(r, emailSubscriptionsHandler, admin)
+	routes.RegisterOutboxRoutes(r, outboxHandler, admin)
+	routes.RegisterSubscriptionAccessRoutes(r, subscriptionAccessHandler, admin)
 
 	return &Server{
 		engine: r,
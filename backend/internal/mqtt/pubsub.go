@@ -17,3 +17,10 @@ func Publish(client mqtt.Client, topic string, message string) {
 	token := client.Publish(topic, 0, false, message)
 	token.Wait()
 }
+
+// PublishRetained publishes a message the broker keeps for late subscribers,
+// for topics that describe current state rather than events.
+func PublishRetained(client mqtt.Client, topic string, message string) {
+	token := client.Publish(topic, 1, true, message)
+	token.Wait()
+}
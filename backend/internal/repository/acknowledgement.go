@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"fall-detection/internal/database"
+	"time"
+)
+
+// Acknowledgement records someone pressing "I've seen this" on an alert.
+type Acknowledgement struct {
+	FallEventID    int64
+	UserID         int64 // Telegram user, not chat
+	DisplayName    string
+	AcknowledgedAt time.Time
+}
+
+type AcknowledgementRepo struct {
+	db *database.DB
+}
+
+func NewAcknowledgementRepo(db *database.DB) *AcknowledgementRepo {
+	return &AcknowledgementRepo{db: db}
+}
+
+// Create records an acknowledgement and queues notify's notifications for
+// it in the same transaction. Returns false if this user had already
+// acknowledged the event, in which case the first time is kept and nothing
+// is queued.
+func (r *AcknowledgementRepo) Create(ctx context.Context, eventID int64, userID int64, displayName string, notify OutboxFunc) (bool, error) {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO event_acknowledgements (fall_event_id, user_id, display_name, acknowledged_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (fall_event_id, user_id) DO NOTHING
+	`
+	result, err := tx.Exec(ctx, query, eventID, userID, displayName, time.Now())
+	if err != nil {
+		return false, err
+	}
+	if result.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	event := FallEvent{ID: eventID}
+	err = tx.QueryRow(ctx, `SELECT board_id, kind, detected_at, status FROM fall_events WHERE id = $1`, eventID).
+		Scan(&event.BoardID, &event.Kind, &event.DetectedAt, &event.Status)
+	if err != nil {
+		return false, err
+	}
+	if err := enqueueEvent(ctx, tx, notify, event); err != nil {
+		return false, err
+	}
+	return true, tx.Commit(ctx)
+}
+
+// GetByEvents returns the acknowledgements of several events, oldest first,
+// by event ID.
+func (r *AcknowledgementRepo) GetByEvents(ctx context.Context, eventIDs []int64) (map[int64][]Acknowledgement, error) {
+	query := `
+		SELECT fall_event_id, user_id, display_name, acknowledged_at
+		FROM event_acknowledgements
+		WHERE fall_event_id = ANY($1)
+		ORDER BY acknowledged_at
+	`
+	rows, err := r.db.Pool.Query(ctx, query, eventIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	acks := make(map[int64][]Acknowledgement)
+	for rows.Next() {
+		var a Acknowledgement
+		if err := rows.Scan(&a.FallEventID, &a.UserID, &a.DisplayName, &a.AcknowledgedAt); err != nil {
+			return nil, err
+		}
+		acks[a.FallEventID] = append(acks[a.FallEventID], a)
+	}
+	return acks, rows.Err()
+}
+
+// AckMetrics summarises how quickly a board's falls were first acknowledged.
+type AckMetrics struct {
+	Events       int
+	Acknowledged int
+	Mean         *time.Duration // nil when no fall was acknowledged
+	Median       *time.Duration
+	Max          *time.Duration
+}
+
+// GetMetrics computes time-to-acknowledge over a board's falls detected
+// since the given time, from each fall's first acknowledgement. Falls are
+// selected the same way as for FallEventRepo.GetStats.
+func (r *AcknowledgementRepo) GetMetrics(ctx context.Context, boardID string, since time.Time) (AckMetrics, error) {
+	query := `
+		WITH ` + fallsCTE + `
+		SELECT COUNT(*), COUNT(seen_secs), AVG(seen_secs),
+			PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY seen_secs), MAX(seen_secs)
+		FROM falls
+	`
+	var m AckMetrics
+	var mean, median, max *float64
+	err := r.db.Pool.QueryRow(ctx, query, since, time.Now(), boardID).Scan(&m.Events, &m.Acknowledged, &mean, &median, &max)
+	if err != nil {
+		return AckMetrics{}, err
+	}
+	m.Mean, m.Median, m.Max = secondsPtr(mean), secondsPtr(median), secondsPtr(max)
+	return m, nil
+}
+
+func secondsPtr(secs *float64) *time.Duration {
+	if secs == nil {
+		return nil
+	}
+	d := time.Duration(*secs * float64(time.Second))
+	return &d
+}
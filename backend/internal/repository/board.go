@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"fall-detection/internal/database"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Board is a board known to the facility, whether or not it is connected.
+// Who wears it is tracked by ResidentRepo.
+type Board struct {
+	ID          string // e.g. board1
+	DisplayName string
+	Location    string
+	Firmware    string
+	FirstSeenAt *time.Time // nil for boards registered before they connected
+	LastSeenAt  *time.Time
+	Enabled     bool
+	UpdatedAt   time.Time
+}
+
+// Name returns the display name, falling back to the board ID.
+func (b Board) Name() string {
+	if b.DisplayName != "" {
+		return b.DisplayName
+	}
+	return b.ID
+}
+
+// BoardDetails are the fields staff can edit.
+type BoardDetails struct {
+	DisplayName string
+	Location    string
+	Enabled     *bool // nil keeps the current value, or enables a new board
+}
+
+type BoardRepo struct {
+	db *database.DB
+}
+
+func NewBoardRepo(db *database.DB) *BoardRepo {
+	return &BoardRepo{db: db}
+}
+
+const boardColumns = `id, display_name, location, firmware_version, first_seen_at, last_seen_at, enabled, updated_at`
+
+func scanBoard(row pgx.Row) (*Board, error) {
+	var b Board
+	err := row.Scan(&b.ID, &b.DisplayName, &b.Location, &b.Firmware, &b.FirstSeenAt, &b.LastSeenAt, &b.Enabled, &b.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// RecordSeen registers a connecting board, or updates its last-seen time and
+// firmware if it is already known. An empty firmware keeps the stored one.
+func (r *BoardRepo) RecordSeen(ctx context.Context, id string, firmware string, at time.Time) (*Board, error) {
+	query := `
+		INSERT INTO boards (id, firmware_version, first_seen_at, last_seen_at)
+		VALUES ($1, $2, $3, $3)
+		ON CONFLICT (id) DO UPDATE SET
+			firmware_version = COALESCE(NULLIF(EXCLUDED.firmware_version, ''), boards.firmware_version),
+			first_seen_at = COALESCE(boards.first_seen_at, EXCLUDED.first_seen_at),
+			last_seen_at = EXCLUDED.last_seen_at
+		RETURNING ` + boardColumns
+	return scanBoard(r.db.Pool.QueryRow(ctx, query, id, firmware, at))
+}
+
+// Get returns a board, or pgx.ErrNoRows if it is unknown.
+func (r *BoardRepo) Get(ctx context.Context, id string) (*Board, error) {
+	query := `SELECT ` + boardColumns + ` FROM boards WHERE id = $1`
+	return scanBoard(r.db.Pool.QueryRow(ctx, query, id))
+}
+
+func (r *BoardRepo) List(ctx context.Context) ([]Board, error) {
+	query := `SELECT ` + boardColumns + ` FROM boards ORDER BY id`
+	rows, err := r.db.Pool.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var boards []Board
+	for rows.Next() {
+		b, err := scanBoard(rows)
+		if err != nil {
+			return nil, err
+		}
+		boards = append(boards, *b)
+	}
+	return boards, rows.Err()
+}
+
+// Save creates or updates a board's editable details, so boards can be set
+// up before they first connect.
+func (r *BoardRepo) Save(ctx context.Context, id string, d BoardDetails) (*Board, error) {
+	query := `
+		INSERT INTO boards (id, display_name, location, enabled)
+		VALUES ($1, $2, $3, COALESCE($4, TRUE))
+		ON CONFLICT (id) DO UPDATE SET
+			display_name = EXCLUDED.display_name,
+			location = EXCLUDED.location,
+			enabled = COALESCE($4, boards.enabled),
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING ` + boardColumns
+	return scanBoard(r.db.Pool.QueryRow(ctx, query, id, d.DisplayName, d.Location, d.Enabled))
+}
+
+// Names returns the display name of every named board, by board ID.
+func (r *BoardRepo) Names(ctx context.Context) (map[string]string, error) {
+	rows, err := r.db.Pool.Query(ctx, `SELECT id, display_name FROM boards WHERE display_name <> ''`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := make(map[string]string)
+	for rows.Next() {
+		var id, name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		names[id] = name
+	}
+	return names, rows.Err()
+}
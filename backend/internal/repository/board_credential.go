@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"fall-detection/internal/database"
+)
+
+type BoardCredentialRepo struct {
+	db *database.DB
+}
+
+func NewBoardCredentialRepo(db *database.DB) *BoardCredentialRepo {
+	return &BoardCredentialRepo{db: db}
+}
+
+// GetSecret returns the shared secret a board signs its handshake with.
+// Returns pgx.ErrNoRows if the board has not been provisioned.
+func (r *BoardCredentialRepo) GetSecret(ctx context.Context, boardID string) ([]byte, error) {
+	query := `
+		SELECT secret FROM board_credentials WHERE board_id = $1
+	`
+	var secret []byte
+	err := r.db.Pool.QueryRow(ctx, query, boardID).Scan(&secret)
+	if err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// HasSecret reports whether a board has been provisioned with a secret.
+func (r *BoardCredentialRepo) HasSecret(ctx context.Context, boardID string) (bool, error) {
+	query := `
+		SELECT EXISTS (SELECT 1 FROM board_credentials WHERE board_id = $1)
+	`
+	var exists bool
+	err := r.db.Pool.QueryRow(ctx, query, boardID).Scan(&exists)
+	return exists, err
+}
+
+// SetSecret provisions or rotates a board's secret.
+func (r *BoardCredentialRepo) SetSecret(ctx context.Context, boardID string, secret []byte) error {
+	query := `
+		INSERT INTO board_credentials (board_id, secret)
+		VALUES ($1, $2)
+		ON CONFLICT (board_id) DO UPDATE SET secret = EXCLUDED.secret, updated_at = CURRENT_TIMESTAMP
+	`
+	_, err := r.db.Pool.Exec(ctx, query, boardID, secret)
+	return err
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"fall-detection/internal/database"
+	"fall-detection/internal/telemetry"
+	"time"
+)
+
+type DetectionProfile struct {
+	BoardID      string
+	Version      int
+	Thresholds   telemetry.Thresholds
+	AckedVersion int
+	AckedAt      *time.Time
+	UpdatedAt    time.Time
+}
+
+type DetectionProfileRepo struct {
+	db *database.DB
+}
+
+func NewDetectionProfileRepo(db *database.DB) *DetectionProfileRepo {
+	return &DetectionProfileRepo{db: db}
+}
+
+// Get returns the board's stored profile, or pgx.ErrNoRows if the board runs
+// the firmware defaults.
+func (r *DetectionProfileRepo) Get(ctx context.Context, boardID string) (*DetectionProfile, error) {
+	query := `
+		SELECT board_id, version, freefall_threshold, impact_threshold, impact_delta,
+			impact_gyro_max, gyro_threshold, lying_threshold, fall_detection_time_ms,
+			lying_detection_time_ms, pressure_fall_threshold, acked_version, acked_at, updated_at
+		FROM detection_profiles
+		WHERE board_id = $1
+	`
+	var p DetectionProfile
+	var fallMs, lyingMs int64
+	t := &p.Thresholds
+	err := r.db.Pool.QueryRow(ctx, query, boardID).Scan(
+		&p.BoardID, &p.Version, &t.FreefallThreshold, &t.ImpactThreshold, &t.ImpactDelta,
+		&t.ImpactGyroMax, &t.GyroThreshold, &t.LyingThreshold, &fallMs,
+		&lyingMs, &t.PressureFallThreshold, &p.AckedVersion, &p.AckedAt, &p.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	t.FallDetectionTime = time.Duration(fallMs) * time.Millisecond
+	t.LyingDetectionTime = time.Duration(lyingMs) * time.Millisecond
+	return &p, nil
+}
+
+// Save stores new thresholds for a board and returns the new profile version.
+// Every save bumps the version so boards can be re-synced.
+func (r *DetectionProfileRepo) Save(ctx context.Context, boardID string, t telemetry.Thresholds) (int, error) {
+	query := `
+		INSERT INTO detection_profiles (
+			board_id, freefall_threshold, impact_threshold, impact_delta, impact_gyro_max,
+			gyro_threshold, lying_threshold, fall_detection_time_ms, lying_detection_time_ms,
+			pressure_fall_threshold
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (board_id) DO UPDATE SET
+			version = detection_profiles.version + 1,
+			freefall_threshold = EXCLUDED.freefall_threshold,
+			impact_threshold = EXCLUDED.impact_threshold,
+			impact_delta = EXCLUDED.impact_delta,
+			impact_gyro_max = EXCLUDED.impact_gyro_max,
+			gyro_threshold = EXCLUDED.gyro_threshold,
+			lying_threshold = EXCLUDED.lying_threshold,
+			fall_detection_time_ms = EXCLUDED.fall_detection_time_ms,
+			lying_detection_time_ms = EXCLUDED.lying_detection_time_ms,
+			pressure_fall_threshold = EXCLUDED.pressure_fall_threshold,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING version
+	`
+	var version int
+	err := r.db.Pool.QueryRow(ctx, query,
+		boardID, t.FreefallThreshold, t.ImpactThreshold, t.ImpactDelta, t.ImpactGyroMax,
+		t.GyroThreshold, t.LyingThreshold, t.FallDetectionTime.Milliseconds(), t.LyingDetectionTime.Milliseconds(),
+		t.PressureFallThreshold,
+	).Scan(&version)
+	if err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+// MarkAcked records that the board acknowledged a profile version. Late acks
+// for older versions never move acked_version backwards.
+func (r *DetectionProfileRepo) MarkAcked(ctx context.Context, boardID string, version int) error {
+	query := `
+		UPDATE detection_profiles
+		SET acked_version = $1, acked_at = $2
+		WHERE board_id = $3 AND acked_version <= $1
+	`
+	_, err := r.db.Pool.Exec(ctx, query, version, time.Now(), boardID)
+	return err
+}
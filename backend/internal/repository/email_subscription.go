@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"fall-detection/internal/database"
+	"time"
+)
+
+// EmailSubscription sends a board's alerts to one email address.
+type EmailSubscription struct {
+	ID         int64
+	Email      string
+	Name       string
+	BoardID    string
+	ApprovedBy string // Admin who added the address
+	CreatedAt  time.Time
+}
+
+type EmailSubscriptionRepo struct {
+	db *database.DB
+}
+
+func NewEmailSubscriptionRepo(db *database.DB) *EmailSubscriptionRepo {
+	return &EmailSubscriptionRepo{db: db}
+}
+
+const emailSubscriptionColumns = `id, email, name, board_id, approved_by, created_at`
+
+func (r *EmailSubscriptionRepo) query(ctx context.Context, query string, args ...any) ([]EmailSubscription, error) {
+	rows, err := r.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []EmailSubscription
+	for rows.Next() {
+		var s EmailSubscription
+		if err := rows.Scan(&s.ID, &s.Email, &s.Name, &s.BoardID, &s.ApprovedBy, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		subs = append(subs, s)
+	}
+	return subs, rows.Err()
+}
+
+// Subscribe adds an address to a board's alerts on approvedBy's say-so,
+// updating the recipient's name if they were already subscribed. Returns the
+// subscription's ID.
+func (r *EmailSubscriptionRepo) Subscribe(ctx context.Context, email, name, boardID, approvedBy string) (int64, error) {
+	query := `
+		INSERT INTO email_subscriptions (email, name, board_id, approved_by, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (email, board_id) DO UPDATE SET name = EXCLUDED.name, approved_by = EXCLUDED.approved_by
+		RETURNING id
+	`
+	var id int64
+	err := r.db.Pool.QueryRow(ctx, query, email, name, boardID, approvedBy, time.Now()).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// Delete removes a subscription. Returns false if it does not exist.
+func (r *EmailSubscriptionRepo) Delete(ctx context.Context, id int64) (bool, error) {
+	result, err := r.db.Pool.Exec(ctx, `DELETE FROM email_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+func (r *EmailSubscriptionRepo) Get(ctx context.Context, id int64) (*EmailSubscription, error) {
+	var s EmailSubscription
+	err := r.db.Pool.QueryRow(ctx, `SELECT `+emailSubscriptionColumns+` FROM email_subscriptions WHERE id = $1`, id).
+		Scan(&s.ID, &s.Email, &s.Name, &s.BoardID, &s.ApprovedBy, &s.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// GetByBoard returns the addresses subscribed to a board.
+func (r *EmailSubscriptionRepo) GetByBoard(ctx context.Context, boardID string) ([]EmailSubscription, error) {
+	return r.query(ctx, `SELECT `+emailSubscriptionColumns+` FROM email_subscriptions WHERE board_id = $1 ORDER BY id`, boardID)
+}
+
+// GetByEmail returns every board an address is subscribed to.
+func (r *EmailSubscriptionRepo) GetByEmail(ctx context.Context, email string) ([]EmailSubscription, error) {
+	return r.query(ctx, `SELECT `+emailSubscriptionColumns+` FROM email_subscriptions WHERE email = $1 ORDER BY board_id`, email)
+}
+
+func (r *EmailSubscriptionRepo) List(ctx context.Context) ([]EmailSubscription, error) {
+	return r.query(ctx, `SELECT `+emailSubscriptionColumns+` FROM email_subscriptions ORDER BY email, board_id`)
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"fall-detection/internal/database"
+	"time"
+)
+
+// Escalation step outcomes and the reasons a step was cancelled.
+const (
+	EscalationNotified  = "notified"
+	EscalationCancelled = "cancelled"
+
+	CancelSeen     = "seen"
+	CancelResolved = "resolved"
+	CancelExpired  = "expired"
+)
+
+// EscalationStep notifies ChatID, or the board's subscribers when it is nil,
+// once Delay has passed without the event being seen or resolved.
+type EscalationStep struct {
+	Delay  time.Duration
+	ChatID *int64
+	Label  string
+}
+
+// DefaultEscalation alerts the board's subscribers at once, for boards
+// without a policy of their own.
+func DefaultEscalation() []EscalationStep {
+	return []EscalationStep{{Label: "subscribers"}}
+}
+
+// EscalationRecord is what happened to one step of a fall event's chain.
+type EscalationRecord struct {
+	Position   int
+	Label      string
+	Status     string
+	Recipients int
+	Reason     *string
+	RecordedAt time.Time
+}
+
+type EscalationRepo struct {
+	db *database.DB
+}
+
+func NewEscalationRepo(db *database.DB) *EscalationRepo {
+	return &EscalationRepo{db: db}
+}
+
+// GetPolicy returns a board's escalation chain in order, or nil if the board
+// has none.
+func (r *EscalationRepo) GetPolicy(ctx context.Context, boardID string) ([]EscalationStep, error) {
+	query := `
+		SELECT delay_secs, chat_id, label
+		FROM escalation_steps
+		WHERE board_id = $1
+		ORDER BY position
+	`
+	rows, err := r.db.Pool.Query(ctx, query, boardID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var steps []EscalationStep
+	for rows.Next() {
+		var s EscalationStep
+		var delaySecs int64
+		if err := rows.Scan(&delaySecs, &s.ChatID, &s.Label); err != nil {
+			return nil, err
+		}
+		s.Delay = time.Duration(delaySecs) * time.Second
+		steps = append(steps, s)
+	}
+	return steps, rows.Err()
+}
+
+// SavePolicy replaces a board's escalation chain. An empty chain restores the
+// default.
+func (r *EscalationRepo) SavePolicy(ctx context.Context, boardID string, steps []EscalationStep) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM escalation_steps WHERE board_id = $1`, boardID); err != nil {
+		return err
+	}
+	for i, s := range steps {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO escalation_steps (board_id, position, delay_secs, chat_id, label)
+			VALUES ($1, $2, $3, $4, $5)
+		`, boardID, i, int64(s.Delay/time.Second), s.ChatID, s.Label)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// Record stores the outcome of one step of a fall event's chain. reason is
+// empty for notified steps.
+func (r *EscalationRepo) Record(ctx context.Context, eventID int64, position int, label, status string, recipients int, reason string) error {
+	query := `
+		INSERT INTO fall_event_escalations (fall_event_id, position, label, status, recipients, reason, recorded_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7)
+	`
+	_, err := r.db.Pool.Exec(ctx, query, eventID, position, label, status, recipients, reason, time.Now())
+	return err
+}
+
+func (r *EscalationRepo) GetByEvent(ctx context.Context, eventID int64) ([]EscalationRecord, error) {
+	query := `
+		SELECT position, label, status, recipients, reason, recorded_at
+		FROM fall_event_escalations
+		WHERE fall_event_id = $1
+		ORDER BY position, recorded_at
+	`
+	rows, err := r.db.Pool.Query(ctx, query, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []EscalationRecord
+	for rows.Next() {
+		var e EscalationRecord
+		if err := rows.Scan(&e.Position, &e.Label, &e.Status, &e.Recipients, &e.Reason, &e.RecordedAt); err != nil {
+			return nil, err
+		}
+		records = append(records, e)
+	}
+	return records, rows.Err()
+}
@@ -6,13 +6,26 @@ import (
 	"time"
 )
 
+// Event kinds. Inactivity events are raised by the server when a board has
+// not moved for too long, and clear when it moves again.
+const (
+	EventKindFall       = "fall"
+	EventKindInactivity = "inactivity"
+)
+
 type FallEvent struct {
-	ID         int64
-	BoardID    string
-	DetectedAt time.Time
-	ResolvedAt *time.Time
-	ResolvedBy *int64
-	Status     string
+	ID          int64
+	BoardID     string
+	Kind        string
+	DetectedAt  time.Time
+	ResolvedAt  *time.Time
+	ResolvedBy  *int64  // Telegram user who sent a remote reset
+	ResolvedVia *string // How it was cleared, e.g. nfc
+	Status      string
+
+	// Resident wearing the board when the event was raised, if assigned
+	ResidentID   *int64
+	ResidentName *string
 }
 
 type FallEventRepo struct {
@@ -23,18 +36,34 @@ func NewFallEventRepo(db *database.DB) *FallEventRepo {
 	return &FallEventRepo{db: db}
 }
 
-func (r *FallEventRepo) Create(ctx context.Context, boardID string) (int64, error) {
+// Create records a new active event and queues notify's notifications for
+// it in the same transaction.
+func (r *FallEventRepo) Create(ctx context.Context, boardID string, kind string, notify OutboxFunc) (int64, error) {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
 	query := `
-		INSERT INTO fall_events (board_id, detected_at)
-		VALUES ($1, $2)
+		INSERT INTO fall_events (board_id, kind, detected_at, resident_id)
+		VALUES ($1, $2, $3, (
+			SELECT resident_id FROM board_assignments
+			WHERE board_id = $1 AND unassigned_at IS NULL
+		))
 		RETURNING id
 	`
-	var id int64
-	err := r.db.Pool.QueryRow(ctx, query, boardID, time.Now()).Scan(&id)
-	if err != nil {
+	event := FallEvent{BoardID: boardID, Kind: kind, DetectedAt: time.Now(), Status: "active"}
+	if err := tx.QueryRow(ctx, query, boardID, kind, event.DetectedAt).Scan(&event.ID); err != nil {
+		return 0, err
+	}
+	if err := enqueueEvent(ctx, tx, notify, event); err != nil {
 		return 0, err
 	}
-	return id, nil
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return event.ID, nil
 }
 
 // Resolve marks a fall event as resolved. Returns (true, nil) if the event was
@@ -51,56 +80,111 @@ func (r *FallEventRepo) Resolve(ctx context.Context, id int64, resolvedBy int64)
 	return result.RowsAffected() > 0, nil
 }
 
-// AutoExpireStale expires active events older than ttl and returns the board IDs
-// that were just expired so the caller can notify subscribers.
-func (r *FallEventRepo) AutoExpireStale(ctx context.Context, ttl time.Duration) ([]string, error) {
+// AutoExpireStale expires active fall events older than ttl and returns the
+// events that were just expired, queueing notify's notifications for each.
+// Inactivity events only clear when the board moves again.
+func (r *FallEventRepo) AutoExpireStale(ctx context.Context, ttl time.Duration, notify OutboxFunc) ([]FallEvent, error) {
 	query := `
 		UPDATE fall_events
 		SET status = 'expired', resolved_at = $1
-		WHERE status = 'active' AND detected_at < $2
-		RETURNING board_id
+		WHERE status = 'active' AND kind = 'fall' AND detected_at < $2
+		RETURNING id, board_id, kind, detected_at, resolved_at, status
+	`
+	return r.updateEvents(ctx, notify, query, time.Now(), time.Now().Add(-ttl))
+}
+
+// ResolveActiveForBoard resolves the active fall events for a board and
+// returns them, or none if nothing was active. via says how the board was
+// cleared, and by is the Telegram user who cleared it remotely or nil for an
+// NFC tap. Inactivity events clear only when the board moves again.
+func (r *FallEventRepo) ResolveActiveForBoard(ctx context.Context, boardID string, via string, by *int64, notify OutboxFunc) ([]FallEvent, error) {
+	query := `
+		UPDATE fall_events
+		SET status = 'resolved', resolved_at = $1, resolved_via = $3, resolved_by = $4
+		WHERE board_id = $2 AND kind = 'fall' AND status = 'active'
+		RETURNING id, board_id, kind, detected_at, resolved_at, status
 	`
-	rows, err := r.db.Pool.Query(ctx, query, time.Now(), time.Now().Add(-ttl))
+	return r.updateEvents(ctx, notify, query, time.Now(), boardID, via, by)
+}
+
+// ResolveActiveOfKind resolves a board's active events of one kind with no
+// user, e.g. an inactivity event when the board moves again.
+func (r *FallEventRepo) ResolveActiveOfKind(ctx context.Context, boardID string, kind string, via string, notify OutboxFunc) ([]FallEvent, error) {
+	query := `
+		UPDATE fall_events
+		SET status = 'resolved', resolved_at = $1, resolved_via = $4
+		WHERE board_id = $2 AND kind = $3 AND status = 'active'
+		RETURNING id, board_id, kind, detected_at, resolved_at, status
+	`
+	return r.updateEvents(ctx, notify, query, time.Now(), boardID, kind, via)
+}
+
+// updateEvents runs an UPDATE ... RETURNING of the events it changed and
+// queues their notifications in the same transaction.
+func (r *FallEventRepo) updateEvents(ctx context.Context, notify OutboxFunc, query string, args ...any) ([]FallEvent, error) {
+	tx, err := r.db.Pool.Begin(ctx)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	defer tx.Rollback(ctx)
 
-	var boardIDs []string
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	var events []FallEvent
 	for rows.Next() {
-		var boardID string
-		if err := rows.Scan(&boardID); err != nil {
+		var e FallEvent
+		if err := rows.Scan(&e.ID, &e.BoardID, &e.Kind, &e.DetectedAt, &e.ResolvedAt, &e.Status); err != nil {
+			rows.Close()
 			return nil, err
 		}
-		boardIDs = append(boardIDs, boardID)
+		events = append(events, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, e := range events {
+		if err := enqueueEvent(ctx, tx, notify, e); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
 	}
-	return boardIDs, rows.Err()
+	return events, nil
 }
 
-// ResolveActiveForBoard resolves the active fall event for a board with no user
-// (used for NFC-tap resolutions where there is no Telegram user involved).
-func (r *FallEventRepo) ResolveActiveForBoard(ctx context.Context, boardID string) (bool, error) {
+func (r *FallEventRepo) GetByBoard(ctx context.Context, boardID string, limit int) ([]FallEvent, error) {
 	query := `
-		UPDATE fall_events
-		SET status = 'resolved', resolved_at = $1
-		WHERE board_id = $2 AND status = 'active'
+		SELECT e.id, e.board_id, e.kind, e.detected_at, e.resolved_at, e.resolved_by, e.resolved_via, e.status, e.resident_id, r.name
+		FROM fall_events e
+		LEFT JOIN residents r ON r.id = e.resident_id
+		WHERE e.board_id = $1
+		ORDER BY e.detected_at DESC
+		LIMIT $2
 	`
-	result, err := r.db.Pool.Exec(ctx, query, time.Now(), boardID)
-	if err != nil {
-		return false, err
-	}
-	return result.RowsAffected() > 0, nil
+	return r.queryEvents(ctx, query, boardID, limit)
 }
 
-func (r *FallEventRepo) GetByBoard(ctx context.Context, boardID string, limit int) ([]FallEvent, error) {
+// GetByResident returns a resident's events across every board they have
+// worn, newest first.
+func (r *FallEventRepo) GetByResident(ctx context.Context, residentID int64, limit int) ([]FallEvent, error) {
 	query := `
-		SELECT id, board_id, detected_at, resolved_at, status
-		FROM fall_events
-		WHERE board_id = $1
-		ORDER BY detected_at DESC
+		SELECT e.id, e.board_id, e.kind, e.detected_at, e.resolved_at, e.resolved_by, e.resolved_via, e.status, e.resident_id, r.name
+		FROM fall_events e
+		LEFT JOIN residents r ON r.id = e.resident_id
+		WHERE e.resident_id = $1
+		ORDER BY e.detected_at DESC
 		LIMIT $2
 	`
-	rows, err := r.db.Pool.Query(ctx, query, boardID, limit)
+	return r.queryEvents(ctx, query, residentID, limit)
+}
+
+func (r *FallEventRepo) queryEvents(ctx context.Context, query string, args ...any) ([]FallEvent, error) {
+	rows, err := r.db.Pool.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -109,7 +193,7 @@ func (r *FallEventRepo) GetByBoard(ctx context.Context, boardID string, limit in
 	var events []FallEvent
 	for rows.Next() {
 		var e FallEvent
-		if err := rows.Scan(&e.ID, &e.BoardID, &e.DetectedAt, &e.ResolvedAt, &e.Status); err != nil {
+		if err := rows.Scan(&e.ID, &e.BoardID, &e.Kind, &e.DetectedAt, &e.ResolvedAt, &e.ResolvedBy, &e.ResolvedVia, &e.Status, &e.ResidentID, &e.ResidentName); err != nil {
 			return nil, err
 		}
 		events = append(events, e)
@@ -119,28 +203,31 @@ func (r *FallEventRepo) GetByBoard(ctx context.Context, boardID string, limit in
 
 func (r *FallEventRepo) GetByID(ctx context.Context, id int64) (*FallEvent, error) {
 	query := `
-		SELECT id, board_id, detected_at, resolved_at, status
-		FROM fall_events
-		WHERE id = $1
+		SELECT e.id, e.board_id, e.kind, e.detected_at, e.resolved_at, e.resolved_by, e.resolved_via, e.status, e.resident_id, r.name
+		FROM fall_events e
+		LEFT JOIN residents r ON r.id = e.resident_id
+		WHERE e.id = $1
 	`
 	var e FallEvent
-	err := r.db.Pool.QueryRow(ctx, query, id).Scan(&e.ID, &e.BoardID, &e.DetectedAt, &e.ResolvedAt, &e.Status)
+	err := r.db.Pool.QueryRow(ctx, query, id).Scan(&e.ID, &e.BoardID, &e.Kind, &e.DetectedAt, &e.ResolvedAt, &e.ResolvedBy, &e.ResolvedVia, &e.Status, &e.ResidentID, &e.ResidentName)
 	if err != nil {
 		return nil, err
 	}
 	return &e, nil
 }
 
-func (r *FallEventRepo) GetActive(ctx context.Context, boardID string) (*FallEvent, error) {
+// GetActive returns the board's active event of the given kind. A board can
+// have an active fall and inactivity event at the same time.
+func (r *FallEventRepo) GetActive(ctx context.Context, boardID string, kind string) (*FallEvent, error) {
 	query := `
-		SELECT id, board_id, detected_at, status
+		SELECT id, board_id, kind, detected_at, status
 		FROM fall_events
-		WHERE board_id = $1 AND status = 'active'
+		WHERE board_id = $1 AND kind = $2 AND status = 'active'
 		LIMIT 1
 	`
 
 	var event FallEvent
-	err := r.db.Pool.QueryRow(ctx, query, boardID).Scan(&event.ID, &event.BoardID, &event.DetectedAt, &event.Status)
+	err := r.db.Pool.QueryRow(ctx, query, boardID, kind).Scan(&event.ID, &event.BoardID, &event.Kind, &event.DetectedAt, &event.Status)
 	if err != nil {
 		return nil, err
 	}
@@ -149,10 +236,11 @@ func (r *FallEventRepo) GetActive(ctx context.Context, boardID string) (*FallEve
 
 func (r *FallEventRepo) GetLastFiveEvents(ctx context.Context, boardID string) ([]FallEvent, error) {
 	query := `
-		SELECT detected_at, resolved_at, resolved_by, status	
-		FROM fall_events
-		WHERE board_id = $1
-		ORDER BY detected_at DESC
+		SELECT e.id, e.kind, e.detected_at, e.resolved_at, e.resolved_by, e.status, r.name
+		FROM fall_events e
+		LEFT JOIN residents r ON r.id = e.resident_id
+		WHERE e.board_id = $1
+		ORDER BY e.detected_at DESC
 		LIMIT 5
 	`
 
@@ -165,7 +253,7 @@ func (r *FallEventRepo) GetLastFiveEvents(ctx context.Context, boardID string) (
 	var events []FallEvent
 	for rows.Next() {
 		var e FallEvent
-		if err := rows.Scan(&e.DetectedAt, &e.ResolvedAt, &e.ResolvedBy, &e.Status); err != nil {
+		if err := rows.Scan(&e.ID, &e.Kind, &e.DetectedAt, &e.ResolvedAt, &e.ResolvedBy, &e.Status, &e.ResidentName); err != nil {
 			return nil, err
 		}
 		events = append(events, e)
@@ -175,20 +263,22 @@ func (r *FallEventRepo) GetLastFiveEvents(ctx context.Context, boardID string) (
 
 }
 
-// GetRecent returns the most recent fall event for a board regardless of status,
-// as long as it was detected within the given window. Used to prevent re-firing
-// after an early ACK while the board is still in fall state.
-func (r *FallEventRepo) GetRecent(ctx context.Context, boardID string, window time.Duration) (*FallEvent, error) {
+// GetForFall returns the fall event that a fall raised by a board at
+// raisedAt belongs to: the event that was already active then and absorbed
+// it as a duplicate, or else the first one created after it. Later falls on
+// the same board are never returned.
+func (r *FallEventRepo) GetForFall(ctx context.Context, boardID string, raisedAt time.Time) (*FallEvent, error) {
 	query := `
-		SELECT id, board_id, detected_at, status
+		SELECT id, board_id, kind, detected_at, status
 		FROM fall_events
-		WHERE board_id = $1 AND detected_at >= $2
-		ORDER BY detected_at DESC
+		WHERE board_id = $1 AND kind = 'fall'
+			AND (detected_at >= $2 OR resolved_at IS NULL OR resolved_at >= $2)
+		ORDER BY detected_at
 		LIMIT 1
 	`
 	var event FallEvent
-	err := r.db.Pool.QueryRow(ctx, query, boardID, time.Now().Add(-window)).Scan(
-		&event.ID, &event.BoardID, &event.DetectedAt, &event.Status,
+	err := r.db.Pool.QueryRow(ctx, query, boardID, raisedAt).Scan(
+		&event.ID, &event.BoardID, &event.Kind, &event.DetectedAt, &event.Status,
 	)
 	if err != nil {
 		return nil, err
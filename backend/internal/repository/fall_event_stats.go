@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// ResponseTimes summarises how long falls took to reach a milestone. Nil
+// when no fall in the range reached it.
+type ResponseTimes struct {
+	Median *time.Duration
+	P90    *time.Duration
+}
+
+// FallStats counts the falls detected on one board, or across the facility
+// when BoardID is empty. Only fall events are counted, not inactivity.
+type FallStats struct {
+	BoardID  string
+	Falls    int
+	Resolved int // Cleared by NFC tap; remote resets count in neither this nor Expired
+	Expired  int // Never cleared, timed out by the safety net
+	Active   int
+	Seen     int // Acknowledged by at least one person
+
+	TimeToSeen    ResponseTimes // Detection until the first acknowledgement
+	TimeToResolve ResponseTimes // Detection until the NFC tap
+}
+
+// ExpiryRate is the share of finished falls that timed out instead of being
+// cleared at the board.
+func (s FallStats) ExpiryRate() float64 {
+	finished := s.Resolved + s.Expired
+	if finished == 0 {
+		return 0
+	}
+	return float64(s.Expired) / float64(finished)
+}
+
+// FallStatsReport covers falls detected in [From, To).
+type FallStatsReport struct {
+	From      time.Time
+	To        time.Time
+	Total     FallStats
+	Boards    []FallStats    // Per board, by board ID; only boards with falls
+	Location  *time.Location // Zone of the hour and weekday buckets
+	ByHour    [24]int        // Falls by hour of day detected
+	ByWeekday [7]int         // Falls by day of week, indexed by time.Weekday
+}
+
+// fallsCTE selects the falls (not inactivity events) detected in [$1, $2)
+// on board $3, or every board when $3 is empty, with the seconds until each
+// was first seen and, for falls cleared at the board, cleared. A remote
+// RESET_FALL says nothing about how fast someone reached the resident, so
+// only NFC taps count as resolved; resolved_via is NULL for falls resolved
+// before it was recorded, when NFC was the only way.
+const fallsCTE = `falls AS (
+	SELECT e.board_id, e.status,
+		e.status = 'resolved' AND (e.resolved_via = 'nfc' OR e.resolved_via IS NULL) AS nfc_resolved,
+		EXTRACT(EPOCH FROM (
+			SELECT MIN(a.acknowledged_at) FROM event_acknowledgements a
+			WHERE a.fall_event_id = e.id
+		) - e.detected_at)::double precision AS seen_secs,
+		CASE WHEN e.status = 'resolved' AND (e.resolved_via = 'nfc' OR e.resolved_via IS NULL)
+			THEN EXTRACT(EPOCH FROM e.resolved_at - e.detected_at)::double precision
+		END AS resolve_secs
+	FROM fall_events e
+	WHERE e.kind = 'fall' AND e.detected_at >= $1 AND e.detected_at < $2
+		AND ($3 = '' OR e.board_id = $3)
+)`
+
+// GetStats reports on the falls detected between from and to, on one board
+// or across every board when boardID is empty. Falls are bucketed by the
+// hour and weekday they happened in loc.
+func (r *FallEventRepo) GetStats(ctx context.Context, boardID string, from, to time.Time, loc *time.Location) (*FallStatsReport, error) {
+	report := &FallStatsReport{From: from, To: to, Total: FallStats{BoardID: boardID}, Location: loc}
+
+	// One pass computes both the per-board rows and the total row, which
+	// has a NULL board_id
+	query := `
+		WITH ` + fallsCTE + `
+		SELECT board_id,
+			COUNT(*),
+			COUNT(*) FILTER (WHERE nfc_resolved),
+			COUNT(*) FILTER (WHERE status = 'expired'),
+			COUNT(*) FILTER (WHERE status = 'active'),
+			COUNT(seen_secs),
+			PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY seen_secs),
+			PERCENTILE_CONT(0.9) WITHIN GROUP (ORDER BY seen_secs),
+			PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY resolve_secs),
+			PERCENTILE_CONT(0.9) WITHIN GROUP (ORDER BY resolve_secs)
+		FROM falls
+		GROUP BY GROUPING SETS ((board_id), ())
+		ORDER BY board_id NULLS FIRST
+	`
+	rows, err := r.db.Pool.Query(ctx, query, from, to, boardID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s FallStats
+		var rowBoardID *string
+		var seenMedian, seenP90, resolveMedian, resolveP90 *float64
+		err := rows.Scan(&rowBoardID, &s.Falls, &s.Resolved, &s.Expired, &s.Active, &s.Seen,
+			&seenMedian, &seenP90, &resolveMedian, &resolveP90)
+		if err != nil {
+			return nil, err
+		}
+		s.TimeToSeen = ResponseTimes{Median: secondsPtr(seenMedian), P90: secondsPtr(seenP90)}
+		s.TimeToResolve = ResponseTimes{Median: secondsPtr(resolveMedian), P90: secondsPtr(resolveP90)}
+
+		if rowBoardID == nil {
+			s.BoardID = boardID
+			report.Total = s
+			continue
+		}
+		s.BoardID = *rowBoardID
+		report.Boards = append(report.Boards, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Bucketed here rather than in SQL, since the database has no zone for
+	// detected_at. Falls are rare enough to fetch one by one.
+	query = `
+		SELECT detected_at
+		FROM fall_events
+		WHERE kind = 'fall' AND detected_at >= $1 AND detected_at < $2
+			AND ($3 = '' OR board_id = $3)
+	`
+	rows, err = r.db.Pool.Query(ctx, query, from, to, boardID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var detectedAt time.Time
+		if err := rows.Scan(&detectedAt); err != nil {
+			return nil, err
+		}
+		t := serverLocal(detectedAt).In(loc)
+		report.ByWeekday[t.Weekday()]++
+		report.ByHour[t.Hour()]++
+	}
+	return report, rows.Err()
+}
+
+// serverLocal restores the zone of a TIMESTAMP column. Times are stored as
+// the server's local wall clock and come back labelled UTC.
+func serverLocal(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.Local)
+}
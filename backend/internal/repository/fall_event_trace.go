@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"fall-detection/internal/database"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// FallEventTraceRepo stores the readings captured around each fall event.
+type FallEventTraceRepo struct {
+	db *database.DB
+}
+
+func NewFallEventTraceRepo(db *database.DB) *FallEventTraceRepo {
+	return &FallEventTraceRepo{db: db}
+}
+
+// Save stores a trace for a fall event. Returns (false, nil) if the event
+// already has a trace, e.g. when two captures resolve to the same event.
+func (r *FallEventTraceRepo) Save(ctx context.Context, fallEventID int64, readings []SensorReading) (bool, error) {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Serialise concurrent saves for the same event
+	if _, err := tx.Exec(ctx, `SELECT id FROM fall_events WHERE id = $1 FOR UPDATE`, fallEventID); err != nil {
+		return false, err
+	}
+
+	var exists bool
+	err = tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM fall_event_traces WHERE fall_event_id = $1)`, fallEventID).Scan(&exists)
+	if err != nil {
+		return false, err
+	}
+	if exists {
+		return false, nil
+	}
+
+	_, err = tx.CopyFrom(ctx,
+		pgx.Identifier{"fall_event_traces"},
+		append([]string{"fall_event_id"}, readingColumns...),
+		pgx.CopyFromSlice(len(readings), func(i int) ([]any, error) {
+			return append([]any{fallEventID}, readingValues(readings[i])...), nil
+		}),
+	)
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit(ctx)
+}
+
+func (r *FallEventTraceRepo) GetByEvent(ctx context.Context, fallEventID int64) ([]SensorReading, error) {
+	query := `
+		SELECT ` + readingSelectColumns + `
+		FROM fall_event_traces
+		WHERE fall_event_id = $1
+		ORDER BY received_at
+	`
+	rows, err := r.db.Pool.Query(ctx, query, fallEventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var readings []SensorReading
+	for rows.Next() {
+		var s SensorReading
+		if err := scanReading(rows, &s); err != nil {
+			return nil, err
+		}
+		readings = append(readings, s)
+	}
+	return readings, rows.Err()
+}
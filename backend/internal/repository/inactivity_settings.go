@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"fall-detection/internal/database"
+	"fall-detection/internal/detection"
+	"time"
+)
+
+type InactivitySettingsRepo struct {
+	db *database.DB
+}
+
+func NewInactivitySettingsRepo(db *database.DB) *InactivitySettingsRepo {
+	return &InactivitySettingsRepo{db: db}
+}
+
+// Get returns the board's inactivity settings, or pgx.ErrNoRows if the board
+// uses the server defaults.
+func (r *InactivitySettingsRepo) Get(ctx context.Context, boardID string) (*detection.InactivitySettings, error) {
+	query := `
+		SELECT enabled, period_secs, quiet_start_min, quiet_end_min
+		FROM inactivity_settings
+		WHERE board_id = $1
+	`
+	var s detection.InactivitySettings
+	var periodSecs int64
+	var quietStart, quietEnd *int
+	err := r.db.Pool.QueryRow(ctx, query, boardID).Scan(&s.Enabled, &periodSecs, &quietStart, &quietEnd)
+	if err != nil {
+		return nil, err
+	}
+	s.Period = time.Duration(periodSecs) * time.Second
+	if quietStart != nil && quietEnd != nil {
+		s.Quiet = &detection.QuietHours{Start: *quietStart, End: *quietEnd}
+	}
+	return &s, nil
+}
+
+func (r *InactivitySettingsRepo) Save(ctx context.Context, boardID string, s detection.InactivitySettings) error {
+	query := `
+		INSERT INTO inactivity_settings (board_id, enabled, period_secs, quiet_start_min, quiet_end_min)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (board_id) DO UPDATE SET
+			enabled = EXCLUDED.enabled,
+			period_secs = EXCLUDED.period_secs,
+			quiet_start_min = EXCLUDED.quiet_start_min,
+			quiet_end_min = EXCLUDED.quiet_end_min,
+			updated_at = CURRENT_TIMESTAMP
+	`
+	var quietStart, quietEnd *int
+	if s.Quiet != nil {
+		quietStart, quietEnd = &s.Quiet.Start, &s.Quiet.End
+	}
+	_, err := r.db.Pool.Exec(ctx, query, boardID, s.Enabled, int64(s.Period/time.Second), quietStart, quietEnd)
+	return err
+}
@@ -0,0 +1,243 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fall-detection/internal/database"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Outbox message states. Dead messages ran out of attempts and wait for
+// someone to retry them.
+const (
+	OutboxPending   = "pending"
+	OutboxDelivered = "delivered"
+	OutboxDead      = "dead"
+)
+
+// OutboxMessage is one notification for one channel.
+type OutboxMessage struct {
+	ID            int64
+	Channel       string
+	Type          string
+	BoardID       string
+	FallEventID   *int64
+	Payload       []byte // JSON
+	Status        string
+	Attempts      int
+	LastError     *string
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+	DeliveredAt   *time.Time
+
+	// EventStatus is the fall event's status when the message was claimed,
+	// nil for messages without an event.
+	EventStatus *string
+}
+
+// OutboxFunc returns the notifications to queue for a changed fall event.
+// It runs inside the transaction that changed the event, so the change and
+// its notifications are committed together.
+type OutboxFunc func(event FallEvent) []OutboxMessage
+
+type OutboxRepo struct {
+	db *database.DB
+}
+
+func NewOutboxRepo(db *database.DB) *OutboxRepo {
+	return &OutboxRepo{db: db}
+}
+
+// enqueueEvent queues notify's messages for event within tx. notify may be
+// nil.
+func enqueueEvent(ctx context.Context, tx pgx.Tx, notify OutboxFunc, event FallEvent) error {
+	if notify == nil {
+		return nil
+	}
+	for _, m := range notify(event) {
+		m.BoardID = event.BoardID
+		m.FallEventID = &event.ID
+		if err := insertOutbox(ctx, tx, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func insertOutbox(ctx context.Context, tx pgx.Tx, m OutboxMessage) error {
+	now := time.Now()
+	_, err := tx.Exec(ctx, `
+		INSERT INTO notification_outbox (channel, type, board_id, fall_event_id, payload, next_attempt_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)
+	`, m.Channel, m.Type, m.BoardID, m.FallEventID, m.Payload, now)
+	return err
+}
+
+// Enqueue queues notifications that are not tied to a fall event change,
+// such as a board going offline.
+func (r *OutboxRepo) Enqueue(ctx context.Context, messages []OutboxMessage) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	for _, m := range messages {
+		if err := insertOutbox(ctx, tx, m); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+const outboxColumns = `id, channel, type, board_id, fall_event_id, payload, status, attempts, last_error, next_attempt_at, created_at, delivered_at`
+
+func scanOutbox(row pgx.Row, extra ...any) (*OutboxMessage, error) {
+	var m OutboxMessage
+	dest := []any{&m.ID, &m.Channel, &m.Type, &m.BoardID, &m.FallEventID, &m.Payload, &m.Status,
+		&m.Attempts, &m.LastError, &m.NextAttemptAt, &m.CreatedAt, &m.DeliveredAt}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// ClaimNext returns a channel's oldest pending message that is due, or nil
+// if there is none, and holds it off for lease so that no other worker
+// delivers it at the same time.
+func (r *OutboxRepo) ClaimNext(ctx context.Context, channel string, lease time.Duration) (*OutboxMessage, error) {
+	now := time.Now()
+	query := `
+		UPDATE notification_outbox SET next_attempt_at = $3
+		WHERE id = (
+			SELECT id FROM notification_outbox
+			WHERE channel = $1 AND status = 'pending' AND next_attempt_at <= $2
+			ORDER BY id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + outboxColumns + `,
+			(SELECT e.status FROM fall_events e WHERE e.id = fall_event_id)
+	`
+	var eventStatus *string
+	m, err := scanOutbox(r.db.Pool.QueryRow(ctx, query, channel, now, now.Add(lease)), &eventStatus)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	m.EventStatus = eventStatus
+	return m, nil
+}
+
+// Extend holds a claimed message off until until, for deliveries that are
+// still running when the lease ClaimNext took runs out.
+func (r *OutboxRepo) Extend(ctx context.Context, id int64, until time.Time) error {
+	_, err := r.db.Pool.Exec(ctx, `
+		UPDATE notification_outbox SET next_attempt_at = $1
+		WHERE id = $2 AND status = 'pending' AND next_attempt_at < $1
+	`, until, id)
+	return err
+}
+
+// GetRecipients returns the recipients a message has already reached.
+func (r *OutboxRepo) GetRecipients(ctx context.Context, id int64) ([]string, error) {
+	rows, err := r.db.Pool.Query(ctx, `SELECT recipient FROM notification_outbox_recipients WHERE outbox_id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+// RecordRecipient records that a message has reached one recipient.
+func (r *OutboxRepo) RecordRecipient(ctx context.Context, id int64, recipient string) error {
+	_, err := r.db.Pool.Exec(ctx, `
+		INSERT INTO notification_outbox_recipients (outbox_id, recipient, delivered_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING
+	`, id, recipient, time.Now())
+	return err
+}
+
+func (r *OutboxRepo) MarkDelivered(ctx context.Context, id int64) error {
+	_, err := r.db.Pool.Exec(ctx, `
+		UPDATE notification_outbox
+		SET status = 'delivered', attempts = attempts + 1, delivered_at = $1
+		WHERE id = $2
+	`, time.Now(), id)
+	return err
+}
+
+// MarkFailed records a failed attempt. The message is retried at next, or
+// dead-lettered when dead is set.
+func (r *OutboxRepo) MarkFailed(ctx context.Context, id int64, reason string, next time.Time, dead bool) error {
+	status := OutboxPending
+	if dead {
+		status = OutboxDead
+	}
+	_, err := r.db.Pool.Exec(ctx, `
+		UPDATE notification_outbox
+		SET status = $1, attempts = attempts + 1, last_error = $2, next_attempt_at = $3
+		WHERE id = $4
+	`, status, reason, next, id)
+	return err
+}
+
+// Retry puts a dead or pending message back in the queue for delivery now.
+// Returns false if there is no such undelivered message.
+func (r *OutboxRepo) Retry(ctx context.Context, id int64) (bool, error) {
+	result, err := r.db.Pool.Exec(ctx, `
+		UPDATE notification_outbox
+		SET status = 'pending', attempts = 0, next_attempt_at = $1
+		WHERE id = $2 AND status <> 'delivered'
+	`, time.Now(), id)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+func (r *OutboxRepo) Get(ctx context.Context, id int64) (*OutboxMessage, error) {
+	return scanOutbox(r.db.Pool.QueryRow(ctx, `SELECT `+outboxColumns+` FROM notification_outbox WHERE id = $1`, id))
+}
+
+// GetUndelivered returns pending and dead messages, newest first. status and
+// channel narrow the list when set.
+func (r *OutboxRepo) GetUndelivered(ctx context.Context, status, channel string, limit int) ([]OutboxMessage, error) {
+	query := `
+		SELECT ` + outboxColumns + `
+		FROM notification_outbox
+		WHERE status <> 'delivered'
+			AND ($1 = '' OR status = $1)
+			AND ($2 = '' OR channel = $2)
+		ORDER BY id DESC
+		LIMIT $3
+	`
+	rows, err := r.db.Pool.Query(ctx, query, status, channel, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []OutboxMessage
+	for rows.Next() {
+		m, err := scanOutbox(rows)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, *m)
+	}
+	return messages, rows.Err()
+}
+
+// DeleteDelivered prunes messages delivered before the given time.
+func (r *OutboxRepo) DeleteDelivered(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.Pool.Exec(ctx, `DELETE FROM notification_outbox WHERE status = 'delivered' AND delivered_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
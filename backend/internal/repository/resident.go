@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fall-detection/internal/database"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Fall-risk levels, as assessed by care staff.
+const (
+	FallRiskLow    = "low"
+	FallRiskMedium = "medium"
+	FallRiskHigh   = "high"
+)
+
+var ErrInvalidFallRisk = errors.New("fall risk must be low, medium or high")
+
+func ValidFallRisk(risk string) bool {
+	return risk == FallRiskLow || risk == FallRiskMedium || risk == FallRiskHigh
+}
+
+type EmergencyContact struct {
+	Name     string `json:"name"`
+	Phone    string `json:"phone"`
+	Relation string `json:"relation"`
+}
+
+type Resident struct {
+	ID                int64
+	Name              string
+	Room              string
+	CareNotes         string
+	EmergencyContacts []EmergencyContact
+	FallRisk          string
+	BoardID           *string // Board currently worn, nil if none
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+// ResidentDetails are the fields staff can edit.
+type ResidentDetails struct {
+	Name              string
+	Room              string
+	CareNotes         string
+	EmergencyContacts []EmergencyContact
+	FallRisk          string
+}
+
+// BoardAssignment records a resident wearing a board for a period.
+type BoardAssignment struct {
+	BoardID      string
+	ResidentID   int64
+	AssignedAt   time.Time
+	UnassignedAt *time.Time // nil while current
+}
+
+type ResidentRepo struct {
+	db *database.DB
+}
+
+func NewResidentRepo(db *database.DB) *ResidentRepo {
+	return &ResidentRepo{db: db}
+}
+
+const residentSelect = `
+	SELECT r.id, r.name, r.room, r.care_notes, r.emergency_contacts, r.fall_risk, a.board_id, r.created_at, r.updated_at
+	FROM residents r
+	LEFT JOIN board_assignments a ON a.resident_id = r.id AND a.unassigned_at IS NULL
+`
+
+func scanResident(row pgx.Row) (*Resident, error) {
+	var r Resident
+	err := row.Scan(&r.ID, &r.Name, &r.Room, &r.CareNotes, &r.EmergencyContacts, &r.FallRisk, &r.BoardID, &r.CreatedAt, &r.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (r *ResidentRepo) Create(ctx context.Context, d ResidentDetails) (int64, error) {
+	query := `
+		INSERT INTO residents (name, room, care_notes, emergency_contacts, fall_risk)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+	var id int64
+	err := r.db.Pool.QueryRow(ctx, query, d.Name, d.Room, d.CareNotes, contactsOrEmpty(d.EmergencyContacts), d.FallRisk).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// Update replaces a resident's details. Returns pgx.ErrNoRows if the resident
+// does not exist.
+func (r *ResidentRepo) Update(ctx context.Context, id int64, d ResidentDetails) error {
+	query := `
+		UPDATE residents
+		SET name = $1, room = $2, care_notes = $3, emergency_contacts = $4, fall_risk = $5, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $6
+	`
+	result, err := r.db.Pool.Exec(ctx, query, d.Name, d.Room, d.CareNotes, contactsOrEmpty(d.EmergencyContacts), d.FallRisk, id)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// contactsOrEmpty stores a missing contact list as [] rather than null.
+func contactsOrEmpty(contacts []EmergencyContact) []EmergencyContact {
+	if contacts == nil {
+		return []EmergencyContact{}
+	}
+	return contacts
+}
+
+func (r *ResidentRepo) Get(ctx context.Context, id int64) (*Resident, error) {
+	return scanResident(r.db.Pool.QueryRow(ctx, residentSelect+`WHERE r.id = $1`, id))
+}
+
+// GetByBoard returns the resident currently wearing a board, or pgx.ErrNoRows
+// if it is unassigned.
+func (r *ResidentRepo) GetByBoard(ctx context.Context, boardID string) (*Resident, error) {
+	return scanResident(r.db.Pool.QueryRow(ctx, residentSelect+`WHERE a.board_id = $1`, boardID))
+}
+
+func (r *ResidentRepo) List(ctx context.Context) ([]Resident, error) {
+	return r.query(ctx, residentSelect+`ORDER BY r.name`)
+}
+
+// GetAssigned returns the residents currently wearing a board, by board ID.
+func (r *ResidentRepo) GetAssigned(ctx context.Context) (map[string]Resident, error) {
+	residents, err := r.query(ctx, residentSelect+`WHERE a.board_id IS NOT NULL`)
+	if err != nil {
+		return nil, err
+	}
+	byBoard := make(map[string]Resident, len(residents))
+	for _, resident := range residents {
+		byBoard[*resident.BoardID] = resident
+	}
+	return byBoard, nil
+}
+
+func (r *ResidentRepo) query(ctx context.Context, query string, args ...any) ([]Resident, error) {
+	rows, err := r.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var residents []Resident
+	for rows.Next() {
+		resident, err := scanResident(rows)
+		if err != nil {
+			return nil, err
+		}
+		residents = append(residents, *resident)
+	}
+	return residents, rows.Err()
+}
+
+// AssignBoard gives a resident a board, ending the board's previous
+// assignment and the resident's previous board in the same transaction.
+func (r *ResidentRepo) AssignBoard(ctx context.Context, residentID int64, boardID string) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now()
+	_, err = tx.Exec(ctx, `
+		UPDATE board_assignments SET unassigned_at = $1
+		WHERE (board_id = $2 OR resident_id = $3) AND unassigned_at IS NULL
+	`, now, boardID, residentID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO board_assignments (board_id, resident_id, assigned_at)
+		VALUES ($1, $2, $3)
+	`, boardID, residentID, now)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// UnassignBoard ends a resident's current board assignment. Returns false if
+// the resident had no board.
+func (r *ResidentRepo) UnassignBoard(ctx context.Context, residentID int64) (bool, error) {
+	result, err := r.db.Pool.Exec(ctx, `
+		UPDATE board_assignments SET unassigned_at = $1
+		WHERE resident_id = $2 AND unassigned_at IS NULL
+	`, time.Now(), residentID)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+// GetAssignments returns a resident's board history, newest first.
+func (r *ResidentRepo) GetAssignments(ctx context.Context, residentID int64) ([]BoardAssignment, error) {
+	rows, err := r.db.Pool.Query(ctx, `
+		SELECT board_id, resident_id, assigned_at, unassigned_at
+		FROM board_assignments
+		WHERE resident_id = $1
+		ORDER BY assigned_at DESC
+	`, residentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var assignments []BoardAssignment
+	for rows.Next() {
+		var a BoardAssignment
+		if err := rows.Scan(&a.BoardID, &a.ResidentID, &a.AssignedAt, &a.UnassignedAt); err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, a)
+	}
+	return assignments, rows.Err()
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"fall-detection/internal/database"
+	"fall-detection/internal/telemetry"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type SensorReading struct {
+	BoardID    string
+	ReceivedAt time.Time
+	Reading    telemetry.Reading
+}
+
+type SensorReadingRepo struct {
+	db *database.DB
+}
+
+func NewSensorReadingRepo(db *database.DB) *SensorReadingRepo {
+	return &SensorReadingRepo{db: db}
+}
+
+// readingColumns are shared by sensor_readings and fall_event_traces.
+var readingColumns = []string{
+	"received_at",
+	"accel_x", "accel_y", "accel_z",
+	"gyro_x", "gyro_y", "gyro_z",
+	"fall_status", "fall_state", "pressure", "seq",
+}
+
+const readingSelectColumns = `received_at, accel_x, accel_y, accel_z, gyro_x, gyro_y, gyro_z,
+			fall_status, fall_state, pressure, seq`
+
+// readingValues returns a row for readingColumns.
+func readingValues(s SensorReading) []any {
+	t := s.Reading
+	var seq *int64
+	if t.Version >= telemetry.Version2 {
+		n := int64(t.Seq)
+		seq = &n
+	}
+	return []any{
+		s.ReceivedAt,
+		float32(t.AccelX), float32(t.AccelY), float32(t.AccelZ),
+		float32(t.GyroX), float32(t.GyroY), float32(t.GyroZ),
+		t.FallStatus, int16(t.FallState), float32(t.Pressure), seq,
+	}
+}
+
+// scanReading scans readingSelectColumns, after any leading columns given in
+// prefix.
+func scanReading(row pgx.Row, s *SensorReading, prefix ...any) error {
+	var ax, ay, az, gx, gy, gz, pressure float32
+	var state int16
+	var seq *int64
+	dest := append(prefix, &s.ReceivedAt, &ax, &ay, &az, &gx, &gy, &gz,
+		&s.Reading.FallStatus, &state, &pressure, &seq)
+	if err := row.Scan(dest...); err != nil {
+		return err
+	}
+
+	s.Reading.AccelX, s.Reading.AccelY, s.Reading.AccelZ = float64(ax), float64(ay), float64(az)
+	s.Reading.GyroX, s.Reading.GyroY, s.Reading.GyroZ = float64(gx), float64(gy), float64(gz)
+	s.Reading.FallState = telemetry.FallState(state)
+	s.Reading.Pressure = float64(pressure)
+	s.Reading.Version = telemetry.VersionLegacy
+	if seq != nil {
+		s.Reading.Version = telemetry.Version2
+		s.Reading.Seq = uint32(*seq)
+	}
+	return nil
+}
+
+// CopyInsert bulk-loads readings with COPY, which is far cheaper than one
+// INSERT per 200ms sample per board.
+func (r *SensorReadingRepo) CopyInsert(ctx context.Context, readings []SensorReading) (int64, error) {
+	return r.db.Pool.CopyFrom(ctx,
+		pgx.Identifier{"sensor_readings"},
+		append([]string{"board_id"}, readingColumns...),
+		pgx.CopyFromSlice(len(readings), func(i int) ([]any, error) {
+			return append([]any{readings[i].BoardID}, readingValues(readings[i])...), nil
+		}),
+	)
+}
+
+// GetRange returns a board's readings received in [from, to), oldest first.
+func (r *SensorReadingRepo) GetRange(ctx context.Context, boardID string, from, to time.Time, limit int) ([]SensorReading, error) {
+	query := `
+		SELECT board_id, ` + readingSelectColumns + `
+		FROM sensor_readings
+		WHERE board_id = $1 AND received_at >= $2 AND received_at < $3
+		ORDER BY received_at
+		LIMIT $4
+	`
+	rows, err := r.db.Pool.Query(ctx, query, boardID, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var readings []SensorReading
+	for rows.Next() {
+		var s SensorReading
+		if err := scanReading(rows, &s, &s.BoardID); err != nil {
+			return nil, err
+		}
+		readings = append(readings, s)
+	}
+	return readings, rows.Err()
+}
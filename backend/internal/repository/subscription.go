@@ -3,8 +3,44 @@ package repository
 import (
 	"context"
 	"fall-detection/internal/database"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// How a subscription was allowed.
+const (
+	ApprovalLegacy      = "legacy"       // Made before subscriptions needed authorizing
+	ApprovalPairingCode = "pairing_code" // The board's pairing code was given
+	ApprovalInvite      = "invite"       // A one-time invite link was used
+	ApprovalAdmin       = "admin"        // An admin approved a request
 )
 
+// Approval records who allowed a subscription. By is the approving admin,
+// or whoever created the invite, and empty for pairing codes.
+type Approval struct {
+	Method   string
+	By       string
+	InviteID *int64
+}
+
+type Subscription struct {
+	ChatID         int64
+	BoardID        string
+	FirstName      string
+	Username       string
+	ApprovalMethod string
+	ApprovedBy     string
+	InviteID       *int64
+	ApprovedAt     *time.Time // nil for legacy subscriptions
+	CreatedAt      time.Time
+}
+
+// execer is satisfied by both the pool and a transaction.
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
 type SubscriptionRepo struct {
 	db *database.DB
 }
@@ -13,16 +49,50 @@ func NewSubscriptionRepo(db *database.DB) *SubscriptionRepo {
 	return &SubscriptionRepo{db: db}
 }
 
-func (r *SubscriptionRepo) CreateSubscription(ctx context.Context, chatID int64, boardID string, firstName string, username string) error {
+// CreateSubscription subscribes a chat to a board, recording how it was
+// allowed to. An existing subscription keeps its original approval, unless
+// it is a legacy one being authorized now.
+func (r *SubscriptionRepo) CreateSubscription(ctx context.Context, chatID int64, boardID string, firstName string, username string, approval Approval) error {
+	return createSubscription(ctx, r.db.Pool, chatID, boardID, firstName, username, approval)
+}
+
+func createSubscription(ctx context.Context, db execer, chatID int64, boardID string, firstName string, username string, approval Approval) error {
 	query := `
-		INSERT INTO subscriptions (chat_id,board_id,first_name,username)	
-		VALUES ($1,$2,$3,$4)
-		ON CONFLICT (chat_id,board_id) DO NOTHING
+		INSERT INTO subscriptions (chat_id, board_id, first_name, username, approval_method, approved_by, invite_id, approved_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (chat_id, board_id) DO UPDATE SET
+			approval_method = EXCLUDED.approval_method,
+			approved_by = EXCLUDED.approved_by,
+			invite_id = EXCLUDED.invite_id,
+			approved_at = EXCLUDED.approved_at
+		WHERE subscriptions.approval_method = 'legacy'
 	`
-
-	_, err := r.db.Pool.Exec(ctx, query, chatID, boardID, firstName, username)
+	_, err := db.Exec(ctx, query, chatID, boardID, firstName, username, approval.Method, approval.By, approval.InviteID, time.Now())
 	return err
 }
+
+// GetSubscription returns a chat's subscription to a board, or
+// pgx.ErrNoRows if it is not subscribed.
+func (r *SubscriptionRepo) GetSubscription(ctx context.Context, chatID int64, boardID string) (*Subscription, error) {
+	query := `
+		SELECT chat_id, board_id, first_name, username, approval_method, approved_by, invite_id, approved_at, created_at
+		FROM subscriptions
+		WHERE chat_id = $1 AND board_id = $2
+	`
+	var s Subscription
+	err := r.db.Pool.QueryRow(ctx, query, chatID, boardID).Scan(&s.ChatID, &s.BoardID, &s.FirstName, &s.Username, &s.ApprovalMethod, &s.ApprovedBy, &s.InviteID, &s.ApprovedAt, &s.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Authorized reports whether the subscription was approved with a pairing
+// code, an invite or by an admin rather than grandfathered in.
+func (s *Subscription) Authorized() bool {
+	return s.ApprovalMethod != ApprovalLegacy
+}
+
 func (r *SubscriptionRepo) Unsubscribe(ctx context.Context, chatID int64, boardID string) error {
 	query := `
 		DELETE FROM subscriptions WHERE chat_id = $1 AND board_id = $2
@@ -56,9 +126,14 @@ func (r *SubscriptionRepo) GetBoardsSubscribedTo(ctx context.Context, chatID int
 	return boards, nil
 }
 
+// GetSubscribers returns the chats that receive a board's alerts. Legacy
+// subscriptions are left out until they are authorized, since anyone could
+// subscribe to any board before pairing codes existed; /myboards tells their
+// holders how to authorize them.
 func (r *SubscriptionRepo) GetSubscribers(ctx context.Context, boardID string) ([]int64, []string, []string, error) {
 	query := `
-		SELECT chat_id, first_name, username FROM subscriptions WHERE board_id = $1
+		SELECT chat_id, first_name, username FROM subscriptions
+		WHERE board_id = $1 AND approval_method <> 'legacy'
 	`
 
 	rows, err := r.db.Pool.Query(ctx, query, boardID)
@@ -85,3 +160,29 @@ func (r *SubscriptionRepo) GetSubscribers(ctx context.Context, boardID string) (
 	return chatIDs, firstNames, usernames, rows.Err()
 
 }
+
+// List returns a board's subscriptions with who approved them, oldest first.
+func (r *SubscriptionRepo) List(ctx context.Context, boardID string) ([]Subscription, error) {
+	query := `
+		SELECT chat_id, board_id, first_name, username, approval_method, approved_by, invite_id, approved_at, created_at
+		FROM subscriptions
+		WHERE board_id = $1
+		ORDER BY created_at
+	`
+	rows, err := r.db.Pool.Query(ctx, query, boardID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subscriptions []Subscription
+	for rows.Next() {
+		var s Subscription
+		err := rows.Scan(&s.ChatID, &s.BoardID, &s.FirstName, &s.Username, &s.ApprovalMethod, &s.ApprovedBy, &s.InviteID, &s.ApprovedAt, &s.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, s)
+	}
+	return subscriptions, rows.Err()
+}
@@ -0,0 +1,271 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Subscription request states.
+const (
+	RequestPending  = "pending"
+	RequestApproved = "approved"
+	RequestRejected = "rejected"
+)
+
+// ErrInviteInvalid is returned for invites that do not exist, have expired
+// or were already used.
+var ErrInviteInvalid = errors.New("invite is invalid, expired or already used")
+
+// SubscriptionAccess is how people may subscribe to a board. Without a
+// pairing code or approval mode only invites work.
+type SubscriptionAccess struct {
+	BoardID          string
+	PairingCode      *string
+	ApprovalRequired bool
+	UpdatedAt        *time.Time // nil if the board was never configured
+}
+
+type SubscriptionInvite struct {
+	ID           int64
+	BoardID      string
+	Token        string
+	Note         string
+	CreatedBy    string
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+	UsedAt       *time.Time
+	UsedByChatID *int64
+}
+
+type SubscriptionRequest struct {
+	ID          int64
+	ChatID      int64
+	BoardID     string
+	FirstName   string
+	Username    string
+	Status      string
+	RequestedAt time.Time
+	DecidedAt   *time.Time
+	DecidedBy   *string
+}
+
+// GetAccess returns a board's access settings, or the defaults if it has
+// none.
+func (r *SubscriptionRepo) GetAccess(ctx context.Context, boardID string) (SubscriptionAccess, error) {
+	query := `
+		SELECT pairing_code, approval_required, updated_at
+		FROM subscription_access
+		WHERE board_id = $1
+	`
+	a := SubscriptionAccess{BoardID: boardID}
+	err := r.db.Pool.QueryRow(ctx, query, boardID).Scan(&a.PairingCode, &a.ApprovalRequired, &a.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return a, nil
+	}
+	return a, err
+}
+
+// SetPairingCode replaces a board's pairing code. A nil code turns pairing
+// codes off for the board.
+func (r *SubscriptionRepo) SetPairingCode(ctx context.Context, boardID string, code *string) error {
+	query := `
+		INSERT INTO subscription_access (board_id, pairing_code)
+		VALUES ($1, $2)
+		ON CONFLICT (board_id) DO UPDATE SET
+			pairing_code = EXCLUDED.pairing_code,
+			updated_at = CURRENT_TIMESTAMP
+	`
+	_, err := r.db.Pool.Exec(ctx, query, boardID, code)
+	return err
+}
+
+func (r *SubscriptionRepo) SetApprovalRequired(ctx context.Context, boardID string, required bool) error {
+	query := `
+		INSERT INTO subscription_access (board_id, approval_required)
+		VALUES ($1, $2)
+		ON CONFLICT (board_id) DO UPDATE SET
+			approval_required = EXCLUDED.approval_required,
+			updated_at = CURRENT_TIMESTAMP
+	`
+	_, err := r.db.Pool.Exec(ctx, query, boardID, required)
+	return err
+}
+
+const inviteColumns = `id, board_id, token, note, created_by, created_at, expires_at, used_at, used_by_chat_id`
+
+func scanInvite(row pgx.Row) (*SubscriptionInvite, error) {
+	var i SubscriptionInvite
+	err := row.Scan(&i.ID, &i.BoardID, &i.Token, &i.Note, &i.CreatedBy, &i.CreatedAt, &i.ExpiresAt, &i.UsedAt, &i.UsedByChatID)
+	if err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
+
+func (r *SubscriptionRepo) CreateInvite(ctx context.Context, boardID, token, note, createdBy string, expiresAt time.Time) (*SubscriptionInvite, error) {
+	query := `
+		INSERT INTO subscription_invites (board_id, token, note, created_by, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + inviteColumns
+	return scanInvite(r.db.Pool.QueryRow(ctx, query, boardID, token, note, createdBy, time.Now(), expiresAt))
+}
+
+// GetInvites returns a board's invites, newest first.
+func (r *SubscriptionRepo) GetInvites(ctx context.Context, boardID string) ([]SubscriptionInvite, error) {
+	query := `
+		SELECT ` + inviteColumns + `
+		FROM subscription_invites
+		WHERE board_id = $1
+		ORDER BY created_at DESC
+	`
+	rows, err := r.db.Pool.Query(ctx, query, boardID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invites []SubscriptionInvite
+	for rows.Next() {
+		i, err := scanInvite(rows)
+		if err != nil {
+			return nil, err
+		}
+		invites = append(invites, *i)
+	}
+	return invites, rows.Err()
+}
+
+// DeleteInvite revokes an unused invite. Returns false if the board has no
+// such unused invite.
+func (r *SubscriptionRepo) DeleteInvite(ctx context.Context, boardID string, id int64) (bool, error) {
+	result, err := r.db.Pool.Exec(ctx, `DELETE FROM subscription_invites WHERE id = $1 AND board_id = $2 AND used_at IS NULL`, id, boardID)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+// RedeemInvite uses up an invite and subscribes the chat to its board in
+// one transaction. Returns ErrInviteInvalid if the invite cannot be used.
+func (r *SubscriptionRepo) RedeemInvite(ctx context.Context, token string, chatID int64, firstName, username string) (*SubscriptionInvite, error) {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now()
+	query := `
+		UPDATE subscription_invites
+		SET used_at = $1, used_by_chat_id = $2
+		WHERE token = $3 AND used_at IS NULL AND expires_at > $1
+		RETURNING ` + inviteColumns
+	invite, err := scanInvite(tx.QueryRow(ctx, query, now, chatID, token))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrInviteInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	approval := Approval{Method: ApprovalInvite, By: invite.CreatedBy, InviteID: &invite.ID}
+	if err := createSubscription(ctx, tx, chatID, invite.BoardID, firstName, username, approval); err != nil {
+		return nil, err
+	}
+	return invite, tx.Commit(ctx)
+}
+
+// CreateRequest files a request to subscribe for an admin to decide on.
+// Returns false if the chat already has a pending request for the board.
+func (r *SubscriptionRepo) CreateRequest(ctx context.Context, chatID int64, boardID, firstName, username string) (bool, error) {
+	query := `
+		INSERT INTO subscription_requests (chat_id, board_id, first_name, username, requested_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (chat_id, board_id) WHERE status = 'pending' DO NOTHING
+	`
+	result, err := r.db.Pool.Exec(ctx, query, chatID, boardID, firstName, username, time.Now())
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+const requestColumns = `id, chat_id, board_id, first_name, username, status, requested_at, decided_at, decided_by`
+
+func scanRequest(row pgx.Row) (*SubscriptionRequest, error) {
+	var q SubscriptionRequest
+	err := row.Scan(&q.ID, &q.ChatID, &q.BoardID, &q.FirstName, &q.Username, &q.Status, &q.RequestedAt, &q.DecidedAt, &q.DecidedBy)
+	if err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+// GetRequests returns requests with the given status, oldest first. boardID
+// narrows the list when set.
+func (r *SubscriptionRepo) GetRequests(ctx context.Context, status, boardID string) ([]SubscriptionRequest, error) {
+	query := `
+		SELECT ` + requestColumns + `
+		FROM subscription_requests
+		WHERE status = $1 AND ($2 = '' OR board_id = $2)
+		ORDER BY requested_at
+	`
+	rows, err := r.db.Pool.Query(ctx, query, status, boardID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var requests []SubscriptionRequest
+	for rows.Next() {
+		q, err := scanRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, *q)
+	}
+	return requests, rows.Err()
+}
+
+// ApproveRequest approves a pending request and subscribes its chat in one
+// transaction. Returns pgx.ErrNoRows if there is no such pending request.
+func (r *SubscriptionRepo) ApproveRequest(ctx context.Context, id int64, admin string) (*SubscriptionRequest, error) {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	request, err := decideRequest(ctx, tx, id, RequestApproved, admin)
+	if err != nil {
+		return nil, err
+	}
+	approval := Approval{Method: ApprovalAdmin, By: admin}
+	if err := createSubscription(ctx, tx, request.ChatID, request.BoardID, request.FirstName, request.Username, approval); err != nil {
+		return nil, err
+	}
+	return request, tx.Commit(ctx)
+}
+
+// RejectRequest rejects a pending request. Returns pgx.ErrNoRows if there is
+// no such pending request.
+func (r *SubscriptionRepo) RejectRequest(ctx context.Context, id int64, admin string) (*SubscriptionRequest, error) {
+	return decideRequest(ctx, r.db.Pool, id, RequestRejected, admin)
+}
+
+// rowQuerier is satisfied by both the pool and a transaction.
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func decideRequest(ctx context.Context, db rowQuerier, id int64, status, admin string) (*SubscriptionRequest, error) {
+	query := `
+		UPDATE subscription_requests
+		SET status = $1, decided_at = $2, decided_by = $3
+		WHERE id = $4 AND status = 'pending'
+		RETURNING ` + requestColumns
+	return scanRequest(db.QueryRow(ctx, query, status, time.Now(), admin, id))
+}
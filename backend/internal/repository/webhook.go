@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"fall-detection/internal/database"
+	"time"
+)
+
+// Webhook is a URL that receives a board's alert events as signed JSON.
+type Webhook struct {
+	ID        int64
+	BoardID   string
+	URL       string
+	Secret    string
+	Enabled   bool
+	CreatedAt time.Time
+}
+
+// WebhookDelivery is one attempt at POSTing an event to a webhook.
+type WebhookDelivery struct {
+	ID          int64
+	WebhookID   int64
+	DeliveryID  string
+	EventType   string
+	FallEventID *int64
+	Attempt     int
+	StatusCode  *int
+	Error       *string
+	Succeeded   bool
+	Duration    time.Duration
+	AttemptedAt time.Time
+}
+
+type WebhookRepo struct {
+	db *database.DB
+}
+
+func NewWebhookRepo(db *database.DB) *WebhookRepo {
+	return &WebhookRepo{db: db}
+}
+
+const webhookColumns = `id, board_id, url, secret, enabled, created_at`
+
+func (r *WebhookRepo) queryWebhooks(ctx context.Context, query string, args ...any) ([]Webhook, error) {
+	rows, err := r.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var webhooks []Webhook
+	for rows.Next() {
+		var w Webhook
+		if err := rows.Scan(&w.ID, &w.BoardID, &w.URL, &w.Secret, &w.Enabled, &w.CreatedAt); err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, w)
+	}
+	return webhooks, rows.Err()
+}
+
+// GetByBoard returns every webhook of a board, enabled or not.
+func (r *WebhookRepo) GetByBoard(ctx context.Context, boardID string) ([]Webhook, error) {
+	return r.queryWebhooks(ctx, `SELECT `+webhookColumns+` FROM webhooks WHERE board_id = $1 ORDER BY id`, boardID)
+}
+
+// GetEnabled returns the webhooks that should receive a board's events.
+func (r *WebhookRepo) GetEnabled(ctx context.Context, boardID string) ([]Webhook, error) {
+	return r.queryWebhooks(ctx, `SELECT `+webhookColumns+` FROM webhooks WHERE board_id = $1 AND enabled ORDER BY id`, boardID)
+}
+
+func (r *WebhookRepo) Get(ctx context.Context, id int64) (*Webhook, error) {
+	var w Webhook
+	err := r.db.Pool.QueryRow(ctx, `SELECT `+webhookColumns+` FROM webhooks WHERE id = $1`, id).
+		Scan(&w.ID, &w.BoardID, &w.URL, &w.Secret, &w.Enabled, &w.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+func (r *WebhookRepo) Create(ctx context.Context, boardID, url, secret string, enabled bool) (int64, error) {
+	query := `
+		INSERT INTO webhooks (board_id, url, secret, enabled, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+	var id int64
+	err := r.db.Pool.QueryRow(ctx, query, boardID, url, secret, enabled, time.Now()).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// Update changes a webhook's URL and whether it is enabled. Returns false if
+// the webhook does not exist.
+func (r *WebhookRepo) Update(ctx context.Context, id int64, url string, enabled bool) (bool, error) {
+	result, err := r.db.Pool.Exec(ctx, `UPDATE webhooks SET url = $1, enabled = $2 WHERE id = $3`, url, enabled, id)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+// Delete removes a webhook and its delivery log. Returns false if the webhook
+// does not exist.
+func (r *WebhookRepo) Delete(ctx context.Context, id int64) (bool, error) {
+	result, err := r.db.Pool.Exec(ctx, `DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+func (r *WebhookRepo) RecordDelivery(ctx context.Context, d WebhookDelivery) error {
+	query := `
+		INSERT INTO webhook_deliveries
+			(webhook_id, delivery_id, event_type, fall_event_id, attempt, status_code, error, succeeded, duration_ms, attempted_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+	_, err := r.db.Pool.Exec(ctx, query, d.WebhookID, d.DeliveryID, d.EventType, d.FallEventID, d.Attempt,
+		d.StatusCode, d.Error, d.Succeeded, d.Duration.Milliseconds(), d.AttemptedAt)
+	return err
+}
+
+// GetDeliveries returns a webhook's most recent delivery attempts, newest
+// first.
+func (r *WebhookRepo) GetDeliveries(ctx context.Context, webhookID int64, limit int) ([]WebhookDelivery, error) {
+	query := `
+		SELECT id, webhook_id, delivery_id, event_type, fall_event_id, attempt, status_code, error, succeeded, duration_ms, attempted_at
+		FROM webhook_deliveries
+		WHERE webhook_id = $1
+		ORDER BY attempted_at DESC, id DESC
+		LIMIT $2
+	`
+	rows, err := r.db.Pool.Query(ctx, query, webhookID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []WebhookDelivery
+	for rows.Next() {
+		var d WebhookDelivery
+		var durationMs int64
+		err := rows.Scan(&d.ID, &d.WebhookID, &d.DeliveryID, &d.EventType, &d.FallEventID, &d.Attempt,
+			&d.StatusCode, &d.Error, &d.Succeeded, &durationMs, &d.AttemptedAt)
+		if err != nil {
+			return nil, err
+		}
+		d.Duration = time.Duration(durationMs) * time.Millisecond
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
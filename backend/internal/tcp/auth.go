@@ -0,0 +1,127 @@
+package tcp
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fall-detection/internal/telemetry"
+	"fmt"
+	"log"
+	"net"
+	"time"
+)
+
+const (
+	nonceSize   = 32
+	authTimeout = 5 * time.Second
+)
+
+var errUnauthenticated = errors.New("board failed authentication")
+
+// authenticate identifies the board on a new connection, either from a
+// verified TLS client certificate or by running the handshake described in
+// telemetry/handshake.go, and returns the verified board ID. Boards that do
+// neither are only admitted when RequireAuth is off; their first line is
+// returned as pending so the caller can process it as telemetry.
+func (s *TCPServer) authenticate(conn net.Conn, reader *bufio.Reader) (boardID string, pending string, err error) {
+	boardID, err = certBoardID(conn)
+	if err != nil {
+		log.Printf("[TCP SERVER] Rejected TLS connection from %s: %v", conn.RemoteAddr(), err)
+		return "", "", errUnauthenticated
+	}
+	if boardID != "" {
+		log.Printf("[TCP SERVER] Board %s authenticated by client certificate from %s", boardID, conn.RemoteAddr())
+		return boardID, "", nil
+	}
+
+	line, err := readLine(conn, reader)
+	for err == nil && line == "" {
+		line, err = readLine(conn, reader)
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	if !telemetry.IsHello(line) {
+		if s.RequireAuth {
+			log.Printf("[TCP SERVER] Rejected unauthenticated connection from %s", conn.RemoteAddr())
+			return "", "", errUnauthenticated
+		}
+		log.Printf("[TCP SERVER] Accepting unauthenticated connection from %s (board auth not required)", conn.RemoteAddr())
+		return "", line, nil
+	}
+
+	boardID, err = telemetry.ParseHello(line)
+	if err != nil {
+		log.Printf("[TCP SERVER] Rejected malformed hello from %s: %v", conn.RemoteAddr(), err)
+		return "", "", errUnauthenticated
+	}
+
+	// Always issue a challenge, even for unknown boards, so a scanner cannot
+	// tell which board IDs are provisioned.
+	var secret []byte
+	if s.Credentials != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), authTimeout)
+		secret, err = s.Credentials.GetSecret(ctx, "board"+boardID)
+		cancel()
+		if err != nil {
+			log.Printf("[TCP SERVER] No credentials for board %s: %v", boardID, err)
+			secret = nil
+		}
+	}
+
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", "", fmt.Errorf("generate nonce: %w", err)
+	}
+	if err := writeLine(conn, telemetry.ChallengeLine(nonce)); err != nil {
+		return "", "", err
+	}
+
+	line, err = readLine(conn, reader)
+	if err != nil {
+		return "", "", err
+	}
+	signature, err := telemetry.ParseAuth(line)
+	if err != nil || secret == nil || !telemetry.VerifyChallenge(secret, nonce, boardID, signature) {
+		log.Printf("[TCP SERVER] Authentication failed for board %s from %s", boardID, conn.RemoteAddr())
+		writeLine(conn, telemetry.AuthDenied)
+		return "", "", errUnauthenticated
+	}
+
+	if err := writeLine(conn, telemetry.AuthOK); err != nil {
+		return "", "", err
+	}
+	log.Printf("[TCP SERVER] Board %s authenticated from %s", boardID, conn.RemoteAddr())
+	return boardID, "", nil
+}
+
+// admitUnauthenticated decides whether a connection that did not
+// authenticate may stream as boardID. Boards that have been provisioned with
+// a secret must always authenticate, even when RequireAuth is off, and a
+// board that authenticated is never displaced by one that did not.
+func (s *TCPServer) admitUnauthenticated(boardID string) error {
+	s.BoardsMu.Lock()
+	b := s.Boards[boardID]
+	connected := b != nil && b.Authenticated && b.DataSocket != nil
+	s.BoardsMu.Unlock()
+	if connected {
+		return errUnauthenticated
+	}
+
+	if s.Credentials == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), authTimeout)
+	defer cancel()
+	provisioned, err := s.Credentials.HasSecret(ctx, "board"+boardID)
+	if err != nil {
+		// Fail closed; the board reconnects and is checked again
+		return fmt.Errorf("check credentials for board %s: %w", boardID, err)
+	}
+	if provisioned {
+		return errUnauthenticated
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package tcp
+
+import (
+	"errors"
+	"fall-detection/internal/telemetry"
+	"fmt"
+	"log"
+	"net"
+	"time"
+)
+
+const commandTimeout = 5 * time.Second
+
+// remoteResetWindow is how long after a RESET_FALL command the board clearing
+// its fall is put down to the command rather than an NFC tap.
+const remoteResetWindow = 15 * time.Second
+
+var (
+	ErrBoardOffline    = errors.New("board is not connected")
+	ErrCommandTimeout  = errors.New("board did not acknowledge command in time")
+	ErrCommandRejected = errors.New("board rejected command")
+)
+
+// pendingCommand is a command waiting for its ack.
+type pendingCommand struct {
+	boardID string
+	ack     chan telemetry.Ack
+}
+
+// SendCommand writes a command to a connected board and waits for it to be
+// acknowledged. The command ID is assigned here. A negative ack is returned
+// together with ErrCommandRejected so callers can show the board's reason.
+func (s *TCPServer) SendCommand(boardID string, cmd telemetry.Command) (telemetry.Ack, error) {
+	cmd.ID = s.nextCommandID.Add(1)
+	pending := &pendingCommand{boardID: boardID, ack: make(chan telemetry.Ack, 1)}
+
+	s.PendingMu.Lock()
+	s.Pending[cmd.ID] = pending
+	s.PendingMu.Unlock()
+
+	defer func() {
+		s.PendingMu.Lock()
+		delete(s.Pending, cmd.ID)
+		s.PendingMu.Unlock()
+	}()
+
+	if err := s.writeToBoard(boardID, cmd.Encode()); err != nil {
+		return telemetry.Ack{}, err
+	}
+	log.Printf("[TCP SERVER] Sent command #%d %s to board %s", cmd.ID, cmd.Type, boardID)
+
+	select {
+	case ack := <-pending.ack:
+		if !ack.OK {
+			return ack, fmt.Errorf("%w: %s", ErrCommandRejected, ack.Detail)
+		}
+		return ack, nil
+	case <-time.After(commandTimeout):
+		log.Printf("[TCP SERVER] Command #%d to board %s timed out", cmd.ID, boardID)
+		return telemetry.Ack{}, ErrCommandTimeout
+	}
+}
+
+// remoteReset is a RESET_FALL sent from Telegram or the HTTP API.
+type remoteReset struct {
+	by *int64 // Telegram user who sent it, nil from the HTTP API
+	at time.Time
+}
+
+// ResetFall clears a board's fall remotely. The board reports the cleared
+// fall like an NFC tap, so who asked is kept for TakeRemoteReset.
+func (s *TCPServer) ResetFall(boardID string, by *int64) (telemetry.Ack, error) {
+	s.resetsMu.Lock()
+	if s.resets == nil {
+		s.resets = make(map[string]remoteReset)
+	}
+	s.resets[boardID] = remoteReset{by: by, at: time.Now()}
+	s.resetsMu.Unlock()
+
+	ack, err := s.SendCommand(boardID, telemetry.Command{Type: telemetry.CmdResetFall})
+	if errors.Is(err, ErrBoardOffline) || errors.Is(err, ErrCommandRejected) {
+		// The board did not reset, so a later NFC tap must not be
+		// mistaken for this command. After a timeout it may still have.
+		s.TakeRemoteReset(boardID)
+	}
+	return ack, err
+}
+
+// TakeRemoteReset reports whether a board's fall was just cleared by
+// ResetFall rather than at the board, and who sent the command.
+func (s *TCPServer) TakeRemoteReset(boardID string) (by *int64, ok bool) {
+	s.resetsMu.Lock()
+	defer s.resetsMu.Unlock()
+
+	reset, ok := s.resets[boardID]
+	delete(s.resets, boardID)
+	if !ok || time.Since(reset.at) > remoteResetWindow {
+		return nil, false
+	}
+	return reset.by, true
+}
+
+// handleAck delivers an ack line from a board to the waiting SendCommand call.
+func (s *TCPServer) handleAck(boardID string, line string) {
+	ack, err := telemetry.ParseAck(line)
+	if err != nil {
+		log.Printf("[TCP SERVER] Invalid ack from board %s: %v", boardID, err)
+		return
+	}
+
+	s.PendingMu.Lock()
+	pending := s.Pending[ack.ID]
+	s.PendingMu.Unlock()
+
+	if pending == nil || pending.boardID != boardID {
+		log.Printf("[TCP SERVER] Unexpected ack #%d from board %s", ack.ID, boardID)
+		return
+	}
+
+	select {
+	case pending.ack <- ack:
+	default:
+		// Duplicate ack, the first one already woke the sender
+	}
+}
+
+// writeToBoard sends a line on the board's current connection. Writes are
+// serialised because commands can be issued from HTTP and Telegram at once.
+func (s *TCPServer) writeToBoard(boardID string, line string) error {
+	s.BoardsMu.RLock()
+	board := s.Boards[boardID]
+	var conn net.Conn
+	if board != nil {
+		conn = board.DataSocket
+	}
+	s.BoardsMu.RUnlock()
+
+	if conn == nil {
+		return ErrBoardOffline
+	}
+
+	board.writeMu.Lock()
+	defer board.writeMu.Unlock()
+	return writeLine(conn, line)
+}
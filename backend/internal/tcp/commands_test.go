@@ -0,0 +1,44 @@
+package tcp
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTakeRemoteReset(t *testing.T) {
+	s := NewTCPServer("", nil, nil, false)
+	user := int64(42)
+
+	s.resets = map[string]remoteReset{
+		"1": {by: &user, at: time.Now()},
+		"2": {by: nil, at: time.Now()},
+		"3": {by: &user, at: time.Now().Add(-remoteResetWindow - time.Second)},
+	}
+
+	if by, ok := s.TakeRemoteReset("1"); !ok || by == nil || *by != user {
+		t.Errorf("board 1: TakeRemoteReset() = %v, %v, want user %d", by, ok, user)
+	}
+	if _, ok := s.TakeRemoteReset("1"); ok {
+		t.Error("board 1: reset reported twice")
+	}
+	if by, ok := s.TakeRemoteReset("2"); !ok || by != nil {
+		t.Errorf("board 2: TakeRemoteReset() = %v, %v, want an HTTP reset", by, ok)
+	}
+	if _, ok := s.TakeRemoteReset("3"); ok {
+		t.Error("board 3: reset outside the window was reported")
+	}
+}
+
+func TestResetFallOfflineBoard(t *testing.T) {
+	s := NewTCPServer("", nil, nil, false)
+	user := int64(42)
+
+	if _, err := s.ResetFall("1", &user); !errors.Is(err, ErrBoardOffline) {
+		t.Fatalf("ResetFall() error = %v, want ErrBoardOffline", err)
+	}
+	// A later NFC tap must not be credited to the failed command
+	if _, ok := s.TakeRemoteReset("1"); ok {
+		t.Error("failed reset was remembered")
+	}
+}
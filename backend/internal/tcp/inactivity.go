@@ -0,0 +1,53 @@
+package tcp
+
+import (
+	"context"
+	"errors"
+	"fall-detection/internal/detection"
+	"fall-detection/internal/mqtt"
+	"fall-detection/internal/telemetry"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// LoadInactivitySettings applies a board's stored inactivity settings to the
+// monitor. Boards without stored settings use the monitor's defaults.
+func (s *TCPServer) LoadInactivitySettings(boardID string) error {
+	if s.Inactivity == nil || s.InactivitySettings == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	settings, err := s.InactivitySettings.Get(ctx, "board"+boardID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		log.Printf("[TCP SERVER] Failed to load inactivity settings for board %s: %v", boardID, err)
+		return err
+	}
+	s.Inactivity.SetSettings(boardID, *settings)
+	return nil
+}
+
+// checkInactivity raises and clears prolonged inactivity alerts through the
+// same MQTT alerts topic as falls.
+func (s *TCPServer) checkInactivity(reading telemetry.Reading, now time.Time) {
+	if s.Inactivity == nil {
+		return
+	}
+
+	alertTopic := "fall-detection/" + reading.Topic() + "/alerts"
+	switch s.Inactivity.Observe(reading, now) {
+	case detection.InactivityStarted:
+		log.Printf("[TCP Server] Prolonged inactivity on %s, publishing INACTIVITY", reading.BoardID)
+		mqtt.Publish(s.Publisher, alertTopic, "INACTIVITY")
+	case detection.InactivityEnded:
+		log.Printf("[TCP Server] Movement resumed on %s, publishing ACTIVITY_RESUMED", reading.BoardID)
+		mqtt.Publish(s.Publisher, alertTopic, "ACTIVITY_RESUMED")
+	}
+}
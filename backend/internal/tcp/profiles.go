@@ -0,0 +1,54 @@
+package tcp
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// PushProfile sends a board its stored detection profile and records the
+// acknowledged version. Boards without a stored profile keep their firmware
+// defaults. It is called on every connect, since the firmware keeps pushed
+// thresholds in RAM only, and whenever the profile is edited.
+func (s *TCPServer) PushProfile(boardID string) error {
+	if s.Profiles == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	profile, err := s.Profiles.Get(ctx, "board"+boardID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.SendCommand(boardID, profile.Thresholds.Command(profile.Version)); err != nil {
+		log.Printf("[TCP SERVER] Board %s did not apply profile v%d: %v", boardID, profile.Version, err)
+		return err
+	}
+
+	s.BoardsMu.Lock()
+	// A push for an older version can finish after a newer one when the
+	// profile is edited while the board connects
+	if board := s.Boards[boardID]; board != nil && profile.Version > board.ProfileVersion {
+		board.ProfileVersion = profile.Version
+	}
+	s.BoardsMu.Unlock()
+
+	if s.Verifier != nil {
+		s.Verifier.SetThresholds(boardID, profile.Thresholds)
+	}
+
+	log.Printf("[TCP SERVER] Board %s applied profile v%d", boardID, profile.Version)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return s.Profiles.MarkAcked(ctx, "board"+boardID, profile.Version)
+}
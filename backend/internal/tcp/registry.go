@@ -0,0 +1,42 @@
+package tcp
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+var errBoardDisabled = errors.New("board is disabled")
+
+// registerBoard records a connecting board in the registry and reports
+// whether it may stream. Registry errors never keep a board offline.
+func (s *TCPServer) registerBoard(boardID, firmware string) bool {
+	if s.Registry == nil {
+		return true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	board, err := s.Registry.RecordSeen(ctx, "board"+boardID, firmware, time.Now())
+	if err != nil {
+		log.Printf("[TCP SERVER] Failed to record board %s in registry: %v", boardID, err)
+		return true
+	}
+	return board.Enabled
+}
+
+// recordLastSeen stores when a disconnecting board last sent data.
+func (s *TCPServer) recordLastSeen(boardID string, lastSeen time.Time) {
+	if s.Registry == nil || lastSeen.IsZero() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := s.Registry.RecordSeen(ctx, "board"+boardID, "", lastSeen); err != nil {
+		log.Printf("[TCP SERVER] Failed to record last seen for board %s: %v", boardID, err)
+	}
+}
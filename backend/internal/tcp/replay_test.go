@@ -0,0 +1,122 @@
+package tcp
+
+import (
+	"fall-detection/internal/capture"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	pahomqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// recordingClient is an MQTT client that keeps what is published to it.
+// Other methods panic, as the TCP server only publishes.
+type recordingClient struct {
+	pahomqtt.Client
+
+	mu        sync.Mutex
+	published []publishedMessage
+}
+
+type publishedMessage struct {
+	topic   string
+	payload string
+}
+
+func (c *recordingClient) Publish(topic string, qos byte, retained bool, payload interface{}) pahomqtt.Token {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.published = append(c.published, publishedMessage{topic: topic, payload: payload.(string)})
+	return &pahomqtt.DummyToken{}
+}
+
+// topic returns the payloads published to a topic, in order.
+func (c *recordingClient) topic(topic string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var payloads []string
+	for _, m := range c.published {
+		if m.topic == topic {
+			payloads = append(payloads, m.payload)
+		}
+	}
+	return payloads
+}
+
+// replayCapture feeds a capture from testdata into a fresh server over an
+// in-memory connection and waits for the server to finish the session.
+func replayCapture(t *testing.T, name string) (*TCPServer, *recordingClient) {
+	t.Helper()
+
+	records, err := capture.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &recordingClient{}
+	s := NewTCPServer("", client, nil, false)
+
+	board, server := net.Pipe()
+	done := make(chan error, 1)
+	go func() { done <- s.handleConnection(server) }()
+
+	if err := capture.Replay(board, records, 0); err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	board.Close()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not finish the session")
+	}
+	return s, client
+}
+
+// TestReplayFallThenNFCReset is a board confirming a fall and a caregiver
+// clearing it with the NFC tag. The alert must be raised exactly once, when
+// the fall status first goes up, and cleared once.
+func TestReplayFallThenNFCReset(t *testing.T) {
+	_, client := replayCapture(t, "fall-then-nfc-reset.jsonl")
+
+	sensors := client.topic("fall-detection/board1/sensors")
+	if len(sensors) != 24 {
+		t.Errorf("published %d readings, want 24", len(sensors))
+	}
+
+	alerts := client.topic("fall-detection/board1/alerts")
+	if len(alerts) != 2 {
+		t.Fatalf("alerts = %q, want a fall then BOARD_RESET", alerts)
+	}
+	if !strings.HasSuffix(alerts[0], ",1,1,3,1013.30") {
+		t.Errorf("fall alert = %q, want the first confirmed reading", alerts[0])
+	}
+	if alerts[1] != "BOARD_RESET" {
+		t.Errorf("second alert = %q, want BOARD_RESET", alerts[1])
+	}
+}
+
+// TestReplayResentFrames is a v2 board whose Wi-Fi module resent a frame,
+// reordered two and sent a corrupted line. The resent frame must not reach
+// MQTT and the stats must account for every frame.
+func TestReplayResentFrames(t *testing.T) {
+	s, client := replayCapture(t, "resent-frames.jsonl")
+
+	if got := len(client.topic("fall-detection/board2/sensors")); got != 7 {
+		t.Errorf("published %d readings, want 7", got)
+	}
+
+	stats, ok := s.SequenceStats("2")
+	if !ok {
+		t.Fatal("no sequence stats for board 2")
+	}
+	stats.Since = time.Time{}
+	want := SequenceStats{Received: 7, Duplicates: 1, Reordered: 1}
+	if stats != want {
+		t.Errorf("stats = %+v, want %+v", stats, want)
+	}
+}
@@ -0,0 +1,150 @@
+package tcp
+
+import (
+	"fall-detection/internal/telemetry"
+	"time"
+)
+
+// seqWindow is how many recent sequence numbers are remembered to tell a
+// late frame from a duplicate. A frame further behind than this is taken as
+// the board restarting its counter.
+const seqWindow = 64
+
+// SequenceStats counts frame delivery problems for one board, from the
+// sequence numbers sent by v2+ firmware.
+type SequenceStats struct {
+	Received   uint64    `json:"received"`   // Unique frames
+	Lost       uint64    `json:"lost"`       // Skipped sequence numbers never seen
+	Duplicates uint64    `json:"duplicates"` // Frames seen more than once, dropped
+	Reordered  uint64    `json:"reordered"`  // Frames that arrived after a later one
+	Restarts   uint64    `json:"restarts"`   // Counter resets, e.g. board reboots
+	Since      time.Time `json:"since"`
+}
+
+// LossRate is the fraction of expected frames that never arrived.
+func (s SequenceStats) LossRate() float64 {
+	expected := s.Received + s.Lost
+	if expected == 0 {
+		return 0
+	}
+	return float64(s.Lost) / float64(expected)
+}
+
+type seqTracker struct {
+	stats   SequenceStats
+	started bool
+	highest uint32
+	seen    uint64 // Bit i set if highest-i has been received
+
+	// resync is set when the board reconnects. A counter that has gone
+	// backwards since then means the board rebooted, not that old frames
+	// are being resent.
+	resync bool
+}
+
+// observe records seq and reports whether the frame is a duplicate.
+func (t *seqTracker) observe(seq uint32) (duplicate bool) {
+	if !t.started {
+		t.started = true
+		t.highest = seq
+		t.seen = 1
+		t.stats.Received++
+		return false
+	}
+
+	resync := t.resync
+	t.resync = false
+
+	// Past MaxUint32 the counter wraps to 0, so distances are taken modulo
+	// 2^32. Only a short step past the wrap counts as moving forward; a
+	// counter far behind is still a restart.
+	ahead := seq - t.highest
+	forward := ahead != 0 && ahead < 1<<31 && (seq > t.highest || ahead < seqWindow)
+
+	switch {
+	case forward:
+		gap := ahead
+		if gap >= seqWindow {
+			t.seen = 0
+		} else {
+			t.seen <<= gap
+		}
+		t.seen |= 1
+		t.stats.Lost += uint64(gap - 1)
+		t.highest = seq
+	case !resync && t.highest-seq < seqWindow:
+		bit := uint64(1) << (t.highest - seq)
+		if t.seen&bit != 0 {
+			t.stats.Duplicates++
+			return true
+		}
+		// Arrived late, so it was counted as lost when the gap opened
+		t.seen |= bit
+		t.stats.Reordered++
+		if t.stats.Lost > 0 {
+			t.stats.Lost--
+		}
+	default:
+		t.stats.Restarts++
+		t.highest = seq
+		t.seen = 1
+	}
+
+	t.stats.Received++
+	return false
+}
+
+// resyncSequence is called when a board connects, see seqTracker.resync.
+func (s *TCPServer) resyncSequence(boardID string) {
+	s.sequencesMu.Lock()
+	defer s.sequencesMu.Unlock()
+
+	if t := s.sequences[boardID]; t != nil {
+		t.resync = true
+	}
+}
+
+// trackSequence updates the board's sequence stats and reports whether the
+// reading duplicates one already handled. Readings without a sequence number
+// are never duplicates.
+func (s *TCPServer) trackSequence(reading telemetry.Reading, now time.Time) bool {
+	if !reading.HasSeq() {
+		return false
+	}
+
+	s.sequencesMu.Lock()
+	defer s.sequencesMu.Unlock()
+
+	t := s.sequences[reading.BoardID]
+	if t == nil {
+		t = &seqTracker{stats: SequenceStats{Since: now}}
+		s.sequences[reading.BoardID] = t
+	}
+	return t.observe(reading.Seq)
+}
+
+// SequenceStats returns the frame delivery stats for a board, or false if it
+// has not sent any numbered frames.
+func (s *TCPServer) SequenceStats(boardID string) (SequenceStats, bool) {
+	s.sequencesMu.Lock()
+	defer s.sequencesMu.Unlock()
+
+	t := s.sequences[boardID]
+	if t == nil {
+		return SequenceStats{}, false
+	}
+	return t.stats, true
+}
+
+// AllSequenceStats returns the frame delivery stats of every board that has
+// sent numbered frames, by board ID.
+func (s *TCPServer) AllSequenceStats() map[string]SequenceStats {
+	s.sequencesMu.Lock()
+	defer s.sequencesMu.Unlock()
+
+	result := make(map[string]SequenceStats, len(s.sequences))
+	for id, t := range s.sequences {
+		result[id] = t.stats
+	}
+	return result
+}
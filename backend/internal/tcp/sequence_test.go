@@ -0,0 +1,116 @@
+package tcp
+
+import "testing"
+
+func TestSeqTracker(t *testing.T) {
+	const maxSeq = ^uint32(0)
+
+	tests := []struct {
+		name       string
+		seqs       []uint32
+		resyncAt   int // Index before which the board reconnects, or -1
+		want       SequenceStats
+		duplicates []int // Indexes of frames reported as duplicates
+	}{
+		{
+			name:     "in order",
+			seqs:     []uint32{1, 2, 3, 4, 5},
+			resyncAt: -1,
+			want:     SequenceStats{Received: 5},
+		},
+		{
+			name:     "gap",
+			seqs:     []uint32{1, 2, 6, 7},
+			resyncAt: -1,
+			want:     SequenceStats{Received: 4, Lost: 3},
+		},
+		{
+			name:       "duplicate",
+			seqs:       []uint32{1, 2, 2, 3, 1},
+			resyncAt:   -1,
+			want:       SequenceStats{Received: 3, Duplicates: 2},
+			duplicates: []int{2, 4},
+		},
+		{
+			// 4 is counted lost when 5 arrives, then found again
+			name:     "reordered",
+			seqs:     []uint32{1, 2, 3, 5, 4, 6},
+			resyncAt: -1,
+			want:     SequenceStats{Received: 6, Reordered: 1},
+		},
+		{
+			name:     "late frame outside the window is a restart",
+			seqs:     []uint32{1, 100, 20},
+			resyncAt: -1,
+			want:     SequenceStats{Received: 3, Lost: 98, Restarts: 1},
+		},
+		{
+			name:     "gap wider than the window",
+			seqs:     []uint32{1, 1 + seqWindow + 10, 2 + seqWindow + 10},
+			resyncAt: -1,
+			want:     SequenceStats{Received: 3, Lost: seqWindow + 9},
+		},
+		{
+			name:     "counter wraps around",
+			seqs:     []uint32{maxSeq - 1, maxSeq, 0, 1},
+			resyncAt: -1,
+			want:     SequenceStats{Received: 4},
+		},
+		{
+			name:     "gap across the wrap",
+			seqs:     []uint32{maxSeq - 1, 1, maxSeq, 2},
+			resyncAt: -1,
+			want:     SequenceStats{Received: 4, Lost: 1, Reordered: 1},
+		},
+		{
+			// Without the reconnect, 3 would look like a resent frame
+			name:     "reboot after reconnect",
+			seqs:     []uint32{1, 2, 3, 4, 5, 3, 4},
+			resyncAt: 5,
+			want:     SequenceStats{Received: 7, Restarts: 1},
+		},
+		{
+			name:       "resync only covers the first frame",
+			seqs:       []uint32{1, 2, 3, 6, 6},
+			resyncAt:   3,
+			want:       SequenceStats{Received: 4, Lost: 2, Duplicates: 1},
+			duplicates: []int{4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tr seqTracker
+			var duplicates []int
+			for i, seq := range tt.seqs {
+				if i == tt.resyncAt {
+					tr.resync = true
+				}
+				if tr.observe(seq) {
+					duplicates = append(duplicates, i)
+				}
+			}
+
+			if tr.stats != tt.want {
+				t.Errorf("stats = %+v, want %+v", tr.stats, tt.want)
+			}
+			if len(duplicates) != len(tt.duplicates) {
+				t.Fatalf("duplicates at %v, want %v", duplicates, tt.duplicates)
+			}
+			for i := range duplicates {
+				if duplicates[i] != tt.duplicates[i] {
+					t.Errorf("duplicates at %v, want %v", duplicates, tt.duplicates)
+				}
+			}
+		})
+	}
+}
+
+func TestSequenceStatsLossRate(t *testing.T) {
+	if got := (SequenceStats{}).LossRate(); got != 0 {
+		t.Errorf("empty LossRate() = %v, want 0", got)
+	}
+	if got := (SequenceStats{Received: 90, Lost: 10}).LossRate(); got != 0.1 {
+		t.Errorf("LossRate() = %v, want 0.1", got)
+	}
+}
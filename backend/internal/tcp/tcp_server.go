@@ -2,13 +2,20 @@ package tcp
 
 import (
 	"bufio"
+	"crypto/tls"
+	"errors"
+	"fall-detection/internal/capture"
+	"fall-detection/internal/detection"
 	"fall-detection/internal/mqtt"
-	"fmt"
+	"fall-detection/internal/repository"
+	"fall-detection/internal/telemetry"
+	"fall-detection/internal/timeseries"
 	"io"
 	"log"
 	"net"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	pahomqtt "github.com/eclipse/paho.mqtt.golang"
@@ -19,40 +26,121 @@ type Board struct {
 	ConnectedAt time.Time
 	LastSeen    time.Time // Last seen time depends on response from DataSocket
 
+	ProtocolVersion int    // Wire format negotiated on this connection
+	Firmware        string // Reported by v2+ firmware only
+	Authenticated   bool   // Connection completed the HMAC handshake
+	ProfileVersion  int    // Detection profile acked on this connection, 0 for firmware defaults
+
 	DataSocket net.Conn
+	writeMu    sync.Mutex
 }
 
 type TCPServer struct {
-	Addr      string // Port
+	Addr      string // Plain TCP port for legacy boards, disabled if empty
 	Publisher pahomqtt.Client
 
+	TLSAddr   string // TLS port, only used when TLSConfig is set
+	TLSConfig *tls.Config
+
+	Registry    *repository.BoardRepo // Records every board that connects when set
+	Credentials *repository.BoardCredentialRepo
+	RequireAuth bool // Reject all boards that do not complete the handshake, not just provisioned ones
+
+	Profiles *repository.DetectionProfileRepo // Pushed to boards on connect when set
+	Verifier *detection.Verifier              // Cross-checks board verdicts when set
+	Storage  *timeseries.Writer               // Persists raw readings when set
+	Recorder *capture.Recorder                // Writes every session to a capture file when set
+
+	// Raises prolonged inactivity alerts when set, with per-board settings
+	// loaded from InactivitySettings on connect
+	Inactivity         *detection.InactivityMonitor
+	InactivitySettings *repository.InactivitySettingsRepo
+
+	// Fall traces are captured when both repos are set
+	FallEvents *repository.FallEventRepo
+	Traces     *repository.FallEventTraceRepo
+	traces     map[string]*traceBuffer
+	tracesMu   sync.Mutex
+
 	Boards   map[string]*Board
 	BoardsMu sync.RWMutex
 
-	FallState   map[string]string // Tracks previous fall status per board
-	FallStateMu sync.RWMutex
+	FallStatus   map[string]bool // Tracks previous fall status per board
+	FallStatusMu sync.RWMutex
+
+	sequences   map[string]*seqTracker // Frame delivery stats per board, kept across reconnects
+	sequencesMu sync.Mutex
+
+	Pending       map[uint32]*pendingCommand // Commands awaiting an ack, by command ID
+	PendingMu     sync.Mutex
+	nextCommandID atomic.Uint32
+
+	resets   map[string]remoteReset // Latest RESET_FALL per board
+	resetsMu sync.Mutex
 }
 
 const (
 	staleAfter = 5 * time.Second
 )
 
-func NewTCPServer(addr string, pub pahomqtt.Client) *TCPServer {
+func NewTCPServer(addr string, pub pahomqtt.Client, credentials *repository.BoardCredentialRepo, requireAuth bool) *TCPServer {
 	return &TCPServer{
-		Addr:      addr,
-		Publisher: pub,
-		Boards:    make(map[string]*Board),
-		FallState: make(map[string]string),
+		Addr:        addr,
+		Publisher:   pub,
+		Credentials: credentials,
+		RequireAuth: requireAuth,
+		Boards:      make(map[string]*Board),
+		FallStatus:  make(map[string]bool),
+		Pending:     make(map[uint32]*pendingCommand),
+		traces:      make(map[string]*traceBuffer),
+		sequences:   make(map[string]*seqTracker),
 	}
 }
 
+// Start listens on the plain and TLS ports (whichever are configured) and
+// blocks serving board connections.
 func (s *TCPServer) Start() error {
-	listener, err := net.Listen("tcp", s.Addr)
-	if err != nil {
+	var listeners []net.Listener
+
+	if s.Addr != "" {
+		listener, err := net.Listen("tcp", s.Addr)
+		if err != nil {
+			log.Fatal("Error listening: ", err)
+			return err
+		}
+		log.Printf("[TCP SERVER] Listening on %s", s.Addr)
+		listeners = append(listeners, listener)
+	}
+
+	if s.TLSConfig != nil {
+		listener, err := tls.Listen("tcp", s.TLSAddr, s.TLSConfig)
+		if err != nil {
+			log.Fatal("Error listening (TLS): ", err)
+			return err
+		}
+		log.Printf("[TCP SERVER] Listening on %s (TLS)", s.TLSAddr)
+		listeners = append(listeners, listener)
+	}
+
+	if len(listeners) == 0 {
+		err := errors.New("no TCP listeners configured")
 		log.Fatal("Error listening: ", err)
 		return err
 	}
 
+	var wg sync.WaitGroup
+	for _, listener := range listeners {
+		wg.Add(1)
+		go func(l net.Listener) {
+			defer wg.Done()
+			s.serve(l)
+		}(listener)
+	}
+	wg.Wait()
+	return nil
+}
+
+func (s *TCPServer) serve(listener net.Listener) {
 	defer listener.Close()
 
 	for {
@@ -67,9 +155,13 @@ func (s *TCPServer) Start() error {
 }
 
 func (s *TCPServer) handleConnection(conn net.Conn) error {
+	if s.Recorder != nil {
+		conn = s.Recorder.Wrap(conn)
+	}
 	defer conn.Close()
 
 	reader := bufio.NewReader(conn)
+	var decoder telemetry.Decoder
 	registered := false
 	var boardID string
 
@@ -77,44 +169,80 @@ func (s *TCPServer) handleConnection(conn net.Conn) error {
 		if boardID != "" {
 			s.BoardsMu.Lock()
 			b := s.Boards[boardID]
+			var lastSeen time.Time
 			if b != nil && b.DataSocket == conn {
 				b.DataSocket = nil
+				lastSeen = b.LastSeen
 			}
 			s.BoardsMu.Unlock()
+
+			go s.recordLastSeen(boardID, lastSeen)
+
+			// Keep whatever was captured if the board dropped mid-fall
+			s.flushTrace(boardID)
 		}
 	}()
 
+	authedID, pending, err := s.authenticate(conn, reader)
+	if err != nil {
+		return err
+	}
+
 	for {
-		// Timeout if no data received within 5 seconds
-		conn.SetReadDeadline(time.Now().Add(staleAfter))
-		message, err := reader.ReadString('\n')
+		line := pending
+		pending = ""
+		if line == "" {
+			line, err = readLine(conn, reader)
+		}
 
 		if err != nil {
 			if err == io.EOF {
-				fmt.Printf("Read error: %v\n", err, conn.RemoteAddr())
+				log.Printf("[TCP SERVER] Connection closed by %s", conn.RemoteAddr())
 			} else {
 				log.Printf("Read error: %v\n", err)
 			}
 			return err
 		}
 
-		line := strings.TrimSpace(message)
-		fmt.Printf("Received: %s", line)
 		if line == "" {
 			// No message received from the board
 			continue
 		}
 
-		// Extract the boardID from this valid message
-		fields := strings.Split(line, ",")
+		if telemetry.IsAck(line) {
+			if boardID != "" {
+				s.handleAck(boardID, line)
+			}
+			continue
+		}
 
-		// TODO: Create and Move verification into a different package
-		if len(fields) != 10 {
-			log.Printf("Invalid message (len=%d) from %s: %q", len(fields), conn.RemoteAddr(), line)
+		reading, err := decoder.Decode(line)
+		if err != nil {
+			log.Printf("Invalid message from %s: %v", conn.RemoteAddr(), err)
 			continue
 		}
 
-		boardID = fields[7]
+		if authedID != "" && reading.BoardID != authedID {
+			log.Printf("[TCP SERVER] Board %s from %s sent telemetry for board %s, closing", authedID, conn.RemoteAddr(), reading.BoardID)
+			return errUnauthenticated
+		}
+
+		if !registered {
+			// Decide on authentication before the board touches the registry,
+			// so an unauthenticated sender cannot create boards
+			if authedID == "" {
+				if err := s.admitUnauthenticated(reading.BoardID); err != nil {
+					log.Printf("[TCP SERVER] Rejected unauthenticated connection from %s claiming board %s: %v", conn.RemoteAddr(), reading.BoardID, err)
+					return err
+				}
+			}
+			if !s.registerBoard(reading.BoardID, reading.Firmware) {
+				log.Printf("[TCP SERVER] Rejected connection from %s for disabled board %s", conn.RemoteAddr(), reading.BoardID)
+				return errBoardDisabled
+			}
+		}
+
+		boardID = reading.BoardID
 
 		if !registered {
 			// Board has been connected for the first time
@@ -148,54 +276,113 @@ func (s *TCPServer) handleConnection(conn net.Conn) error {
 			}
 
 			s.Boards[boardID].ConnectedAt = time.Now()
+			s.Boards[boardID].ProtocolVersion = decoder.Version()
+			s.Boards[boardID].Authenticated = authedID != ""
+			s.Boards[boardID].ProfileVersion = 0
 			s.BoardsMu.Unlock()
 
+			log.Printf("[TCP SERVER] Board %s registered from %s using protocol v%d", boardID, conn.RemoteAddr(), decoder.Version())
+
 			// Disconnect old connection
 			if oldConn != nil {
 				oldConn.Close()
 			}
 
 			registered = true
+			s.resyncSequence(boardID)
+
+			// Acks are read by this loop, so the push must not block it
+			go s.PushProfile(boardID)
+			go s.LoadInactivitySettings(boardID)
 
 		}
 
 		// Update lastSeen
 		s.BoardsMu.Lock()
 		s.Boards[boardID].LastSeen = time.Now()
+		if reading.Firmware != "" {
+			s.Boards[boardID].Firmware = reading.Firmware
+		}
 		s.BoardsMu.Unlock()
 
-		// 0 - 2 is accelerometer data
-		// 3 - 5 is gyrometer data
-		// 6 is fallStatus
-		// 7 is the board number
-		// 8 is the fall state
-		// 9 is the barometer data
+		if s.trackSequence(reading, time.Now()) {
+			log.Printf("[TCP SERVER] Dropped duplicate frame %d from board %s", reading.Seq, boardID)
+			continue
+		}
+
+		s.handleReading(reading)
+	}
+}
+
+// readLine reads the next line from a board, timing out if no data is
+// received within staleAfter.
+func readLine(conn net.Conn, reader *bufio.Reader) (string, error) {
+	conn.SetReadDeadline(time.Now().Add(staleAfter))
+	message, err := reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(message), nil
+}
 
-		// Publish to MQTT Broker
-		sensorTopic := "fall-detection/board" + boardID + "/sensors"
+func writeLine(conn net.Conn, line string) error {
+	conn.SetWriteDeadline(time.Now().Add(staleAfter))
+	_, err := conn.Write([]byte(line + "\n"))
+	return err
+}
 
-		mqtt.Publish(s.Publisher, sensorTopic, line)
+// handleReading publishes a reading to the board's sensor topic and raises
+// alerts on fall status transitions.
+func (s *TCPServer) handleReading(reading telemetry.Reading) {
+	boardID := reading.BoardID
+	payload := reading.Encode()
 
-		currentFall := fields[6]
+	// Publish to MQTT Broker
+	sensorTopic := "fall-detection/" + reading.Topic() + "/sensors"
+	mqtt.Publish(s.Publisher, sensorTopic, payload)
 
-		s.FallStateMu.Lock()
-		prevFall := s.FallState[boardID]
+	now := time.Now()
+	if s.Storage != nil {
+		s.Storage.Add(reading, now)
+	}
+	if s.Verifier != nil {
+		s.Verifier.Observe(reading, now)
+	}
 
-		if currentFall == "1" && prevFall != "1" {
-			alertTopic := "fall-detection/board" + boardID + "/alerts"
-			log.Printf("[TCP Server] Fall detected on %s, publishing alert", boardID)
-			mqtt.Publish(s.Publisher, alertTopic, line)
-		}
+	s.FallStatusMu.Lock()
+	prevFall := s.FallStatus[boardID]
+	fallRaised := reading.FallStatus && !prevFall
 
-		if currentFall == "0" && prevFall == "1" {
-			alertTopic := "fall-detection/board" + boardID + "/alerts"
-			log.Printf("[TCP Server] NFC reset detected on %s, publishing BOARD_RESET", boardID)
-			mqtt.Publish(s.Publisher, alertTopic, "BOARD_RESET")
-		}
+	alertTopic := "fall-detection/" + reading.Topic() + "/alerts"
+	if fallRaised {
+		log.Printf("[TCP Server] Fall detected on %s, publishing alert", boardID)
+		mqtt.Publish(s.Publisher, alertTopic, payload)
+	}
 
-		s.FallState[boardID] = currentFall
-		s.FallStateMu.Unlock()
+	if !reading.FallStatus && prevFall {
+		log.Printf("[TCP Server] NFC reset detected on %s, publishing BOARD_RESET", boardID)
+		mqtt.Publish(s.Publisher, alertTopic, "BOARD_RESET")
 	}
+
+	s.FallStatus[boardID] = reading.FallStatus
+	s.FallStatusMu.Unlock()
+
+	s.recordTrace(reading, now, fallRaised)
+	s.checkInactivity(reading, now)
+}
+
+// LastSeen returns when each board currently known to the server last sent
+// data. Unlike GetBoards it does not prune stale boards, so a board that went
+// silent is still reported until it is pruned.
+func (s *TCPServer) LastSeen() map[string]time.Time {
+	s.BoardsMu.RLock()
+	defer s.BoardsMu.RUnlock()
+
+	result := make(map[string]time.Time, len(s.Boards))
+	for id, board := range s.Boards {
+		result[id] = board.LastSeen
+	}
+	return result
 }
 
 func (s *TCPServer) GetBoards() []*Board {
@@ -0,0 +1,87 @@
+package tcp
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LoadTLSConfig builds the TLS config for the board listener. When
+// clientCAFile is set, boards may present a client certificate signed by that
+// CA; its CommonName ("board<N>") then identifies and authenticates the board
+// without the HMAC handshake. Boards without a certificate still connect and
+// fall back to the handshake.
+func LoadTLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load server certificate: %w", err)
+	}
+
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if clientCAFile != "" {
+		pem, err := os.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("client CA file contains no certificates")
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	return cfg, nil
+}
+
+// certBoardID completes the TLS handshake and returns the board ID from a
+// verified client certificate, or "" if the connection is plain TCP or the
+// board presented no certificate.
+func certBoardID(conn net.Conn) (string, error) {
+	tlsConn, ok := unwrapTLS(conn)
+	if !ok {
+		return "", nil
+	}
+
+	tlsConn.SetDeadline(time.Now().Add(authTimeout))
+	defer tlsConn.SetDeadline(time.Time{})
+	if err := tlsConn.Handshake(); err != nil {
+		return "", fmt.Errorf("tls handshake: %w", err)
+	}
+
+	state := tlsConn.ConnectionState()
+	if len(state.VerifiedChains) == 0 {
+		return "", nil
+	}
+
+	cn := state.PeerCertificates[0].Subject.CommonName
+	boardID := strings.TrimPrefix(cn, "board")
+	if n, err := strconv.Atoi(boardID); err != nil || n <= 0 {
+		return "", fmt.Errorf("client certificate CN %q is not a board ID", cn)
+	}
+	return boardID, nil
+}
+
+// unwrapTLS finds the *tls.Conn beneath wrappers such as a capture recorder.
+func unwrapTLS(conn net.Conn) (*tls.Conn, bool) {
+	for {
+		if tlsConn, ok := conn.(*tls.Conn); ok {
+			return tlsConn, true
+		}
+		wrapper, ok := conn.(interface{ NetConn() net.Conn })
+		if !ok {
+			return nil, false
+		}
+		conn = wrapper.NetConn()
+	}
+}
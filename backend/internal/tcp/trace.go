@@ -0,0 +1,140 @@
+package tcp
+
+import (
+	"context"
+	"errors"
+	"fall-detection/internal/repository"
+	"fall-detection/internal/telemetry"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Window of motion kept around each fall.
+const (
+	tracePreFall  = 10 * time.Second
+	tracePostFall = 10 * time.Second
+
+	// traceLinkSlack allows for the delay between the TCP server raising a
+	// fall and the alert service creating the fall event row.
+	traceLinkSlack = 30 * time.Second
+	traceLinkPoll  = 500 * time.Millisecond
+)
+
+// traceBuffer holds a board's recent readings and, while a fall is being
+// captured, the readings collected since the fall was raised.
+type traceBuffer struct {
+	recent  []repository.SensorReading // Last tracePreFall of readings, oldest first
+	capture *traceCapture
+}
+
+type traceCapture struct {
+	boardID  string
+	fallAt   time.Time
+	readings []repository.SensorReading
+	eventID  chan int64 // Receives the linked fall event's ID, or 0 if none was found
+}
+
+// recordTrace adds a reading to the board's ring buffer and starts or
+// completes a fall capture.
+func (s *TCPServer) recordTrace(reading telemetry.Reading, now time.Time, fallRaised bool) {
+	if s.Traces == nil || s.FallEvents == nil {
+		return
+	}
+
+	sample := repository.SensorReading{BoardID: reading.Topic(), ReceivedAt: now, Reading: reading}
+
+	s.tracesMu.Lock()
+	defer s.tracesMu.Unlock()
+
+	buf := s.traces[reading.BoardID]
+	if buf == nil {
+		buf = &traceBuffer{}
+		s.traces[reading.BoardID] = buf
+	}
+
+	buf.recent = append(buf.recent, sample)
+	cutoff := now.Add(-tracePreFall)
+	drop := 0
+	for drop < len(buf.recent) && buf.recent[drop].ReceivedAt.Before(cutoff) {
+		drop++
+	}
+	buf.recent = buf.recent[drop:]
+
+	if buf.capture != nil {
+		buf.capture.readings = append(buf.capture.readings, sample)
+		if now.Sub(buf.capture.fallAt) >= tracePostFall {
+			go s.saveTrace(buf.capture)
+			buf.capture = nil
+		}
+		return
+	}
+
+	if fallRaised {
+		readings := make([]repository.SensorReading, len(buf.recent))
+		copy(readings, buf.recent)
+		buf.capture = &traceCapture{boardID: reading.Topic(), fallAt: now, readings: readings, eventID: make(chan int64, 1)}
+		go s.linkTrace(buf.capture)
+	}
+}
+
+// flushTrace saves an in-progress capture early, e.g. when the board
+// disconnects before the post-fall window has elapsed.
+func (s *TCPServer) flushTrace(boardID string) {
+	s.tracesMu.Lock()
+	buf := s.traces[boardID]
+	var capture *traceCapture
+	if buf != nil {
+		capture = buf.capture
+		buf.capture = nil
+	}
+	s.tracesMu.Unlock()
+
+	if capture != nil {
+		go s.saveTrace(capture)
+	}
+}
+
+// linkTrace finds the fall event a capture belongs to as soon as the alert
+// service has created it, so a later fall on the same board cannot claim the
+// capture.
+func (s *TCPServer) linkTrace(capture *traceCapture) {
+	deadline := time.Now().Add(traceLinkSlack)
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		event, err := s.FallEvents.GetForFall(ctx, capture.boardID, capture.fallAt)
+		cancel()
+		if err == nil {
+			capture.eventID <- event.ID
+			return
+		}
+		if !errors.Is(err, pgx.ErrNoRows) || time.Now().After(deadline) {
+			log.Printf("[TCP SERVER] No fall event to attach %s trace to: %v", capture.boardID, err)
+			capture.eventID <- 0
+			return
+		}
+		time.Sleep(traceLinkPoll)
+	}
+}
+
+// saveTrace persists a capture's readings once linkTrace has found its fall
+// event.
+func (s *TCPServer) saveTrace(capture *traceCapture) {
+	eventID := <-capture.eventID
+	if eventID == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	saved, err := s.Traces.Save(ctx, eventID, capture.readings)
+	if err != nil {
+		log.Printf("[TCP SERVER] Failed to save trace for fall event #%d: %v", eventID, err)
+		return
+	}
+	if saved {
+		log.Printf("[TCP SERVER] Saved %d-reading trace for fall event #%d", len(capture.readings), eventID)
+	}
+}
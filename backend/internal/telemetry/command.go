@@ -0,0 +1,148 @@
+package telemetry
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Downlink commands share the board's TCP connection with telemetry. The
+// server sends a command line and the board answers with an ack carrying the
+// same ID:
+//
+//	server -> CMD,<id>,<type>[,<arg>...]
+//	board  -> ACK,<id>,OK|ERR[,<detail>]
+const (
+	CommandPrefix = "CMD"
+	AckPrefix     = "ACK"
+)
+
+type CommandType string
+
+const (
+	CmdSilenceBuzzer CommandType = "SILENCE"        // Stop the buzzer, fall state unchanged
+	CmdLocate        CommandType = "LOCATE"         // Sound the buzzer briefly to find the board
+	CmdResetFall     CommandType = "RESET_FALL"     // Clear a confirmed fall as if NFC-tapped
+	CmdStatus        CommandType = "STATUS"         // Board replies with a status summary in the ack
+	CmdSetThresholds CommandType = "SET_THRESHOLDS" // Args are NAME=value pairs
+)
+
+var commandTypes = map[CommandType]bool{
+	CmdSilenceBuzzer: true,
+	CmdLocate:        true,
+	CmdResetFall:     true,
+	CmdStatus:        true,
+	CmdSetThresholds: true,
+}
+
+var (
+	ErrUnknownCommand   = errors.New("unknown command")
+	ErrMalformedCommand = errors.New("malformed command")
+	ErrMalformedAck     = errors.New("malformed ack")
+)
+
+// ParseCommandType accepts a command name in any case.
+func ParseCommandType(name string) (CommandType, error) {
+	t := CommandType(strings.ToUpper(strings.TrimSpace(name)))
+	if !commandTypes[t] {
+		return "", fmt.Errorf("%w: %q", ErrUnknownCommand, name)
+	}
+	return t, nil
+}
+
+type Command struct {
+	ID   uint32
+	Type CommandType
+	Args []string
+}
+
+// NewSetThresholds builds a SET_THRESHOLDS command using the firmware's
+// #define names as keys, e.g. "FREEFALL_THRESHOLD".
+func NewSetThresholds(thresholds map[string]float64) Command {
+	names := make([]string, 0, len(thresholds))
+	for name := range thresholds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	args := make([]string, len(names))
+	for i, name := range names {
+		args[i] = name + "=" + strconv.FormatFloat(thresholds[name], 'f', -1, 64)
+	}
+	return Command{Type: CmdSetThresholds, Args: args}
+}
+
+func (c Command) Encode() string {
+	fields := append([]string{CommandPrefix, strconv.FormatUint(uint64(c.ID), 10), string(c.Type)}, c.Args...)
+	return strings.Join(fields, ",")
+}
+
+func ParseCommand(line string) (Command, error) {
+	parts := strings.Split(strings.TrimSpace(line), ",")
+	if len(parts) < 3 || parts[0] != CommandPrefix {
+		return Command{}, &ParseError{Value: line, Err: ErrMalformedCommand}
+	}
+	id, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return Command{}, &ParseError{Field: "command_id", Value: parts[1], Err: ErrNotNumeric}
+	}
+	t, err := ParseCommandType(parts[2])
+	if err != nil {
+		return Command{}, &ParseError{Field: "command", Value: parts[2], Err: ErrUnknownCommand}
+	}
+	return Command{ID: uint32(id), Type: t, Args: parts[3:]}, nil
+}
+
+type Ack struct {
+	ID     uint32
+	OK     bool
+	Detail string
+}
+
+// IsAck reports whether a line from a board is a command ack rather than
+// telemetry.
+func IsAck(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), AckPrefix+",")
+}
+
+func (a Ack) Encode() string {
+	status := "ERR"
+	if a.OK {
+		status = "OK"
+	}
+	fields := []string{AckPrefix, strconv.FormatUint(uint64(a.ID), 10), status}
+	if a.Detail != "" {
+		fields = append(fields, a.Detail)
+	}
+	return strings.Join(fields, ",")
+}
+
+// ParseAck decodes an ack line. Everything after the status is the detail,
+// so it may itself contain commas.
+func ParseAck(line string) (Ack, error) {
+	parts := strings.SplitN(strings.TrimSpace(line), ",", 4)
+	if len(parts) < 3 || parts[0] != AckPrefix {
+		return Ack{}, &ParseError{Value: line, Err: ErrMalformedAck}
+	}
+	id, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return Ack{}, &ParseError{Field: "command_id", Value: parts[1], Err: ErrMalformedAck}
+	}
+
+	var a Ack
+	a.ID = uint32(id)
+	switch parts[2] {
+	case "OK":
+		a.OK = true
+	case "ERR":
+		a.OK = false
+	default:
+		return Ack{}, &ParseError{Field: "ack_status", Value: parts[2], Err: ErrMalformedAck}
+	}
+	if len(parts) == 4 {
+		a.Detail = parts[3]
+	}
+	return a, nil
+}
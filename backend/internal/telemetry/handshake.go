@@ -0,0 +1,104 @@
+package telemetry
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Authentication handshake. A board opens the connection with a hello line,
+// the server answers with a random challenge, and the board proves it holds
+// the shared per-board secret by returning an HMAC over the challenge:
+//
+//	board  -> HELLO,<boardID>
+//	server -> CHALLENGE,<hex nonce>
+//	board  -> AUTH,<hex HMAC-SHA256(secret, nonce || boardID)>
+//	server -> OK | DENIED
+//
+// Telemetry lines follow only after OK.
+const (
+	HelloPrefix     = "HELLO"
+	ChallengePrefix = "CHALLENGE"
+	AuthPrefix      = "AUTH"
+
+	AuthOK     = "OK"
+	AuthDenied = "DENIED"
+)
+
+var ErrMalformedHandshake = errors.New("malformed handshake line")
+
+// IsHello reports whether a line starts the authentication handshake.
+func IsHello(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), HelloPrefix+",")
+}
+
+// ParseHello returns the board ID claimed in a hello line.
+func ParseHello(line string) (string, error) {
+	boardID, err := handshakeValue(line, HelloPrefix)
+	if err != nil {
+		return "", err
+	}
+	if n, err := strconv.Atoi(boardID); err != nil || n <= 0 {
+		return "", &ParseError{Field: "board_id", Value: boardID, Err: ErrInvalidBoardID}
+	}
+	return boardID, nil
+}
+
+func HelloLine(boardID string) string {
+	return HelloPrefix + "," + boardID
+}
+
+func ChallengeLine(nonce []byte) string {
+	return ChallengePrefix + "," + hex.EncodeToString(nonce)
+}
+
+func ParseChallenge(line string) ([]byte, error) {
+	v, err := handshakeValue(line, ChallengePrefix)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hex.DecodeString(v)
+	if err != nil {
+		return nil, &ParseError{Field: "challenge", Value: v, Err: ErrMalformedHandshake}
+	}
+	return nonce, nil
+}
+
+func AuthLine(signature string) string {
+	return AuthPrefix + "," + signature
+}
+
+func ParseAuth(line string) (string, error) {
+	return handshakeValue(line, AuthPrefix)
+}
+
+// SignChallenge computes the board's response to a challenge.
+func SignChallenge(secret, nonce []byte, boardID string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(nonce)
+	mac.Write([]byte(boardID))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyChallenge checks a board's response in constant time.
+func VerifyChallenge(secret, nonce []byte, boardID, signature string) bool {
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(nonce)
+	mac.Write([]byte(boardID))
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+func handshakeValue(line, prefix string) (string, error) {
+	parts := strings.Split(strings.TrimSpace(line), ",")
+	if len(parts) != 2 || parts[0] != prefix || strings.TrimSpace(parts[1]) == "" {
+		return "", &ParseError{Value: line, Err: ErrMalformedHandshake}
+	}
+	return strings.TrimSpace(parts[1]), nil
+}
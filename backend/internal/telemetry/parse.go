@@ -0,0 +1,182 @@
+package telemetry
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// FieldCount is the number of comma-separated fields in a legacy firmware
+// line. Versioned lines carry these same fields after their header.
+const FieldCount = 10
+
+// Field positions in the firmware CSV line.
+const (
+	fieldAccelX = iota
+	fieldAccelY
+	fieldAccelZ
+	fieldGyroX
+	fieldGyroY
+	fieldGyroZ
+	fieldFallStatus
+	fieldBoardID
+	fieldFallState
+	fieldPressure
+)
+
+var fieldNames = [FieldCount]string{
+	"accel_x", "accel_y", "accel_z",
+	"gyro_x", "gyro_y", "gyro_z",
+	"fall_status", "board_id", "fall_state", "pressure",
+}
+
+// Sensor limits. Anything outside these is a corrupted frame rather than a
+// real measurement.
+const (
+	maxAccel    = 160.0   // ±16 g full scale in m/s²
+	maxGyro     = 20000.0 // ±2000 dps full scale, scaled as in firmware
+	minPressure = 260.0   // LPS22HB operating range in hPa
+	maxPressure = 1260.0
+)
+
+var (
+	ErrFieldCount        = errors.New("wrong number of fields")
+	ErrNotNumeric        = errors.New("not a number")
+	ErrOutOfRange        = errors.New("out of range")
+	ErrUnknownFallState  = errors.New("unknown fall state")
+	ErrInvalidFallStatus = errors.New("fall status must be 0 or 1")
+	ErrInvalidBoardID    = errors.New("board id must be a positive integer")
+	ErrInvalidFirmware   = errors.New("firmware version must not be empty")
+)
+
+// ParseError describes why a telemetry line was rejected. Err is one of the
+// Err* sentinels above so callers can use errors.Is.
+type ParseError struct {
+	Field string // empty for whole-line errors
+	Value string
+	Err   error
+}
+
+func (e *ParseError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("telemetry: %v", e.Err)
+	}
+	return fmt.Sprintf("telemetry: field %s=%q: %v", e.Field, e.Value, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Parse decodes a firmware telemetry line of any supported version into a
+// Reading. Surrounding whitespace is ignored.
+func Parse(line string) (Reading, error) {
+	fields := strings.Split(strings.TrimSpace(line), ",")
+	version, err := detectVersion(fields)
+	if err != nil {
+		return Reading{}, err
+	}
+
+	switch version {
+	case VersionLegacy:
+		if err := checkFieldCount(line, fields, FieldCount); err != nil {
+			return Reading{}, err
+		}
+		r, err := parseFields(fields)
+		if err != nil {
+			return Reading{}, err
+		}
+		r.Version = VersionLegacy
+		return r, nil
+
+	case Version2:
+		if err := checkFieldCount(line, fields, v2FieldCount); err != nil {
+			return Reading{}, err
+		}
+		return parseV2(fields[1:])
+	}
+
+	return Reading{}, &ParseError{Value: fields[0], Err: ErrUnsupportedVersion}
+}
+
+func checkFieldCount(line string, fields []string, want int) error {
+	if len(fields) == want {
+		return nil
+	}
+	return &ParseError{
+		Value: line,
+		Err:   fmt.Errorf("%w: got %d, want %d", ErrFieldCount, len(fields), want),
+	}
+}
+
+func parseFields(fields []string) (Reading, error) {
+	var r Reading
+	var err error
+
+	floats := []struct {
+		idx   int
+		dst   *float64
+		limit float64
+	}{
+		{fieldAccelX, &r.AccelX, maxAccel},
+		{fieldAccelY, &r.AccelY, maxAccel},
+		{fieldAccelZ, &r.AccelZ, maxAccel},
+		{fieldGyroX, &r.GyroX, maxGyro},
+		{fieldGyroY, &r.GyroY, maxGyro},
+		{fieldGyroZ, &r.GyroZ, maxGyro},
+	}
+	for _, f := range floats {
+		if *f.dst, err = parseFloat(fields, f.idx, -f.limit, f.limit); err != nil {
+			return Reading{}, err
+		}
+	}
+
+	if r.Pressure, err = parseFloat(fields, fieldPressure, minPressure, maxPressure); err != nil {
+		return Reading{}, err
+	}
+
+	switch v := strings.TrimSpace(fields[fieldFallStatus]); v {
+	case "0":
+		r.FallStatus = false
+	case "1":
+		r.FallStatus = true
+	default:
+		return Reading{}, fieldError(fieldFallStatus, v, ErrInvalidFallStatus)
+	}
+
+	boardID := strings.TrimSpace(fields[fieldBoardID])
+	if n, err := strconv.Atoi(boardID); err != nil || n <= 0 {
+		return Reading{}, fieldError(fieldBoardID, boardID, ErrInvalidBoardID)
+	}
+	r.BoardID = boardID
+
+	state := strings.TrimSpace(fields[fieldFallState])
+	n, err := strconv.Atoi(state)
+	if err != nil {
+		return Reading{}, fieldError(fieldFallState, state, ErrNotNumeric)
+	}
+	r.FallState = FallState(n)
+	if !r.FallState.Valid() {
+		return Reading{}, fieldError(fieldFallState, state, ErrUnknownFallState)
+	}
+
+	return r, nil
+}
+
+func parseFloat(fields []string, idx int, min, max float64) (float64, error) {
+	v := strings.TrimSpace(fields[idx])
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, fieldError(idx, v, ErrNotNumeric)
+	}
+	if f < min || f > max {
+		return 0, fieldError(idx, v, fmt.Errorf("%w: want [%g, %g]", ErrOutOfRange, min, max))
+	}
+	return f, nil
+}
+
+func fieldError(idx int, value string, err error) *ParseError {
+	return &ParseError{Field: fieldNames[idx], Value: value, Err: err}
+}
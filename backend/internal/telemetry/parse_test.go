@@ -0,0 +1,165 @@
+package telemetry
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want Reading
+	}{
+		{
+			name: "legacy",
+			line: "0.12,-9.81,0.30,1.50,-2.00,0.25,0,1,0,1013.25",
+			want: Reading{
+				AccelX: 0.12, AccelY: -9.81, AccelZ: 0.30,
+				GyroX: 1.50, GyroY: -2.00, GyroZ: 0.25,
+				BoardID: "1", FallState: StateNormal, Pressure: 1013.25,
+				Version: VersionLegacy,
+			},
+		},
+		{
+			name: "legacy confirmed fall with whitespace",
+			line: " 1.00, 2.00, 3.00, 4.00, 5.00, 6.00, 1, 12, 3, 990.50\r\n",
+			want: Reading{
+				AccelX: 1, AccelY: 2, AccelZ: 3,
+				GyroX: 4, GyroY: 5, GyroZ: 6,
+				FallStatus: true, BoardID: "12", FallState: StateFallConfirmed, Pressure: 990.5,
+				Version: VersionLegacy,
+			},
+		},
+		{
+			name: "v2",
+			line: "v2,0.12,-9.81,0.30,1.50,-2.00,0.25,0,1,2,1013.25,4294967295,87.5,1.4.0,-61",
+			want: Reading{
+				AccelX: 0.12, AccelY: -9.81, AccelZ: 0.30,
+				GyroX: 1.50, GyroY: -2.00, GyroZ: 0.25,
+				BoardID: "1", FallState: StateImpactDetected, Pressure: 1013.25,
+				Version: Version2, Seq: 4294967295, Battery: 87.5, Firmware: "1.4.0", RSSI: -61,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.line)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.line, err)
+			}
+			if got != tt.want {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		line  string
+		field string
+		err   error
+	}{
+		{"too few fields", "0,0,0,0,0,0,0,1,0", "", ErrFieldCount},
+		{"too many fields", "0,0,0,0,0,0,0,1,0,1000,5", "", ErrFieldCount},
+		{"non-numeric accel", "x,0,0,0,0,0,0,1,0,1000", "accel_x", ErrNotNumeric},
+		{"NaN gyro", "0,0,0,0,NaN,0,0,1,0,1000", "gyro_y", ErrNotNumeric},
+		{"accel out of range", "0,0,161,0,0,0,0,1,0,1000", "accel_z", ErrOutOfRange},
+		{"gyro out of range", "0,0,0,-20001,0,0,0,1,0,1000", "gyro_x", ErrOutOfRange},
+		{"pressure out of range", "0,0,0,0,0,0,0,1,0,100", "pressure", ErrOutOfRange},
+		{"bad fall status", "0,0,0,0,0,0,2,1,0,1000", "fall_status", ErrInvalidFallStatus},
+		{"zero board id", "0,0,0,0,0,0,0,0,0,1000", "board_id", ErrInvalidBoardID},
+		{"named board id", "0,0,0,0,0,0,0,board1,0,1000", "board_id", ErrInvalidBoardID},
+		{"non-numeric fall state", "0,0,0,0,0,0,0,1,x,1000", "fall_state", ErrNotNumeric},
+		{"unknown fall state", "0,0,0,0,0,0,0,1,4,1000", "fall_state", ErrUnknownFallState},
+		{"unsupported version", "v3,0,0,0,0,0,0,0,1,0,1000,1,50,1.0,-60", "version", ErrUnsupportedVersion},
+		{"malformed version", "vx,0,0,0,0,0,0,0,1,0,1000,1,50,1.0,-60", "version", ErrUnsupportedVersion},
+		{"v2 missing fields", "v2,0,0,0,0,0,0,0,1,0,1000,1,50,1.0", "", ErrFieldCount},
+		{"v2 negative seq", "v2,0,0,0,0,0,0,0,1,0,1000,-1,50,1.0,-60", "seq", ErrNotNumeric},
+		{"v2 battery out of range", "v2,0,0,0,0,0,0,0,1,0,1000,1,101,1.0,-60", "battery", ErrOutOfRange},
+		{"v2 empty firmware", "v2,0,0,0,0,0,0,0,1,0,1000,1,50, ,-60", "firmware", ErrInvalidFirmware},
+		{"v2 positive rssi", "v2,0,0,0,0,0,0,0,1,0,1000,1,50,1.0,5", "rssi", ErrOutOfRange},
+		{"v2 bad legacy field", "v2,0,0,0,0,0,0,9,1,0,1000,1,50,1.0,-60", "fall_status", ErrInvalidFallStatus},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.line)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Parse(%q) error = %v, want %v", tt.line, err, tt.err)
+			}
+			var perr *ParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("Parse(%q) error %T is not a *ParseError", tt.line, err)
+			}
+			if perr.Field != tt.field {
+				t.Errorf("Parse(%q) field = %q, want %q", tt.line, perr.Field, tt.field)
+			}
+		})
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	r := Reading{
+		AccelX: 0.5, AccelY: -9.75, AccelZ: 1.25,
+		GyroX: 100, GyroY: -50.5, GyroZ: 0,
+		FallStatus: true, BoardID: "3", FallState: StateFallConfirmed, Pressure: 1002.4,
+		Version: Version2, Seq: 42, Battery: 64, Firmware: "2.0.1", RSSI: -70,
+	}
+
+	for _, version := range []int{VersionLegacy, Version2} {
+		line, err := r.EncodeVersion(version)
+		if err != nil {
+			t.Fatalf("EncodeVersion(%d): %v", version, err)
+		}
+		got, err := Parse(line)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", line, err)
+		}
+
+		want := r
+		if version == VersionLegacy {
+			want = Reading{
+				AccelX: r.AccelX, AccelY: r.AccelY, AccelZ: r.AccelZ,
+				GyroX: r.GyroX, GyroY: r.GyroY, GyroZ: r.GyroZ,
+				FallStatus: r.FallStatus, BoardID: r.BoardID, FallState: r.FallState, Pressure: r.Pressure,
+				Version: VersionLegacy,
+			}
+		}
+		if got != want {
+			t.Errorf("v%d round trip = %+v, want %+v", version, got, want)
+		}
+	}
+}
+
+func TestDecoderRejectsVersionChange(t *testing.T) {
+	var d Decoder
+	if _, err := d.Decode("v2,0,0,0,0,0,0,0,1,0,1000,1,50,1.0,-60"); err != nil {
+		t.Fatalf("first line: %v", err)
+	}
+	if d.Version() != Version2 {
+		t.Fatalf("Version() = %d, want %d", d.Version(), Version2)
+	}
+	_, err := d.Decode("0,0,0,0,0,0,0,1,0,1000")
+	if !errors.Is(err, ErrVersionMismatch) {
+		t.Fatalf("legacy line after v2: error = %v, want %v", err, ErrVersionMismatch)
+	}
+}
+
+func TestIsThresholdName(t *testing.T) {
+	for name, want := range map[string]bool{
+		"FREEFALL_THRESHOLD":     true,
+		"LYING_DETECTION_TIME":   true,
+		"PROFILE_VERSION":        false,
+		"freefall_threshold":     false,
+		"FREEFALL_THRESHOLD,X=1": false,
+		"X=1\nCMD,1,RESET_FALL":  false,
+	} {
+		if got := IsThresholdName(name); got != want {
+			t.Errorf("IsThresholdName(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
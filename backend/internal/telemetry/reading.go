@@ -0,0 +1,135 @@
+package telemetry
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// FallState mirrors the firmware's FallState enum in main.c.
+type FallState int
+
+const (
+	StateNormal FallState = iota
+	StateFreefallDetected
+	StateImpactDetected
+	StateFallConfirmed
+)
+
+func (s FallState) String() string {
+	switch s {
+	case StateNormal:
+		return "normal"
+	case StateFreefallDetected:
+		return "freefall_detected"
+	case StateImpactDetected:
+		return "impact_detected"
+	case StateFallConfirmed:
+		return "fall_confirmed"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(s))
+	}
+}
+
+func (s FallState) Valid() bool {
+	return s >= StateNormal && s <= StateFallConfirmed
+}
+
+// Reading is a single telemetry sample sent by a board every WIFI_INTERVAL_MS.
+type Reading struct {
+	AccelX float64 // m/s²
+	AccelY float64
+	AccelZ float64
+	GyroX  float64
+	GyroY  float64
+	GyroZ  float64
+
+	FallStatus bool // true while the board is sounding a confirmed fall
+	BoardID    string
+	FallState  FallState
+	Pressure   float64 // hPa
+
+	// Version is the wire format the reading arrived in. The fields below are
+	// only populated from Version2 onwards.
+	Version  int
+	Seq      uint32
+	Battery  float64 // percent
+	Firmware string
+	RSSI     int // dBm
+}
+
+// HasSeq reports whether the reading carries a sequence number. Legacy
+// firmware does not number its frames.
+func (r Reading) HasSeq() bool {
+	return r.Version >= Version2
+}
+
+// AccelMagnitude returns the magnitude of the acceleration vector, as computed
+// by the firmware's state machine.
+func (r Reading) AccelMagnitude() float64 {
+	return math.Sqrt(r.AccelX*r.AccelX + r.AccelY*r.AccelY + r.AccelZ*r.AccelZ)
+}
+
+// GyroMagnitude returns the magnitude of the angular velocity vector.
+func (r Reading) GyroMagnitude() float64 {
+	return math.Sqrt(r.GyroX*r.GyroX + r.GyroY*r.GyroY + r.GyroZ*r.GyroZ)
+}
+
+// Topic returns the board segment used in MQTT topics and subscriptions,
+// e.g. "board1".
+func (r Reading) Topic() string {
+	return "board" + r.BoardID
+}
+
+// Encode serialises the reading in the firmware's 10-field CSV layout, without
+// a trailing newline. This is the payload format the dashboard parses.
+func (r Reading) Encode() string {
+	return strings.Join(r.legacyFields(), ",")
+}
+
+// EncodeVersion serialises the reading in the given wire format, without a
+// trailing newline.
+func (r Reading) EncodeVersion(version int) (string, error) {
+	switch version {
+	case VersionLegacy:
+		return r.Encode(), nil
+	case Version2:
+		fields := append([]string{"v2"}, r.legacyFields()...)
+		fields = append(fields,
+			strconv.FormatUint(uint64(r.Seq), 10),
+			formatFloat(r.Battery),
+			r.Firmware,
+			strconv.Itoa(r.RSSI),
+		)
+		return strings.Join(fields, ","), nil
+	default:
+		return "", fmt.Errorf("telemetry: %w: %d", ErrUnsupportedVersion, version)
+	}
+}
+
+func (r Reading) legacyFields() []string {
+	return []string{
+		formatFloat(r.AccelX),
+		formatFloat(r.AccelY),
+		formatFloat(r.AccelZ),
+		formatFloat(r.GyroX),
+		formatFloat(r.GyroY),
+		formatFloat(r.GyroZ),
+		formatBool(r.FallStatus),
+		r.BoardID,
+		strconv.Itoa(int(r.FallState)),
+		formatFloat(r.Pressure),
+	}
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', 2, 64)
+}
+
+func formatBool(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
@@ -0,0 +1,105 @@
+package telemetry
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Thresholds are the tunable constants of the firmware's fall-detection state
+// machine. Field comments give the matching #define in main.c.
+type Thresholds struct {
+	FreefallThreshold     float64       // FREEFALL_THRESHOLD, m/s²
+	ImpactThreshold       float64       // IMPACT_THRESHOLD, m/s²
+	ImpactDelta           float64       // IMPACT_DELTA, m/s² change between samples
+	ImpactGyroMax         float64       // IMPACT_GYRO_MAX
+	GyroThreshold         float64       // GYRO_THRESHOLD
+	LyingThreshold        float64       // LYING_THRESHOLD, m/s²
+	FallDetectionTime     time.Duration // FALL_DETECTION_TIME
+	LyingDetectionTime    time.Duration // LYING_DETECTION_TIME
+	PressureFallThreshold float64       // PRESSURE_FALL_THRESHOLD, hPa
+}
+
+// DefaultThresholds returns the values compiled into the firmware.
+func DefaultThresholds() Thresholds {
+	return Thresholds{
+		FreefallThreshold:     6.5,
+		ImpactThreshold:       13.0,
+		ImpactDelta:           2.0,
+		ImpactGyroMax:         200.0,
+		GyroThreshold:         1200.0,
+		LyingThreshold:        11.0,
+		FallDetectionTime:     2000 * time.Millisecond,
+		LyingDetectionTime:    2000 * time.Millisecond,
+		PressureFallThreshold: 0.08,
+	}
+}
+
+var ErrInvalidThresholds = errors.New("invalid thresholds")
+
+// thresholdNames are the #defines the firmware accepts in SET_THRESHOLDS,
+// apart from PROFILE_VERSION, which only the server sets.
+var thresholdNames = map[string]bool{
+	"FREEFALL_THRESHOLD":      true,
+	"IMPACT_THRESHOLD":        true,
+	"IMPACT_DELTA":            true,
+	"IMPACT_GYRO_MAX":         true,
+	"GYRO_THRESHOLD":          true,
+	"LYING_THRESHOLD":         true,
+	"FALL_DETECTION_TIME":     true,
+	"LYING_DETECTION_TIME":    true,
+	"PRESSURE_FALL_THRESHOLD": true,
+}
+
+// IsThresholdName reports whether name is a threshold the firmware can be
+// sent, e.g. "FREEFALL_THRESHOLD". Names go into the command line as is, so
+// only these may be.
+func IsThresholdName(name string) bool {
+	return thresholdNames[name]
+}
+
+func (t Thresholds) Validate() error {
+	positive := map[string]float64{
+		"FREEFALL_THRESHOLD":      t.FreefallThreshold,
+		"IMPACT_THRESHOLD":        t.ImpactThreshold,
+		"IMPACT_DELTA":            t.ImpactDelta,
+		"IMPACT_GYRO_MAX":         t.ImpactGyroMax,
+		"GYRO_THRESHOLD":          t.GyroThreshold,
+		"LYING_THRESHOLD":         t.LyingThreshold,
+		"PRESSURE_FALL_THRESHOLD": t.PressureFallThreshold,
+	}
+	for name, v := range positive {
+		if v <= 0 {
+			return fmt.Errorf("%w: %s must be positive", ErrInvalidThresholds, name)
+		}
+	}
+	if t.FreefallThreshold >= t.ImpactThreshold {
+		return fmt.Errorf("%w: FREEFALL_THRESHOLD must be below IMPACT_THRESHOLD", ErrInvalidThresholds)
+	}
+	for name, d := range map[string]time.Duration{
+		"FALL_DETECTION_TIME":  t.FallDetectionTime,
+		"LYING_DETECTION_TIME": t.LyingDetectionTime,
+	} {
+		if d < 100*time.Millisecond || d > time.Minute {
+			return fmt.Errorf("%w: %s must be between 100ms and 1m", ErrInvalidThresholds, name)
+		}
+	}
+	return nil
+}
+
+// Command builds the SET_THRESHOLDS command for a stored profile version. The
+// version travels with the values so the board can report what it runs.
+func (t Thresholds) Command(profileVersion int) Command {
+	return NewSetThresholds(map[string]float64{
+		"FREEFALL_THRESHOLD":      t.FreefallThreshold,
+		"IMPACT_THRESHOLD":        t.ImpactThreshold,
+		"IMPACT_DELTA":            t.ImpactDelta,
+		"IMPACT_GYRO_MAX":         t.ImpactGyroMax,
+		"GYRO_THRESHOLD":          t.GyroThreshold,
+		"LYING_THRESHOLD":         t.LyingThreshold,
+		"FALL_DETECTION_TIME":     float64(t.FallDetectionTime.Milliseconds()),
+		"LYING_DETECTION_TIME":    float64(t.LyingDetectionTime.Milliseconds()),
+		"PRESSURE_FALL_THRESHOLD": t.PressureFallThreshold,
+		"PROFILE_VERSION":         float64(profileVersion),
+	})
+}
@@ -0,0 +1,111 @@
+package telemetry
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Wire format versions. Legacy firmware sends the bare 10-field CSV line with
+// no header; newer firmware prefixes every line with "v<N>,".
+//
+//	v1: ax,ay,az,gx,gy,gz,fallStatus,board,fallState,pressure
+//	v2: v2,<v1 fields>,seq,battery,firmware,rssi
+const (
+	VersionLegacy = 1
+	Version2      = 2
+
+	// LatestVersion is the newest format this server understands.
+	LatestVersion = Version2
+)
+
+// v2FieldCount includes the "v2" header.
+const v2FieldCount = 1 + FieldCount + 4
+
+var (
+	ErrUnsupportedVersion = errors.New("unsupported protocol version")
+	ErrVersionMismatch    = errors.New("protocol version changed mid-connection")
+)
+
+// detectVersion inspects the first field of a line for a "v<N>" header.
+func detectVersion(fields []string) (int, error) {
+	head := strings.TrimSpace(fields[0])
+	if !strings.HasPrefix(head, "v") {
+		return VersionLegacy, nil
+	}
+	v, err := strconv.Atoi(head[1:])
+	if err != nil || v < Version2 || v > LatestVersion {
+		return 0, &ParseError{Field: "version", Value: head, Err: ErrUnsupportedVersion}
+	}
+	return v, nil
+}
+
+func parseV2(fields []string) (Reading, error) {
+	r, err := parseFields(fields[:FieldCount])
+	if err != nil {
+		return Reading{}, err
+	}
+	r.Version = Version2
+
+	ext := fields[FieldCount:]
+
+	seq := strings.TrimSpace(ext[0])
+	n, err := strconv.ParseUint(seq, 10, 32)
+	if err != nil {
+		return Reading{}, &ParseError{Field: "seq", Value: seq, Err: ErrNotNumeric}
+	}
+	r.Seq = uint32(n)
+
+	battery := strings.TrimSpace(ext[1])
+	if r.Battery, err = strconv.ParseFloat(battery, 64); err != nil {
+		return Reading{}, &ParseError{Field: "battery", Value: battery, Err: ErrNotNumeric}
+	}
+	if r.Battery < 0 || r.Battery > 100 {
+		return Reading{}, &ParseError{Field: "battery", Value: battery, Err: fmt.Errorf("%w: want [0, 100]", ErrOutOfRange)}
+	}
+
+	r.Firmware = strings.TrimSpace(ext[2])
+	if r.Firmware == "" {
+		return Reading{}, &ParseError{Field: "firmware", Err: ErrInvalidFirmware}
+	}
+
+	rssi := strings.TrimSpace(ext[3])
+	if r.RSSI, err = strconv.Atoi(rssi); err != nil {
+		return Reading{}, &ParseError{Field: "rssi", Value: rssi, Err: ErrNotNumeric}
+	}
+	if r.RSSI < -120 || r.RSSI > 0 {
+		return Reading{}, &ParseError{Field: "rssi", Value: rssi, Err: fmt.Errorf("%w: want [-120, 0]", ErrOutOfRange)}
+	}
+
+	return r, nil
+}
+
+// Decoder parses the lines of a single board connection. The wire format is
+// negotiated from the first valid line; later lines in a different format are
+// rejected so a board cannot silently flip layouts mid-stream.
+type Decoder struct {
+	version int
+}
+
+// Version returns the negotiated wire format, or 0 before the first valid line.
+func (d *Decoder) Version() int {
+	return d.version
+}
+
+func (d *Decoder) Decode(line string) (Reading, error) {
+	r, err := Parse(line)
+	if err != nil {
+		return Reading{}, err
+	}
+	if d.version == 0 {
+		d.version = r.Version
+	} else if r.Version != d.version {
+		return Reading{}, &ParseError{
+			Field: "version",
+			Value: strconv.Itoa(r.Version),
+			Err:   fmt.Errorf("%w: negotiated v%d", ErrVersionMismatch, d.version),
+		}
+	}
+	return r, nil
+}
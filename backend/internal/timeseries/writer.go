@@ -0,0 +1,80 @@
+package timeseries
+
+import (
+	"context"
+	"fall-detection/internal/repository"
+	"fall-detection/internal/telemetry"
+	"log"
+	"time"
+)
+
+const (
+	// Roughly one flush per second with ~10 boards streaming at 5 Hz.
+	defaultBatchSize     = 500
+	defaultFlushInterval = time.Second
+
+	// queueSize bounds memory if the database is slow or down. Readings past
+	// this are dropped rather than stalling the TCP read loops.
+	queueSize = 10000
+)
+
+// Writer batches telemetry readings and persists them with COPY in the
+// background.
+type Writer struct {
+	repo          *repository.SensorReadingRepo
+	queue         chan repository.SensorReading
+	batchSize     int
+	flushInterval time.Duration
+}
+
+func NewWriter(repo *repository.SensorReadingRepo) *Writer {
+	return &Writer{
+		repo:          repo,
+		queue:         make(chan repository.SensorReading, queueSize),
+		batchSize:     defaultBatchSize,
+		flushInterval: defaultFlushInterval,
+	}
+}
+
+// Add queues a reading without blocking.
+func (w *Writer) Add(r telemetry.Reading, receivedAt time.Time) {
+	select {
+	case w.queue <- repository.SensorReading{BoardID: r.Topic(), ReceivedAt: receivedAt, Reading: r}:
+	default:
+		log.Printf("[Timeseries] Queue full, dropping reading from %s", r.Topic())
+	}
+}
+
+// Run flushes queued readings whenever a batch fills up or the flush interval
+// elapses. It blocks forever.
+func (w *Writer) Run() {
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]repository.SensorReading, 0, w.batchSize)
+	for {
+		select {
+		case r := <-w.queue:
+			batch = append(batch, r)
+			if len(batch) < w.batchSize {
+				continue
+			}
+		case <-ticker.C:
+			if len(batch) == 0 {
+				continue
+			}
+		}
+
+		w.flush(batch)
+		batch = batch[:0]
+	}
+}
+
+func (w *Writer) flush(batch []repository.SensorReading) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := w.repo.CopyInsert(ctx, batch); err != nil {
+		log.Printf("[Timeseries] Failed to write %d readings: %v", len(batch), err)
+	}
+}
@@ -0,0 +1 @@
+DROP TABLE board_credentials;
\ No newline at end of file
@@ -0,0 +1,6 @@
+CREATE TABLE board_credentials (
+    board_id VARCHAR(255) PRIMARY KEY,
+    secret BYTEA NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
\ No newline at end of file
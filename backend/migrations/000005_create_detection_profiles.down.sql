@@ -0,0 +1 @@
+DROP TABLE detection_profiles;
\ No newline at end of file
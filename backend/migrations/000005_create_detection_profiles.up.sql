@@ -0,0 +1,16 @@
+CREATE TABLE detection_profiles (
+    board_id VARCHAR(255) PRIMARY KEY,
+    version INT NOT NULL DEFAULT 1,
+    freefall_threshold DOUBLE PRECISION NOT NULL,
+    impact_threshold DOUBLE PRECISION NOT NULL,
+    impact_delta DOUBLE PRECISION NOT NULL,
+    impact_gyro_max DOUBLE PRECISION NOT NULL,
+    gyro_threshold DOUBLE PRECISION NOT NULL,
+    lying_threshold DOUBLE PRECISION NOT NULL,
+    fall_detection_time_ms INT NOT NULL,
+    lying_detection_time_ms INT NOT NULL,
+    pressure_fall_threshold DOUBLE PRECISION NOT NULL,
+    acked_version INT NOT NULL DEFAULT 0,  -- last version the board acknowledged
+    acked_at TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
\ No newline at end of file
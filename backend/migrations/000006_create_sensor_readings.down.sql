@@ -0,0 +1 @@
+DROP TABLE sensor_readings;
\ No newline at end of file
@@ -0,0 +1,16 @@
+CREATE TABLE sensor_readings (
+    board_id VARCHAR(255) NOT NULL,
+    received_at TIMESTAMP NOT NULL,
+    accel_x REAL NOT NULL,
+    accel_y REAL NOT NULL,
+    accel_z REAL NOT NULL,
+    gyro_x REAL NOT NULL,
+    gyro_y REAL NOT NULL,
+    gyro_z REAL NOT NULL,
+    fall_status BOOLEAN NOT NULL,
+    fall_state SMALLINT NOT NULL,
+    pressure REAL NOT NULL,
+    seq BIGINT  -- v2+ firmware only
+);
+
+CREATE INDEX sensor_readings_board_time_idx ON sensor_readings (board_id, received_at);
\ No newline at end of file
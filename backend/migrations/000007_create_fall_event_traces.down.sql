@@ -0,0 +1 @@
+DROP TABLE fall_event_traces;
\ No newline at end of file
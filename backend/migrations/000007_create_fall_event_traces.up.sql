@@ -0,0 +1,16 @@
+CREATE TABLE fall_event_traces (
+    fall_event_id INT NOT NULL REFERENCES fall_events(id) ON DELETE CASCADE,
+    received_at TIMESTAMP NOT NULL,
+    accel_x REAL NOT NULL,
+    accel_y REAL NOT NULL,
+    accel_z REAL NOT NULL,
+    gyro_x REAL NOT NULL,
+    gyro_y REAL NOT NULL,
+    gyro_z REAL NOT NULL,
+    fall_status BOOLEAN NOT NULL,
+    fall_state SMALLINT NOT NULL,
+    pressure REAL NOT NULL,
+    seq BIGINT  -- v2+ firmware only
+);
+
+CREATE INDEX fall_event_traces_event_idx ON fall_event_traces (fall_event_id, received_at);
\ No newline at end of file
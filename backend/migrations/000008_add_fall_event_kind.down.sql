@@ -0,0 +1 @@
+ALTER TABLE fall_events DROP COLUMN kind;
\ No newline at end of file
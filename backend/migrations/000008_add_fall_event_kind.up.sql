@@ -0,0 +1 @@
+ALTER TABLE fall_events ADD COLUMN kind VARCHAR(50) NOT NULL DEFAULT 'fall';  -- fall, inactivity
\ No newline at end of file
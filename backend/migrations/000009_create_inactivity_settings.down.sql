@@ -0,0 +1 @@
+DROP TABLE inactivity_settings;
\ No newline at end of file
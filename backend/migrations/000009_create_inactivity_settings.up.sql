@@ -0,0 +1,8 @@
+CREATE TABLE inactivity_settings (
+    board_id VARCHAR(255) PRIMARY KEY,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    period_secs INT NOT NULL,
+    quiet_start_min SMALLINT,  -- minutes after local midnight, NULL for no quiet hours
+    quiet_end_min SMALLINT,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
\ No newline at end of file
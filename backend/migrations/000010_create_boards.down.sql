@@ -0,0 +1 @@
+DROP TABLE boards;
\ No newline at end of file
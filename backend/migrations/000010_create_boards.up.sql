@@ -0,0 +1,10 @@
+CREATE TABLE boards (
+    id VARCHAR(255) PRIMARY KEY,  -- e.g. board1, as used in topics and subscriptions
+    display_name VARCHAR(255) NOT NULL DEFAULT '',
+    location VARCHAR(255) NOT NULL DEFAULT '',  -- room or area
+    firmware_version VARCHAR(64) NOT NULL DEFAULT '',
+    first_seen_at TIMESTAMP,
+    last_seen_at TIMESTAMP,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE fall_events DROP COLUMN resident_id;
+DROP TABLE board_assignments;
+DROP TABLE residents;
\ No newline at end of file
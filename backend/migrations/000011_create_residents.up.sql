@@ -0,0 +1,26 @@
+CREATE TABLE residents (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    room VARCHAR(255) NOT NULL DEFAULT '',
+    care_notes TEXT NOT NULL DEFAULT '',
+    emergency_contacts JSONB NOT NULL DEFAULT '[]',  -- [{"name", "phone", "relation"}]
+    fall_risk VARCHAR(50) NOT NULL DEFAULT 'medium',  -- low, medium, high
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE board_assignments (
+    id SERIAL PRIMARY KEY,
+    board_id VARCHAR(255) NOT NULL,
+    resident_id INT NOT NULL REFERENCES residents(id) ON DELETE CASCADE,
+    assigned_at TIMESTAMP NOT NULL,
+    unassigned_at TIMESTAMP  -- NULL while current
+);
+
+-- A board is worn by one resident at a time, and a resident wears one board
+CREATE UNIQUE INDEX board_assignments_current_board_idx ON board_assignments (board_id) WHERE unassigned_at IS NULL;
+CREATE UNIQUE INDEX board_assignments_current_resident_idx ON board_assignments (resident_id) WHERE unassigned_at IS NULL;
+
+-- Falls follow the resident across board swaps
+ALTER TABLE fall_events ADD COLUMN resident_id INT REFERENCES residents(id) ON DELETE SET NULL;
+CREATE INDEX fall_events_resident_idx ON fall_events (resident_id, detected_at);
\ No newline at end of file
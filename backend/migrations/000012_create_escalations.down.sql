@@ -0,0 +1,2 @@
+DROP TABLE fall_event_escalations;
+DROP TABLE escalation_steps;
\ No newline at end of file
@@ -0,0 +1,21 @@
+CREATE TABLE escalation_steps (
+    board_id VARCHAR(255) NOT NULL,
+    position INT NOT NULL,  -- 0-based order in the chain
+    delay_secs INT NOT NULL,  -- after the event was raised
+    chat_id BIGINT,  -- Telegram chat to notify, NULL for the board's subscribers
+    label VARCHAR(255) NOT NULL DEFAULT '',
+    PRIMARY KEY (board_id, position)
+);
+
+CREATE TABLE fall_event_escalations (
+    id SERIAL PRIMARY KEY,
+    fall_event_id INT NOT NULL REFERENCES fall_events(id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    label VARCHAR(255) NOT NULL DEFAULT '',
+    status VARCHAR(50) NOT NULL,  -- notified, cancelled
+    recipients INT NOT NULL DEFAULT 0,
+    reason VARCHAR(50),  -- seen, resolved, expired for cancelled steps
+    recorded_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX fall_event_escalations_event_idx ON fall_event_escalations (fall_event_id);
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE fall_events DROP COLUMN resolved_via;
+DROP TABLE event_acknowledgements;
\ No newline at end of file
@@ -0,0 +1,12 @@
+CREATE TABLE event_acknowledgements (
+    id SERIAL PRIMARY KEY,
+    fall_event_id INT NOT NULL REFERENCES fall_events(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL,  -- Telegram user, so people sharing a group chat are told apart
+    display_name VARCHAR(255) NOT NULL DEFAULT '',  -- @username or first name at the time
+    acknowledged_at TIMESTAMP NOT NULL,
+    UNIQUE (fall_event_id, user_id)
+);
+
+-- How an event was cleared: nfc, remote (a RESET_FALL command) or movement.
+-- resolved_by is the Telegram user who sent a remote reset.
+ALTER TABLE fall_events ADD COLUMN resolved_via VARCHAR(20);
\ No newline at end of file
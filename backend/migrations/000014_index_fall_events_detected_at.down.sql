@@ -0,0 +1 @@
+DROP INDEX fall_events_kind_detected_at_idx;
\ No newline at end of file
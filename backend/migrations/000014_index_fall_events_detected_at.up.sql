@@ -0,0 +1 @@
+CREATE INDEX fall_events_kind_detected_at_idx ON fall_events (kind, detected_at);
\ No newline at end of file
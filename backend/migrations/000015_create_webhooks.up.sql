@@ -0,0 +1,26 @@
+CREATE TABLE webhooks (
+    id SERIAL PRIMARY KEY,
+    board_id VARCHAR(255) NOT NULL,
+    url TEXT NOT NULL,
+    secret VARCHAR(255) NOT NULL,  -- HMAC-SHA256 key for the X-Webhook-Signature header
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX webhooks_board_id_idx ON webhooks (board_id);
+
+CREATE TABLE webhook_deliveries (
+    id SERIAL PRIMARY KEY,
+    webhook_id INT NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    delivery_id VARCHAR(64) NOT NULL,  -- Same for every attempt at one payload
+    event_type VARCHAR(50) NOT NULL,
+    fall_event_id INT,
+    attempt INT NOT NULL,  -- 1-based
+    status_code INT,  -- NULL when no response was received
+    error TEXT,
+    succeeded BOOLEAN NOT NULL,
+    duration_ms INT NOT NULL,
+    attempted_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX webhook_deliveries_webhook_idx ON webhook_deliveries (webhook_id, attempted_at);
\ No newline at end of file
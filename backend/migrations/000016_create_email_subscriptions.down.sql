@@ -0,0 +1 @@
+DROP TABLE email_subscriptions;
\ No newline at end of file
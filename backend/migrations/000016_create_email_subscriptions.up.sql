@@ -0,0 +1,11 @@
+CREATE TABLE email_subscriptions (
+    id SERIAL PRIMARY KEY,
+    email VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    board_id VARCHAR(255) NOT NULL,
+    approved_by VARCHAR(255) NOT NULL,  -- Admin who added the address
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (email, board_id)
+);
+
+CREATE INDEX email_subscriptions_board_id_idx ON email_subscriptions (board_id);
\ No newline at end of file
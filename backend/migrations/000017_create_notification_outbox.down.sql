@@ -0,0 +1,2 @@
+DROP TABLE notification_outbox_recipients;
+DROP TABLE notification_outbox;
\ No newline at end of file
@@ -0,0 +1,26 @@
+CREATE TABLE notification_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    channel VARCHAR(50) NOT NULL,  -- Notifier name: telegram, webhooks, email
+    type VARCHAR(50) NOT NULL,  -- fall_detected, seen, resolved, expired, board_offline, board_online
+    board_id VARCHAR(255) NOT NULL,
+    fall_event_id INT REFERENCES fall_events(id) ON DELETE CASCADE,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',  -- pending, delivered, dead
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    delivered_at TIMESTAMP
+);
+
+CREATE INDEX notification_outbox_due_idx ON notification_outbox (channel, next_attempt_at) WHERE status = 'pending';
+CREATE INDEX notification_outbox_status_idx ON notification_outbox (status, created_at);
+
+-- Recipients a message has reached, so a retry after a partial failure only
+-- goes to the rest
+CREATE TABLE notification_outbox_recipients (
+    outbox_id BIGINT NOT NULL REFERENCES notification_outbox(id) ON DELETE CASCADE,
+    recipient VARCHAR(255) NOT NULL,  -- Chat ID, email address or webhook ID
+    delivered_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (outbox_id, recipient)
+);
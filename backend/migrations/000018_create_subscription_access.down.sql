@@ -0,0 +1,7 @@
+ALTER TABLE subscriptions DROP COLUMN approved_at;
+ALTER TABLE subscriptions DROP COLUMN invite_id;
+ALTER TABLE subscriptions DROP COLUMN approved_by;
+ALTER TABLE subscriptions DROP COLUMN approval_method;
+DROP TABLE subscription_requests;
+DROP TABLE subscription_invites;
+DROP TABLE subscription_access;
\ No newline at end of file
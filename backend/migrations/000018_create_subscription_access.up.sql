@@ -0,0 +1,40 @@
+CREATE TABLE subscription_access (
+    board_id VARCHAR(255) PRIMARY KEY,
+    pairing_code VARCHAR(32),  -- NULL when the board has no pairing code
+    approval_required BOOLEAN NOT NULL DEFAULT FALSE,  -- /subscribe files a request for an admin to approve
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE subscription_invites (
+    id SERIAL PRIMARY KEY,
+    board_id VARCHAR(255) NOT NULL,
+    token VARCHAR(64) NOT NULL UNIQUE,
+    note VARCHAR(255) NOT NULL DEFAULT '',  -- who the invite is for
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    used_by_chat_id BIGINT
+);
+
+CREATE INDEX subscription_invites_board_id_idx ON subscription_invites (board_id);
+
+CREATE TABLE subscription_requests (
+    id SERIAL PRIMARY KEY,
+    chat_id BIGINT NOT NULL,
+    board_id VARCHAR(255) NOT NULL,
+    first_name VARCHAR(255) NOT NULL DEFAULT '',
+    username VARCHAR(255) NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',  -- pending, approved, rejected
+    requested_at TIMESTAMP NOT NULL,
+    decided_at TIMESTAMP,
+    decided_by VARCHAR(255)
+);
+
+CREATE UNIQUE INDEX subscription_requests_pending_idx ON subscription_requests (chat_id, board_id) WHERE status = 'pending';
+
+-- Subscriptions made before pairing codes existed are marked legacy
+ALTER TABLE subscriptions ADD COLUMN approval_method VARCHAR(20) NOT NULL DEFAULT 'legacy';  -- pairing_code, invite, admin
+ALTER TABLE subscriptions ADD COLUMN approved_by VARCHAR(255) NOT NULL DEFAULT '';  -- admin, or whoever created the invite
+ALTER TABLE subscriptions ADD COLUMN invite_id INT REFERENCES subscription_invites(id) ON DELETE SET NULL;
+ALTER TABLE subscriptions ADD COLUMN approved_at TIMESTAMP;
\ No newline at end of file
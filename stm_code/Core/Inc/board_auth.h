@@ -0,0 +1,28 @@
+/******************************************************************************
+ * @file           : board_auth.h
+ * @brief          : Board side of the server's authentication handshake
+ ******************************************************************************/
+
+#ifndef __BOARD_AUTH_H
+#define __BOARD_AUTH_H
+
+#include <stdint.h>
+
+#define BOARD_SECRET_SIZE 32
+
+// Runs the handshake on an open socket before any telemetry is sent:
+//
+//   board  -> HELLO,<boardID>
+//   server -> CHALLENGE,<hex nonce>
+//   board  -> AUTH,<hex HMAC-SHA256(secret, nonce || boardID)>
+//   server -> OK | DENIED
+//
+// The secret is the one returned by POST /boards/board<N>/credentials.
+// Returns 0 once the server answers OK, -1 otherwise.
+int board_authenticate(uint32_t socket, int board_number, const uint8_t secret[BOARD_SECRET_SIZE]);
+
+// Decodes a 64-character hex secret. Returns 0 on success, -1 if it is
+// malformed.
+int board_secret_from_hex(const char *hex, uint8_t secret[BOARD_SECRET_SIZE]);
+
+#endif /* __BOARD_AUTH_H */
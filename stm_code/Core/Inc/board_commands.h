@@ -0,0 +1,42 @@
+/******************************************************************************
+ * @file           : board_commands.h
+ * @brief          : Board side of the server's downlink commands
+ ******************************************************************************/
+
+#ifndef __BOARD_COMMANDS_H
+#define __BOARD_COMMANDS_H
+
+#include <stdint.h>
+
+// Fall detection thresholds. They start as main.c's #defines and are
+// replaced by SET_THRESHOLDS, in RAM only, so the server pushes the stored
+// profile again on every connect.
+typedef struct {
+    float    freefall;             // FREEFALL_THRESHOLD
+    float    impact;               // IMPACT_THRESHOLD
+    float    impact_delta;         // IMPACT_DELTA
+    float    impact_gyro_max;      // IMPACT_GYRO_MAX
+    float    gyro;                 // GYRO_THRESHOLD
+    float    lying;                // LYING_THRESHOLD
+    uint32_t fall_detection_ms;    // FALL_DETECTION_TIME
+    uint32_t lying_detection_ms;   // LYING_DETECTION_TIME
+    float    pressure_fall;        // PRESSURE_FALL_THRESHOLD
+    uint32_t profile_version;      // PROFILE_VERSION, 0 for the defaults
+} board_thresholds;
+
+// Reads any command lines the server has sent, without blocking the
+// sampling loop, and answers each with an ack carrying the same ID:
+//
+//   server -> CMD,<id>,<type>[,<arg>...]
+//   board  -> ACK,<id>,OK|ERR[,<detail>]
+//
+// See backend/internal/telemetry/command.go.
+void board_commands_poll(uint32_t socket, board_thresholds *thresholds);
+
+// Implemented by main.c, which owns the buzzer and the fall state.
+void board_command_silence(void);
+void board_command_locate(void);
+int  board_command_reset_fall(void);    // -1 if there is no fall to clear
+void board_command_status(char *detail, uint32_t size);
+
+#endif /* __BOARD_COMMANDS_H */
@@ -0,0 +1,275 @@
+/******************************************************************************
+ * @file           : board_auth.c
+ * @brief          : HMAC-SHA256 challenge/response with the backend, see
+ *                   backend/internal/telemetry/handshake.go
+ ******************************************************************************/
+
+#include "board_auth.h"
+#include "main.h"
+
+#include <stdio.h>
+#include <string.h>
+
+#define AUTH_TIMEOUT_MS  5000
+#define NONCE_SIZE       32
+#define LINE_SIZE        128
+
+// ---- SHA-256 (FIPS 180-4) ---------------------------------------------------
+
+typedef struct {
+    uint32_t state[8];
+    uint64_t length;     // bytes hashed so far
+    uint8_t  block[64];
+    uint32_t used;       // bytes waiting in block
+} sha256_ctx;
+
+static const uint32_t sha256_k[64] = {
+    0x428a2f98, 0x71374491, 0xb5c0fbcf, 0xe9b5dba5, 0x3956c25b, 0x59f111f1, 0x923f82a4, 0xab1c5ed5,
+    0xd807aa98, 0x12835b01, 0x243185be, 0x550c7dc3, 0x72be5d74, 0x80deb1fe, 0x9bdc06a7, 0xc19bf174,
+    0xe49b69c1, 0xefbe4786, 0x0fc19dc6, 0x240ca1cc, 0x2de92c6f, 0x4a7484aa, 0x5cb0a9dc, 0x76f988da,
+    0x983e5152, 0xa831c66d, 0xb00327c8, 0xbf597fc7, 0xc6e00bf3, 0xd5a79147, 0x06ca6351, 0x14292967,
+    0x27b70a85, 0x2e1b2138, 0x4d2c6dfc, 0x53380d13, 0x650a7354, 0x766a0abb, 0x81c2c92e, 0x92722c85,
+    0xa2bfe8a1, 0xa81a664b, 0xc24b8b70, 0xc76c51a3, 0xd192e819, 0xd6990624, 0xf40e3585, 0x106aa070,
+    0x19a4c116, 0x1e376c08, 0x2748774c, 0x34b0bcb5, 0x391c0cb3, 0x4ed8aa4a, 0x5b9cca4f, 0x682e6ff3,
+    0x748f82ee, 0x78a5636f, 0x84c87814, 0x8cc70208, 0x90befffa, 0xa4506ceb, 0xbef9a3f7, 0xc67178f2
+};
+
+#define ROTR(x, n) (((x) >> (n)) | ((x) << (32 - (n))))
+
+static void sha256_transform(sha256_ctx *ctx, const uint8_t *data)
+{
+    uint32_t w[64];
+    for (int i = 0; i < 16; i++)
+    {
+        w[i] = ((uint32_t)data[4*i] << 24) | ((uint32_t)data[4*i+1] << 16)
+             | ((uint32_t)data[4*i+2] << 8) | (uint32_t)data[4*i+3];
+    }
+    for (int i = 16; i < 64; i++)
+    {
+        uint32_t s0 = ROTR(w[i-15], 7) ^ ROTR(w[i-15], 18) ^ (w[i-15] >> 3);
+        uint32_t s1 = ROTR(w[i-2], 17) ^ ROTR(w[i-2], 19) ^ (w[i-2] >> 10);
+        w[i] = w[i-16] + s0 + w[i-7] + s1;
+    }
+
+    uint32_t a = ctx->state[0], b = ctx->state[1], c = ctx->state[2], d = ctx->state[3];
+    uint32_t e = ctx->state[4], f = ctx->state[5], g = ctx->state[6], h = ctx->state[7];
+
+    for (int i = 0; i < 64; i++)
+    {
+        uint32_t S1 = ROTR(e, 6) ^ ROTR(e, 11) ^ ROTR(e, 25);
+        uint32_t ch = (e & f) ^ (~e & g);
+        uint32_t t1 = h + S1 + ch + sha256_k[i] + w[i];
+        uint32_t S0 = ROTR(a, 2) ^ ROTR(a, 13) ^ ROTR(a, 22);
+        uint32_t maj = (a & b) ^ (a & c) ^ (b & c);
+        uint32_t t2 = S0 + maj;
+
+        h = g; g = f; f = e; e = d + t1;
+        d = c; c = b; b = a; a = t1 + t2;
+    }
+
+    ctx->state[0] += a; ctx->state[1] += b; ctx->state[2] += c; ctx->state[3] += d;
+    ctx->state[4] += e; ctx->state[5] += f; ctx->state[6] += g; ctx->state[7] += h;
+}
+
+static void sha256_init(sha256_ctx *ctx)
+{
+    static const uint32_t initial[8] = {
+        0x6a09e667, 0xbb67ae85, 0x3c6ef372, 0xa54ff53a,
+        0x510e527f, 0x9b05688c, 0x1f83d9ab, 0x5be0cd19
+    };
+    memcpy(ctx->state, initial, sizeof(initial));
+    ctx->length = 0;
+    ctx->used = 0;
+}
+
+static void sha256_update(sha256_ctx *ctx, const uint8_t *data, uint32_t len)
+{
+    ctx->length += len;
+    while (len > 0)
+    {
+        uint32_t n = 64 - ctx->used;
+        if (n > len)
+            n = len;
+        memcpy(ctx->block + ctx->used, data, n);
+        ctx->used += n;
+        data += n;
+        len -= n;
+
+        if (ctx->used == 64)
+        {
+            sha256_transform(ctx, ctx->block);
+            ctx->used = 0;
+        }
+    }
+}
+
+static void sha256_final(sha256_ctx *ctx, uint8_t out[32])
+{
+    uint64_t bits = ctx->length * 8;
+
+    uint8_t pad = 0x80;
+    sha256_update(ctx, &pad, 1);
+    pad = 0;
+    while (ctx->used != 56)
+        sha256_update(ctx, &pad, 1);
+
+    uint8_t len_be[8];
+    for (int i = 0; i < 8; i++)
+        len_be[i] = (uint8_t)(bits >> (56 - 8*i));
+    sha256_update(ctx, len_be, 8);
+
+    for (int i = 0; i < 8; i++)
+    {
+        out[4*i]   = (uint8_t)(ctx->state[i] >> 24);
+        out[4*i+1] = (uint8_t)(ctx->state[i] >> 16);
+        out[4*i+2] = (uint8_t)(ctx->state[i] >> 8);
+        out[4*i+3] = (uint8_t)(ctx->state[i]);
+    }
+}
+
+// ---- HMAC-SHA256 (RFC 2104) -------------------------------------------------
+
+// Computes HMAC-SHA256(key, msg1 || msg2) for a key of at most 64 bytes.
+static void hmac_sha256(const uint8_t *key, uint32_t key_len,
+                        const uint8_t *msg1, uint32_t len1,
+                        const uint8_t *msg2, uint32_t len2,
+                        uint8_t out[32])
+{
+    uint8_t pad[64];
+    uint8_t inner[32];
+    sha256_ctx ctx;
+
+    memset(pad, 0, sizeof(pad));
+    memcpy(pad, key, key_len);
+    for (int i = 0; i < 64; i++)
+        pad[i] ^= 0x36;
+    sha256_init(&ctx);
+    sha256_update(&ctx, pad, 64);
+    sha256_update(&ctx, msg1, len1);
+    sha256_update(&ctx, msg2, len2);
+    sha256_final(&ctx, inner);
+
+    // 0x36 ^ 0x5c turns the inner pad into the outer pad
+    for (int i = 0; i < 64; i++)
+        pad[i] ^= 0x36 ^ 0x5c;
+    sha256_init(&ctx);
+    sha256_update(&ctx, pad, 64);
+    sha256_update(&ctx, inner, 32);
+    sha256_final(&ctx, out);
+}
+
+// ---- Hex ----------------------------------------------------------------------
+
+static int hex_nibble(char c)
+{
+    if (c >= '0' && c <= '9') return c - '0';
+    if (c >= 'a' && c <= 'f') return c - 'a' + 10;
+    if (c >= 'A' && c <= 'F') return c - 'A' + 10;
+    return -1;
+}
+
+// Decodes exactly len bytes from hex. Returns 0 on success, -1 otherwise.
+static int hex_decode(const char *hex, uint8_t *out, uint32_t len)
+{
+    if (strlen(hex) != 2 * len)
+        return -1;
+    for (uint32_t i = 0; i < len; i++)
+    {
+        int hi = hex_nibble(hex[2*i]);
+        int lo = hex_nibble(hex[2*i+1]);
+        if (hi < 0 || lo < 0)
+            return -1;
+        out[i] = (uint8_t)((hi << 4) | lo);
+    }
+    return 0;
+}
+
+static void hex_encode(const uint8_t *data, uint32_t len, char *out)
+{
+    static const char digits[] = "0123456789abcdef";
+    for (uint32_t i = 0; i < len; i++)
+    {
+        out[2*i]   = digits[data[i] >> 4];
+        out[2*i+1] = digits[data[i] & 0x0f];
+    }
+    out[2*len] = '\0';
+}
+
+int board_secret_from_hex(const char *hex, uint8_t secret[BOARD_SECRET_SIZE])
+{
+    return hex_decode(hex, secret, BOARD_SECRET_SIZE);
+}
+
+// ---- Handshake ----------------------------------------------------------------
+
+static int send_line(uint32_t socket, const char *line)
+{
+    uint16_t sent_len = 0;
+    uint16_t len = strlen(line);
+    if (WIFI_SendData(socket, (const uint8_t*)line, len, &sent_len, AUTH_TIMEOUT_MS) != WIFI_STATUS_OK
+        || sent_len != len)
+        return -1;
+    return 0;
+}
+
+// Reads one '\n'-terminated line without the terminator (or a trailing
+// '\r'). Returns 0 on success, -1 on timeout, error or overlong line.
+static int read_line(uint32_t socket, char *line, uint32_t size)
+{
+    uint32_t used = 0;
+    uint32_t start = HAL_GetTick();
+
+    while ((HAL_GetTick() - start) < AUTH_TIMEOUT_MS)
+    {
+        uint8_t c;
+        uint16_t got = 0;
+        if (WIFI_ReceiveData(socket, &c, 1, &got, AUTH_TIMEOUT_MS) != WIFI_STATUS_OK)
+            return -1;
+        if (got == 0)
+            continue;
+
+        if (c == '\n')
+        {
+            if (used > 0 && line[used-1] == '\r')
+                used--;
+            line[used] = '\0';
+            return 0;
+        }
+        if (used + 1 >= size)
+            return -1;
+        line[used++] = (char)c;
+    }
+    return -1;
+}
+
+int board_authenticate(uint32_t socket, int board_number, const uint8_t secret[BOARD_SECRET_SIZE])
+{
+    char line[LINE_SIZE];
+    char board_id[12];
+    uint8_t nonce[NONCE_SIZE];
+    uint8_t mac[32];
+
+    snprintf(board_id, sizeof(board_id), "%d", board_number);
+
+    snprintf(line, sizeof(line), "HELLO,%s\n", board_id);
+    if (send_line(socket, line) != 0)
+        return -1;
+
+    if (read_line(socket, line, sizeof(line)) != 0
+        || strncmp(line, "CHALLENGE,", 10) != 0
+        || hex_decode(line + 10, nonce, NONCE_SIZE) != 0)
+        return -1;
+
+    hmac_sha256(secret, BOARD_SECRET_SIZE, nonce, NONCE_SIZE,
+                (const uint8_t*)board_id, strlen(board_id), mac);
+
+    strcpy(line, "AUTH,");
+    hex_encode(mac, sizeof(mac), line + 5);
+    strcat(line, "\n");
+    if (send_line(socket, line) != 0)
+        return -1;
+
+    if (read_line(socket, line, sizeof(line)) != 0 || strcmp(line, "OK") != 0)
+        return -1;
+    return 0;
+}
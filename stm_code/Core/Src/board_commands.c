@@ -0,0 +1,213 @@
+/******************************************************************************
+ * @file           : board_commands.c
+ * @brief          : Parses server commands and sends their acks, see
+ *                   backend/internal/telemetry/command.go
+ ******************************************************************************/
+
+#include "board_commands.h"
+#include "main.h"
+
+#include <math.h>
+#include <stddef.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+
+#define POLL_TIMEOUT_MS  1       // keep the 20Hz loop on time
+#define SEND_TIMEOUT_MS  5000
+#define CHUNK_SIZE       64
+#define LINE_SIZE        384     // a full SET_THRESHOLDS line fits
+#define DETAIL_SIZE      96
+
+// ---- SET_THRESHOLDS -----------------------------------------------------------
+
+typedef enum { VALUE_FLOAT, VALUE_UINT } value_kind;
+
+static const struct {
+    const char *name;
+    value_kind  kind;
+    size_t      offset;
+} threshold_fields[] = {
+    { "FREEFALL_THRESHOLD",      VALUE_FLOAT, offsetof(board_thresholds, freefall) },
+    { "IMPACT_THRESHOLD",        VALUE_FLOAT, offsetof(board_thresholds, impact) },
+    { "IMPACT_DELTA",            VALUE_FLOAT, offsetof(board_thresholds, impact_delta) },
+    { "IMPACT_GYRO_MAX",         VALUE_FLOAT, offsetof(board_thresholds, impact_gyro_max) },
+    { "GYRO_THRESHOLD",          VALUE_FLOAT, offsetof(board_thresholds, gyro) },
+    { "LYING_THRESHOLD",         VALUE_FLOAT, offsetof(board_thresholds, lying) },
+    { "FALL_DETECTION_TIME",     VALUE_UINT,  offsetof(board_thresholds, fall_detection_ms) },
+    { "LYING_DETECTION_TIME",    VALUE_UINT,  offsetof(board_thresholds, lying_detection_ms) },
+    { "PRESSURE_FALL_THRESHOLD", VALUE_FLOAT, offsetof(board_thresholds, pressure_fall) },
+    { "PROFILE_VERSION",         VALUE_UINT,  offsetof(board_thresholds, profile_version) },
+};
+
+#define THRESHOLD_FIELD_COUNT (sizeof(threshold_fields) / sizeof(threshold_fields[0]))
+
+// Applies one NAME=value argument to t. Returns 0 on success, -1 with the
+// reason in detail otherwise.
+static int set_threshold(board_thresholds *t, char *arg, char *detail, uint32_t size)
+{
+    char *eq = strchr(arg, '=');
+    if (eq == NULL)
+    {
+        snprintf(detail, size, "expected NAME=value, got %s", arg);
+        return -1;
+    }
+    *eq = '\0';
+    const char *value = eq + 1;
+
+    for (uint32_t i = 0; i < THRESHOLD_FIELD_COUNT; i++)
+    {
+        if (strcmp(arg, threshold_fields[i].name) != 0)
+            continue;
+
+        char *end;
+        float v = strtof(value, &end);
+        if (end == value || *end != '\0' || !isfinite(v) || v < 0.0f)
+        {
+            snprintf(detail, size, "bad value for %s", arg);
+            return -1;
+        }
+
+        uint8_t *field = (uint8_t*)t + threshold_fields[i].offset;
+        if (threshold_fields[i].kind == VALUE_FLOAT)
+            *(float*)field = v;
+        else
+            *(uint32_t*)field = (uint32_t)v;
+        return 0;
+    }
+
+    snprintf(detail, size, "unknown threshold %s", arg);
+    return -1;
+}
+
+// ---- Dispatch -----------------------------------------------------------------
+
+// Splits off the next comma-separated field, or returns NULL at the end.
+static char *next_field(char **cursor)
+{
+    char *field = *cursor;
+    if (field == NULL)
+        return NULL;
+
+    char *comma = strchr(field, ',');
+    if (comma != NULL)
+    {
+        *comma = '\0';
+        *cursor = comma + 1;
+    }
+    else
+    {
+        *cursor = NULL;
+    }
+    return field;
+}
+
+static void send_ack(uint32_t socket, const char *id, int ok, const char *detail)
+{
+    char line[DETAIL_SIZE + 32];
+    uint16_t sent_len = 0;
+
+    if (detail[0] != '\0')
+        snprintf(line, sizeof(line), "ACK,%s,%s,%s\n", id, ok ? "OK" : "ERR", detail);
+    else
+        snprintf(line, sizeof(line), "ACK,%s,%s\n", id, ok ? "OK" : "ERR");
+
+    // A failed send is left to the telemetry path, which reconnects; the
+    // server times the command out in the meantime
+    WIFI_SendData(socket, (const uint8_t*)line, strlen(line), &sent_len, SEND_TIMEOUT_MS);
+}
+
+static void handle_line(uint32_t socket, char *line, board_thresholds *thresholds)
+{
+    char detail[DETAIL_SIZE] = "";
+    char *cursor = line;
+
+    char *prefix = next_field(&cursor);
+    char *id = next_field(&cursor);
+    char *type = next_field(&cursor);
+    if (prefix == NULL || strcmp(prefix, "CMD") != 0 || id == NULL || type == NULL)
+        return;
+
+    int ok = 1;
+    if (strcmp(type, "SILENCE") == 0)
+    {
+        board_command_silence();
+    }
+    else if (strcmp(type, "LOCATE") == 0)
+    {
+        board_command_locate();
+    }
+    else if (strcmp(type, "RESET_FALL") == 0)
+    {
+        if (board_command_reset_fall() != 0)
+        {
+            ok = 0;
+            snprintf(detail, sizeof(detail), "no fall to reset");
+        }
+    }
+    else if (strcmp(type, "STATUS") == 0)
+    {
+        board_command_status(detail, sizeof(detail));
+    }
+    else if (strcmp(type, "SET_THRESHOLDS") == 0)
+    {
+        // All or nothing, so a rejected profile leaves the old one running
+        board_thresholds updated = *thresholds;
+        char *arg;
+        while (ok && (arg = next_field(&cursor)) != NULL)
+        {
+            if (set_threshold(&updated, arg, detail, sizeof(detail)) != 0)
+                ok = 0;
+        }
+        if (ok)
+        {
+            *thresholds = updated;
+            snprintf(detail, sizeof(detail), "profile v%lu", (unsigned long)updated.profile_version);
+        }
+    }
+    else
+    {
+        ok = 0;
+        snprintf(detail, sizeof(detail), "unknown command %s", type);
+    }
+
+    send_ack(socket, id, ok, detail);
+}
+
+void board_commands_poll(uint32_t socket, board_thresholds *thresholds)
+{
+    static char line[LINE_SIZE];
+    static uint32_t used = 0;
+    static int overflowed = 0;   // dropping the rest of an overlong line
+
+    uint8_t chunk[CHUNK_SIZE];
+    uint16_t got = 0;
+    if (WIFI_ReceiveData(socket, chunk, sizeof(chunk), &got, POLL_TIMEOUT_MS) != WIFI_STATUS_OK)
+        return;
+
+    for (uint16_t i = 0; i < got; i++)
+    {
+        char c = (char)chunk[i];
+        if (c == '\n')
+        {
+            if (!overflowed)
+            {
+                if (used > 0 && line[used-1] == '\r')
+                    used--;
+                line[used] = '\0';
+                handle_line(socket, line, thresholds);
+            }
+            used = 0;
+            overflowed = 0;
+            continue;
+        }
+        if (overflowed)
+            continue;
+        if (used + 1 >= sizeof(line))
+        {
+            overflowed = 1;
+            continue;
+        }
+        line[used++] = c;
+    }
+}
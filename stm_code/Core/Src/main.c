@@ -11,6 +11,8 @@
 #include "../../Drivers/BSP/B-L4S5I-IOT01/stm32l4s5i_iot01_gyro.h"
 #include "../../Drivers/BSP/B-L4S5I-IOT01/stm32l4s5i_iot01_psensor.h"
 #include "../../Drivers/BSP/B-L4S5I-IOT01/stm32l4s5i_iot01_nfctag.h"
+#include "board_auth.h"
+#include "board_commands.h"
 
 #include "stdio.h"
 #include "string.h"
@@ -37,11 +39,16 @@ UART_HandleTypeDef huart1;
 #define WIFI_SOCKET    0
 #define BOARD_NUMBER 2
 
+// Handshake secret from POST /boards/board<N>/credentials, as 64 hex
+// characters. Leave empty to connect without authenticating, which only
+// works while the server has BOARD_AUTH_REQUIRED off.
+#define BOARD_SECRET_HEX ""
+
 // Buzzer pin definition - PA4 (D7)
 #define BUZZER_PIN GPIO_PIN_4
 #define BUZZER_PORT GPIOA
 
-// Fall detection thresholds
+// Fall detection thresholds (defaults until the server pushes a profile)
 #define FREEFALL_THRESHOLD  6.5f    // Must be a deeper drop, not just a quick lift
 #define IMPACT_THRESHOLD     13.0f   // Higher - only extreme spikes count as direct impact
 #define IMPACT_DELTA        2.0f
@@ -78,8 +85,22 @@ uint32_t last_buzzer_toggle = 0;
 
 static int TCPConnected = 0;
 static int fallStatus = 0;
+static int buzzer_silenced = 0;      // SILENCE command, cleared with the fall
 volatile int nfc_tap_detected = 0;
 
+static board_thresholds thresholds = {
+    .freefall           = FREEFALL_THRESHOLD,
+    .impact             = IMPACT_THRESHOLD,
+    .impact_delta       = IMPACT_DELTA,
+    .impact_gyro_max    = IMPACT_GYRO_MAX,
+    .gyro               = GYRO_THRESHOLD,
+    .lying              = LYING_THRESHOLD,
+    .fall_detection_ms  = FALL_DETECTION_TIME,
+    .lying_detection_ms = LYING_DETECTION_TIME,
+    .pressure_fall      = PRESSURE_FALL_THRESHOLD,
+    .profile_version    = 0,
+};
+
 uint8_t remote_ip[4] = {66, 33, 22, 238};
 
 // Barometer globals
@@ -173,6 +194,31 @@ int wifiConnect(uint8_t out_ip[4])
     return 0;
 }
 
+// Proves the board's identity to the server on a freshly opened socket,
+// closing it if the server does not accept. A no-op without a secret.
+static int wifiTCPAuthenticate(void)
+{
+    uint8_t secret[BOARD_SECRET_SIZE];
+
+    if (strlen(BOARD_SECRET_HEX) == 0)
+        return 0;
+
+    if (board_secret_from_hex(BOARD_SECRET_HEX, secret) != 0)
+    {
+        uart_log("> ERROR : BOARD_SECRET_HEX must be 64 hex characters\r\n");
+        WIFI_CloseClientConnection(WIFI_SOCKET);
+        return -1;
+    }
+    if (board_authenticate(WIFI_SOCKET, BOARD_NUMBER, secret) != 0)
+    {
+        uart_log("> ERROR : Server rejected board authentication\r\n");
+        WIFI_CloseClientConnection(WIFI_SOCKET);
+        return -1;
+    }
+    uart_log("> Board authenticated\r\n");
+    return 0;
+}
+
 int wifiTCPConnect(uint8_t *remote_ip)
 {
     uart_log("Opening TCP connection...\r\n");
@@ -185,7 +231,7 @@ int wifiTCPConnect(uint8_t *remote_ip)
     }
     uart_logf("> Connected to %d.%d.%d.%d:%d\r\n",
               remote_ip[0], remote_ip[1], remote_ip[2], remote_ip[3], REMOTE_PORT);
-    return 0;
+    return wifiTCPAuthenticate();
 }
 
 int wifiTCPSend(const char *data)
@@ -208,6 +254,11 @@ int wifiTCPSend(const char *data)
             TCPConnected = 0;
             return -1;
         }
+        if (wifiTCPAuthenticate() != 0)
+        {
+            TCPConnected = 0;
+            return -1;
+        }
 
         uart_log("> Reconnected, retrying send...\r\n");
 
@@ -277,6 +328,62 @@ void Buzzer_On(void)     { HAL_GPIO_WritePin(BUZZER_PORT, BUZZER_PIN, GPIO_PIN_S
 void Buzzer_Off(void)    { HAL_GPIO_WritePin(BUZZER_PORT, BUZZER_PIN, GPIO_PIN_RESET); }
 void Buzzer_Toggle(void) { HAL_GPIO_TogglePin(BUZZER_PORT, BUZZER_PIN); }
 
+// Returns to normal monitoring after a confirmed fall, by NFC tap or the
+// server's RESET_FALL
+static void clear_fall(void)
+{
+    fall_state = STATE_NORMAL;
+    fallStatus = 0;
+    buzzer_silenced = 0;
+    Buzzer_Off();
+    BSP_LED_Off(LED2);
+
+    // Triple-blink confirmation pattern
+    for (int b = 0; b < 3; b++)
+    {
+        BSP_LED_On(LED2);
+        HAL_Delay(80);
+        BSP_LED_Off(LED2);
+        HAL_Delay(80);
+    }
+}
+
+// Server commands, see board_commands.h
+void board_command_silence(void)
+{
+    buzzer_silenced = 1;
+    Buzzer_Off();
+    uart_log("Buzzer silenced by server\r\n");
+}
+
+void board_command_locate(void)
+{
+    for (int b = 0; b < 3; b++)
+    {
+        Buzzer_On();
+        HAL_Delay(150);
+        Buzzer_Off();
+        HAL_Delay(150);
+    }
+}
+
+int board_command_reset_fall(void)
+{
+    if (fall_state != STATE_FALL_CONFIRMED)
+        return -1;
+    nfc_tap_detected = 0;
+    clear_fall();
+    uart_log("Fall reset by server\r\n");
+    return 0;
+}
+
+void board_command_status(char *detail, uint32_t size)
+{
+    snprintf(detail, size, "state=%d,profile=%lu,uptime=%lus",
+             fall_state, (unsigned long)thresholds.profile_version,
+             (unsigned long)(HAL_GetTick() / 1000));
+}
+
 
 int main(void)
 {
@@ -431,7 +538,7 @@ int main(void)
                     BSP_LED_Toggle(LED2);
                     last_led_toggle = current_time;
                 }
-                if((current_time - last_buzzer_toggle) > 200)
+                if(!buzzer_silenced && (current_time - last_buzzer_toggle) > 200)
                 {
                     Buzzer_Toggle();
                     last_buzzer_toggle = current_time;
@@ -467,7 +574,7 @@ int main(void)
                 case STATE_NORMAL:
                     // ONLY look for freefall - do NOT trigger on impact alone
                     // This prevents false triggers from simply lifting the board
-                    if(accel_magnitude < FREEFALL_THRESHOLD)
+                    if(accel_magnitude < thresholds.freefall)
                     {
                         fall_state = STATE_FREEFALL_DETECTED;
                         freefall_timestamp = current_time;
@@ -481,15 +588,15 @@ int main(void)
                 {
                     // Pressure rising = altitude dropping = consistent with a real fall.
                     // Used as a soft bonus vote: lowers impact thresholds slightly when confirmed.
-                    int baro_confirms = (pressure_delta > PRESSURE_FALL_THRESHOLD);
+                    int baro_confirms = (pressure_delta > thresholds.pressure_fall);
 
-                    float effective_impact = baro_confirms ? (IMPACT_THRESHOLD * 0.85f) : IMPACT_THRESHOLD;
-                    float effective_delta  = baro_confirms ? (IMPACT_DELTA  * 0.85f) : IMPACT_DELTA;
+                    float effective_impact = baro_confirms ? (thresholds.impact * 0.85f) : thresholds.impact;
+                    float effective_delta  = baro_confirms ? (thresholds.impact_delta * 0.85f) : thresholds.impact_delta;
 
                     if((accel_magnitude > effective_impact || accel_delta > effective_delta)
-                       && gyro_magnitude < IMPACT_GYRO_MAX)
+                       && gyro_magnitude < thresholds.impact_gyro_max)
                     {
-                        if((current_time - freefall_timestamp) < FALL_DETECTION_TIME)
+                        if((current_time - freefall_timestamp) < thresholds.fall_detection_ms)
                         {
                             fall_state = STATE_IMPACT_DETECTED;
                             impact_timestamp = current_time;
@@ -505,7 +612,7 @@ int main(void)
                             HAL_UART_Transmit(&huart1, (uint8_t*)buffer, strlen(buffer), HAL_MAX_DELAY);
                         }
                     }
-                    else if((current_time - freefall_timestamp) > FALL_DETECTION_TIME)
+                    else if((current_time - freefall_timestamp) > thresholds.fall_detection_ms)
                     {
                         fall_state = STATE_NORMAL;
                         sprintf(buffer, "*** No impact after freefall - returning to normal ***\r\n");
@@ -516,15 +623,16 @@ int main(void)
 
                 case STATE_IMPACT_DETECTED:
                     // Wait for board to be still (person lying on ground)
-                    if(accel_magnitude < LYING_THRESHOLD && gyro_magnitude < GYRO_THRESHOLD)
+                    if(accel_magnitude < thresholds.lying && gyro_magnitude < thresholds.gyro)
                     {
                         if(lying_timestamp == 0)
                         {
                             lying_timestamp = current_time;
-                            sprintf(buffer, "*** Board still - fall countdown started (2 sec)... ***\r\n");
+                            sprintf(buffer, "*** Board still - fall countdown started (%.1f sec)... ***\r\n",
+                                    thresholds.lying_detection_ms / 1000.0f);
                             HAL_UART_Transmit(&huart1, (uint8_t*)buffer, strlen(buffer), HAL_MAX_DELAY);
                         }
-                        else if((current_time - lying_timestamp) > LYING_DETECTION_TIME)
+                        else if((current_time - lying_timestamp) > thresholds.lying_detection_ms)
                         {
                             fall_state = STATE_FALL_CONFIRMED;
                             sprintf(buffer, "\r\n!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!\r\n");
@@ -538,7 +646,7 @@ int main(void)
                         }
                         else if(do_print)
                         {
-                            uint32_t time_remaining = LYING_DETECTION_TIME - (current_time - lying_timestamp);
+                            uint32_t time_remaining = thresholds.lying_detection_ms - (current_time - lying_timestamp);
                             sprintf(buffer, "*** Still lying... %.1f seconds remaining ***\r\n",
                                     time_remaining / 1000.0f);
                             HAL_UART_Transmit(&huart1, (uint8_t*)buffer, strlen(buffer), HAL_MAX_DELAY);
@@ -568,20 +676,7 @@ int main(void)
                     if (nfc_tap_detected)
                     {
                         nfc_tap_detected = 0;
-                        fall_state = STATE_NORMAL;
-                        fallStatus = 0;
-                        Buzzer_Off();
-                        BSP_LED_Off(LED2);
-
-                        // Triple-blink confirmation pattern
-                        for (int b = 0; b < 3; b++)
-                        {
-                            BSP_LED_On(LED2);
-                            HAL_Delay(80);
-                            BSP_LED_Off(LED2);
-                            HAL_Delay(80);
-                        }
-
+                        clear_fall();
                         uart_log("Fall acknowledged via NFC tap!\r\n");
                         break;
                     }
@@ -603,6 +698,10 @@ int main(void)
             prev_accel_magnitude = accel_magnitude;
         }
 
+        // ---- Server commands (every loop, answered straight away) ----
+        if(TCPConnected == 1)
+            board_commands_poll(WIFI_SOCKET, &thresholds);
+
         // ---- WiFi send (rate limited) ----
         if(TCPConnected == 1 && i >= 3)
         {